@@ -0,0 +1,393 @@
+package cr_migration
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqtool "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/miq-components"
+	miqkafka "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/miq-components/kafka"
+	"github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/tlstools"
+	miqutilsv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/miqutils"
+	routev1 "github.com/openshift/api/route/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// The objects used to have fixed names, they are now prefixed with the AppName so that several
+// instances can share a namespace. The migration is only recorded once the database files have been
+// copied to the renamed claim, the reconcile waits for it until then.
+func migrate20261018120000(cr *miqv1alpha1.ManageIQ, c client.Client, scheme *runtime.Scheme) (*miqv1alpha1.ManageIQ, error) {
+	migrationId := "20261018120000"
+	for _, migration := range cr.Spec.MigrationsRan {
+		if migration == migrationId {
+			return cr, nil
+		}
+	}
+
+	// Stop the application before the database files are copied
+	for _, name := range []string{"httpd", "memcached", "orchestrator", "postgresql"} {
+		if err := deleteLegacyObject(cr, c, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: name}}); err != nil {
+			return cr, err
+		}
+	}
+
+	// The encryption key and the certificate cannot be regenerated without losing data
+	if err := copyLegacySecret(cr, c, scheme, "app-secrets"); err != nil {
+		return cr, err
+	}
+	if cr.Spec.TLSSecret == "" {
+		if err := copyLegacySecret(cr, c, scheme, "tls-secret"); err != nil {
+			return cr, err
+		}
+	}
+
+	// The database certificate is reissued for the prefixed name with the root CA of the internal
+	// certificates. Without the CA the database secret keeps pointing at the postgresql Service,
+	// which is then kept for verify-full.
+	if err := reissuePostgresqlCertificate(cr, c); err != nil {
+		return cr, err
+	}
+	keepPostgresqlService := !postgresqlCertificateValidFor(cr, c, miqtool.ResourceName(cr, "postgresql"))
+	if !keepPostgresqlService {
+		if err := updateLegacyDatabaseHostname(cr, c); err != nil {
+			return cr, err
+		}
+	}
+
+	if done, err := migrateLegacyPostgresqlVolume(cr, c, scheme); err != nil || !done {
+		return cr, err
+	}
+
+	legacyObjects := []client.Object{
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "api-httpd-configs"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "httpd-auth-configs"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "httpd-configs"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "postgresql-configs"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "remote-console-httpd-configs"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "ui-httpd-configs"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "app-secrets"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "httpd"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "httpd-dbus-api"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "memcached"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "opentofu-runner"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "remote-console"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "ui"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "web-service"}},
+		&networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "httpd"}},
+		&routev1.Route{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "httpd"}},
+	}
+	if cr.Spec.TLSSecret == "" {
+		legacyObjects = append(legacyObjects, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "tls-secret"}})
+	}
+	if !keepPostgresqlService {
+		legacyObjects = append(legacyObjects, &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "postgresql"}})
+	}
+	if miqtool.ResourceName(cr, "automation") != "manageiq-automation" {
+		legacyObjects = append(legacyObjects,
+			&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "manageiq-automation"}},
+			&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "manageiq-automation"}},
+			&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "manageiq-automation"}},
+		)
+	}
+	for _, obj := range legacyObjects {
+		if err := deleteLegacyObject(cr, c, obj); err != nil {
+			return cr, err
+		}
+	}
+
+	for _, topic := range miqkafka.KafkaTopicNames() {
+		if err := deleteLegacyKafkaTopic(cr, c, topic); err != nil {
+			return cr, err
+		}
+	}
+
+	cr.Spec.MigrationsRan = append(cr.Spec.MigrationsRan, migrationId)
+
+	return cr, nil
+}
+
+// deleteLegacyKafkaTopic deletes a KafkaTopic named after its topic. The topic operator is told to
+// leave the topic in Kafka, it is taken over by the KafkaTopic named after the AppName.
+func deleteLegacyKafkaTopic(cr *miqv1alpha1.ManageIQ, c client.Client, name string) error {
+	topic := &unstructured.Unstructured{}
+	topic.SetGroupVersionKind(schema.GroupVersionKind{Group: "kafka.strimzi.io", Kind: "KafkaTopic", Version: "v1beta2"})
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: name}, topic); errors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if !metav1.IsControlledBy(topic, cr) {
+		return nil
+	}
+
+	if annotations := topic.GetAnnotations(); annotations["strimzi.io/managed"] != "false" {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations["strimzi.io/managed"] = "false"
+		topic.SetAnnotations(annotations)
+		if err := c.Update(context.TODO(), topic); err != nil {
+			return err
+		}
+	}
+
+	return deleteLegacyObject(cr, c, topic)
+}
+
+// deleteLegacyObject deletes an object with a fixed name if it is controlled by the CR, objects of
+// other instances or of the user are left alone
+func deleteLegacyObject(cr *miqv1alpha1.ManageIQ, c client.Client, obj client.Object) error {
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj); errors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if !metav1.IsControlledBy(obj, cr) {
+		return nil
+	}
+
+	if err := c.Delete(context.TODO(), obj, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// copyLegacySecret copies the content of a secret with a fixed name to its prefixed name, the
+// legacy secret is deleted with the other legacy objects
+func copyLegacySecret(cr *miqv1alpha1.ManageIQ, c client.Client, scheme *runtime.Scheme, name string) error {
+	legacySecret := &corev1.Secret{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: name}, legacySecret); errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if !metav1.IsControlledBy(legacySecret, cr) {
+		return nil
+	}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, name)}}
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(secret), secret); err == nil {
+		return nil
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	secret.Labels = legacySecret.Labels
+	secret.Data = legacySecret.Data
+	secret.Type = legacySecret.Type
+	if err := controllerutil.SetControllerReference(cr, secret, scheme); err != nil {
+		return err
+	}
+
+	return c.Create(context.TODO(), secret)
+}
+
+// postgresqlCertificateValidFor returns whether the internal certificate of the database, if any, is
+// valid for a host name
+func postgresqlCertificateValidFor(cr *miqv1alpha1.ManageIQ, c client.Client, hostname string) bool {
+	secret := miqtool.InternalCertificatesSecret(cr, c)
+	if secret.Data["postgresql_crt"] == nil || secret.Data["postgresql_key"] == nil {
+		return true
+	}
+
+	block, _ := pem.Decode(secret.Data["postgresql_crt"])
+	if block == nil {
+		return false
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+
+	return certificate.VerifyHostname(hostname) == nil
+}
+
+// reissuePostgresqlCertificate signs a database certificate valid for both the prefixed and the legacy
+// Service name when the internal certificate is not valid for the prefixed name and the root CA key is
+// part of the internal certificates secret
+func reissuePostgresqlCertificate(cr *miqv1alpha1.ManageIQ, c client.Client) error {
+	hostname := miqtool.ResourceName(cr, "postgresql")
+	if postgresqlCertificateValidFor(cr, c, hostname) {
+		return nil
+	}
+
+	secret := miqtool.InternalCertificatesSecret(cr, c)
+	if secret.Data["root_crt"] == nil || secret.Data["root_key"] == nil {
+		return nil
+	}
+
+	crt, key, err := tlstools.GenerateSignedCrt([]string{hostname, "postgresql"}, secret.Data["root_crt"], secret.Data["root_key"])
+	if err != nil {
+		return fmt.Errorf("failed to reissue the postgresql certificate of %s: %w", secret.Name, err)
+	}
+	secret.Data["postgresql_crt"] = crt
+	secret.Data["postgresql_key"] = key
+
+	return c.Update(context.TODO(), secret)
+}
+
+// updateLegacyDatabaseHostname points the database secret at the renamed postgresql service
+func updateLegacyDatabaseHostname(cr *miqv1alpha1.ManageIQ, c client.Client) error {
+	secret := &corev1.Secret{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: cr.Spec.DatabaseSecret}, secret); errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if string(secret.Data["hostname"]) != "postgresql" {
+		return nil
+	}
+
+	secret.Data["hostname"] = []byte(miqtool.ResourceName(cr, "postgresql"))
+
+	return c.Update(context.TODO(), secret)
+}
+
+// migrateLegacyPostgresqlVolume copies the database files from the legacy "postgresql" claim to the
+// prefixed claim with a Job. A claim cannot be renamed and the operator has no access to the
+// PersistentVolumes to rebind them. The legacy claim is released rather than deleted, it can be
+// removed once the migrated database has been verified.
+func migrateLegacyPostgresqlVolume(cr *miqv1alpha1.ManageIQ, c client.Client, scheme *runtime.Scheme) (bool, error) {
+	legacyPVC := &corev1.PersistentVolumeClaim{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: "postgresql"}, legacyPVC); errors.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if !metav1.IsControlledBy(legacyPVC, cr) {
+		return true, nil
+	}
+
+	// The database has to be down before its files are copied
+	podList := &corev1.PodList{}
+	if err := c.List(context.TODO(), podList, client.InNamespace(cr.Namespace), client.MatchingLabels{"app": cr.Spec.AppName, "name": "postgresql"}); err != nil {
+		return false, err
+	} else if len(podList.Items) > 0 {
+		return false, nil
+	}
+
+	// The copy has to fit, the legacy claim may have been expanded past the spec
+	if size, err := resource.ParseQuantity(cr.Spec.DatabaseVolumeCapacity); err == nil && legacyPVC.Spec.Resources.Requests.Storage().Cmp(size) > 0 {
+		cr.Spec.DatabaseVolumeCapacity = legacyPVC.Spec.Resources.Requests.Storage().String()
+	}
+
+	pvc, mutateFunc := miqtool.PostgresqlPVC(cr, scheme)
+	if _, err := controllerutil.CreateOrUpdate(context.TODO(), c, pvc, mutateFunc); err != nil {
+		return false, err
+	}
+
+	job, mutateFunc := postgresqlVolumeMigrationJob(cr, c, scheme)
+	if _, err := controllerutil.CreateOrUpdate(context.TODO(), c, job, mutateFunc); err != nil {
+		return false, err
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return false, fmt.Errorf("the postgresql data copy job %s failed, the legacy postgresql claim has been left untouched", job.Name)
+		}
+	}
+	if job.Status.Succeeded == 0 {
+		return false, nil
+	}
+
+	if err := controllerutil.RemoveControllerReference(cr, legacyPVC, scheme); err != nil {
+		return false, err
+	}
+	if err := c.Update(context.TODO(), legacyPVC); err != nil {
+		return false, err
+	}
+
+	if err := c.Delete(context.TODO(), job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func postgresqlVolumeMigrationJob(cr *miqv1alpha1.ManageIQ, c client.Client, scheme *runtime.Scheme) (*batchv1.Job, controllerutil.MutateFn) {
+	var backoffLimit int32 = 2
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      miqtool.ResourceName(cr, "postgresql-migration"),
+			Namespace: cr.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, job, scheme); err != nil {
+			return err
+		}
+
+		miqtool.AddLabel("app", cr.Spec.AppName, &job.ObjectMeta)
+
+		// The pod template of a Job is immutable
+		if !job.CreationTimestamp.IsZero() {
+			return nil
+		}
+
+		job.Spec.BackoffLimit = &backoffLimit
+		job.Spec.Template.ObjectMeta.Labels = map[string]string{"app": cr.Spec.AppName, "name": "postgresql-migration"}
+		job.Spec.Template.Spec.Containers = []corev1.Container{
+			corev1.Container{
+				Name:            "postgresql-migration",
+				Image:           cr.Spec.PostgresqlImage,
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command:         []string{"/bin/bash", "-c", "cp -a /legacy/. /data/"},
+				SecurityContext: miqtool.DefaultSecurityContext(),
+				VolumeMounts: []corev1.VolumeMount{
+					corev1.VolumeMount{Name: "data", MountPath: "/data"},
+					corev1.VolumeMount{Name: "legacy", MountPath: "/legacy", ReadOnly: true},
+				},
+			},
+		}
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		job.Spec.Template.Spec.Volumes = []corev1.Volume{
+			corev1.Volume{
+				Name: "data",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: miqtool.ResourceName(cr, "postgresql")},
+				},
+			},
+			corev1.Volume{
+				Name: "legacy",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "postgresql", ReadOnly: true},
+				},
+			},
+		}
+
+		if cr.Spec.ImagePullSecret != "" {
+			job.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{corev1.LocalObjectReference{Name: cr.Spec.ImagePullSecret}}
+		}
+
+		miqutilsv1alpha1.SetPodTemplateNodeAffinity(&job.Spec.Template, cr.Namespace, c)
+		miqtool.AddNodeScheduling(cr, "postgresql", &job.Spec.Template)
+
+		return nil
+	}
+
+	return job, f
+}
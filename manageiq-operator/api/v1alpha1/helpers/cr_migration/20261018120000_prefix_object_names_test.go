@@ -0,0 +1,163 @@
+package cr_migration
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqtool "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/miq-components"
+	"github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/tlstools"
+)
+
+// TestMigratePostgresqlHostname checks where the database secret points once the objects have been
+// renamed, depending on the database certificate of the internal certificates secret
+func TestMigratePostgresqlHostname(t *testing.T) {
+	caCrt, caKey := testCA(t)
+	legacyCrt, legacyKey, err := tlstools.GenerateSignedCrt([]string{"postgresql"}, caCrt, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		certificates map[string][]byte
+		hostname     string
+		serviceKept  bool
+	}{
+		{
+			name:     "no database certificate",
+			hostname: "manageiq-postgresql",
+		},
+		{
+			name:         "legacy certificate reissued with the root CA",
+			certificates: map[string][]byte{"root_crt": caCrt, "root_key": caKey, "postgresql_crt": legacyCrt, "postgresql_key": legacyKey},
+			hostname:     "manageiq-postgresql",
+		},
+		{
+			name:         "legacy certificate without the root CA key",
+			certificates: map[string][]byte{"root_crt": caCrt, "postgresql_crt": legacyCrt, "postgresql_key": legacyKey},
+			hostname:     "postgresql",
+			serviceKept:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheme := testScheme(t)
+			cr := &miqv1alpha1.ManageIQ{ObjectMeta: metav1.ObjectMeta{Namespace: "manageiq", Name: "miq", UID: "miq-uid"}}
+			cr.Spec.AppName = "manageiq"
+			cr.Spec.DatabaseSecret = "postgresql-secrets"
+			cr.Spec.InternalCertificatesSecret = "internal-certificates-secret"
+
+			databaseSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: cr.Spec.DatabaseSecret},
+				Data:       map[string][]byte{"hostname": []byte("postgresql")},
+			}
+			certificatesSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: cr.Spec.InternalCertificatesSecret},
+				Data:       test.certificates,
+			}
+			legacyService := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "postgresql"}}
+			if err := controllerutil.SetControllerReference(cr, legacyService, scheme); err != nil {
+				t.Fatal(err)
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cr, databaseSecret, certificatesSecret, legacyService).Build()
+
+			cr, err := migrate20261018120000(cr, c, scheme)
+			if err != nil {
+				t.Fatalf("migrate20261018120000() failed: %v", err)
+			}
+			if !slices.Contains(cr.Spec.MigrationsRan, "20261018120000") {
+				t.Fatalf("the migration has not been recorded: %v", cr.Spec.MigrationsRan)
+			}
+
+			if err := c.Get(context.TODO(), client.ObjectKeyFromObject(databaseSecret), databaseSecret); err != nil {
+				t.Fatal(err)
+			}
+			hostname := string(databaseSecret.Data["hostname"])
+			if hostname != test.hostname {
+				t.Errorf("hostname = %q, want %q", hostname, test.hostname)
+			}
+			if !miqtool.InClusterPostgresqlHostname(cr, hostname) {
+				t.Errorf("hostname %q is not the in-cluster database", hostname)
+			}
+			if test.certificates != nil {
+				if err := verifyPostgresqlCertificate(c, cr, caCrt, hostname); err != nil {
+					t.Errorf("the postgresql certificate is not valid for %q: %v", hostname, err)
+				}
+			}
+
+			err = c.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: "postgresql"}, &corev1.Service{})
+			if serviceKept := !errors.IsNotFound(err); serviceKept != test.serviceKept {
+				t.Errorf("postgresql Service kept = %v, want %v (%v)", serviceKept, test.serviceKept, err)
+			}
+		})
+	}
+}
+
+// verifyPostgresqlCertificate verifies the database certificate against the root CA
+func verifyPostgresqlCertificate(c client.Client, cr *miqv1alpha1.ManageIQ, caCrt []byte, hostname string) error {
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(caCrt)
+
+	block, _ := pem.Decode(miqtool.InternalCertificatesSecret(cr, c).Data["postgresql_crt"])
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return err
+	}
+
+	_, err = certificate.Verify(x509.VerifyOptions{DNSName: hostname, Roots: roots})
+	return err
+}
+
+func testScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, miqv1alpha1.AddToScheme, routev1.AddToScheme} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return scheme
+}
+
+// testCA returns a self-signed CA certificate and its key
+func testCA(t *testing.T) ([]byte, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "root"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
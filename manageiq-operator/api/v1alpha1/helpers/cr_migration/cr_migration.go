@@ -1,6 +1,8 @@
 package cr_migration
 
 import (
+	"slices"
+
 	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -13,8 +15,19 @@ func Migrate(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtime.Sch
 		cr = migrate20210504113000(cr)
 		cr = migrate20240508124600(cr, client, scheme)
 
+		var err error
+		if cr, err = migrate20261018120000(cr, client, scheme); err != nil {
+			return err
+		}
+
 		return nil
 	}
 
 	return cr, f
 }
+
+// Complete returns whether all the migrations have been applied to the CR, the migrations waiting on
+// the cluster are applied by a later reconcile
+func Complete(cr *miqv1alpha1.ManageIQ) bool {
+	return slices.Contains(cr.Spec.MigrationsRan, "20261018120000")
+}
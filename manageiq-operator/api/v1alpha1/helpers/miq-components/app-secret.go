@@ -12,10 +12,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
-const appSecretName = "app-secrets"
+func appSecretName(cr *miqv1alpha1.ManageIQ) string {
+	return ResourceName(cr, "app-secrets")
+}
 
 func ManageAppSecret(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtime.Scheme) (*corev1.Secret, controllerutil.MutateFn) {
-	secretKey := types.NamespacedName{Namespace: cr.ObjectMeta.Namespace, Name: appSecretName}
+	secretKey := types.NamespacedName{Namespace: cr.ObjectMeta.Namespace, Name: appSecretName(cr)}
 	secret := &corev1.Secret{}
 	secretErr := client.Get(context.TODO(), secretKey, secret)
 	if secretErr != nil {
@@ -43,7 +45,7 @@ func defaultAppSecret(cr *miqv1alpha1.ManageIQ) *corev1.Secret {
 
 	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      appSecretName,
+			Name:      appSecretName(cr),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 		StringData: secretData,
@@ -9,10 +9,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
+// The names of the application ConfigMaps are passed to the orchestrator, which mounts them in the
+// worker pods, see updateOrchestratorEnv
 func ApplicationUiHttpdConfigMap(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, client client.Client) (*corev1.ConfigMap, controllerutil.MutateFn) {
 	configMap := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "ui-httpd-configs",
+			Name:      ResourceName(cr, "ui-httpd-configs"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 		Data: make(map[string]string),
@@ -43,7 +45,7 @@ func ApplicationUiHttpdConfigMap(cr *miqv1alpha1.ManageIQ, scheme *runtime.Schem
 func ApplicationApiHttpdConfigMap(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, client client.Client) (*corev1.ConfigMap, controllerutil.MutateFn) {
 	configMap := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "api-httpd-configs",
+			Name:      ResourceName(cr, "api-httpd-configs"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 		Data: make(map[string]string),
@@ -74,7 +76,7 @@ func ApplicationApiHttpdConfigMap(cr *miqv1alpha1.ManageIQ, scheme *runtime.Sche
 func ApplicationRemoteConsoleHttpdConfigMap(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, client client.Client) (*corev1.ConfigMap, controllerutil.MutateFn) {
 	configMap := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "remote-console-httpd-configs",
+			Name:      ResourceName(cr, "remote-console-httpd-configs"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 		Data: make(map[string]string),
@@ -94,7 +96,7 @@ func ApplicationRemoteConsoleHttpdConfigMap(cr *miqv1alpha1.ManageIQ, scheme *ru
 			configMap.Data["ssl_config"] = appHttpdSslConfig()
 		}
 
-		configMap.Data["manageiq-http.conf"] = remoteConsoleHttpdConfig(protocol)
+		configMap.Data["manageiq-http.conf"] = remoteConsoleHttpdConfig(protocol, cr.Spec.AppName)
 
 		return nil
 	}
@@ -0,0 +1,563 @@
+package miqtools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqutilsv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/miqutils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// databaseEnv points the PostgreSQL client tools at the database described by the database secret,
+// the root certificate is read from the databaseRootCertificateVolume
+func databaseEnv(cr *miqv1alpha1.ManageIQ) []corev1.EnvVar {
+	secretEnvVar := func(name, key string, optionalKey bool) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: cr.Spec.DatabaseSecret},
+					Key:                  key,
+					Optional:             &optionalKey,
+				},
+			},
+		}
+	}
+
+	return []corev1.EnvVar{
+		secretEnvVar("PGDATABASE", "dbname", false),
+		secretEnvVar("PGHOST", "hostname", false),
+		secretEnvVar("PGPASSWORD", "password", false),
+		secretEnvVar("PGPORT", "port", false),
+		secretEnvVar("PGSSLMODE", "sslmode", true),
+		secretEnvVar("PGUSER", "username", false),
+		corev1.EnvVar{Name: "PGSSLROOTCERT", Value: "/run/secrets/postgresql/root.crt"},
+	}
+}
+
+// databaseRootCertificateVolume is mounted at /run/secrets/postgresql
+func databaseRootCertificateVolume(cr *miqv1alpha1.ManageIQ) corev1.Volume {
+	optional := true
+
+	return corev1.Volume{
+		Name: "database-secret",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: cr.Spec.DatabaseSecret,
+				Items:      []corev1.KeyToPath{corev1.KeyToPath{Key: "rootcertificate", Path: "root.crt"}},
+				Optional:   &optional,
+			},
+		},
+	}
+}
+
+func finalBackupName(cr *miqv1alpha1.ManageIQ) string {
+	return ResourceName(cr, "final-backup")
+}
+
+// FinalBackupPVC holds the database dump taken before the CR is torn down with the Snapshot
+// deletion policy. It is intentionally not owned by the CR so that it outlives it.
+func FinalBackupPVC(cr *miqv1alpha1.ManageIQ) (*corev1.PersistentVolumeClaim, controllerutil.MutateFn) {
+	storageReq, _ := resource.ParseQuantity(cr.Spec.DatabaseVolumeCapacity)
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      finalBackupName(cr),
+			Namespace: cr.ObjectMeta.Namespace,
+		},
+	}
+
+	f := func() error {
+		addAppLabel(cr.Spec.AppName, &pvc.ObjectMeta)
+		addBackupLabelDB(cr.Spec.BackupLabelName, &pvc.ObjectMeta)
+
+		// The spec of a bound claim is immutable
+		if pvc.CreationTimestamp.IsZero() {
+			pvc.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"}
+			pvc.Spec.Resources = corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{"storage": storageReq},
+			}
+
+			if cr.Spec.StorageClassName != "" {
+				pvc.Spec.StorageClassName = &cr.Spec.StorageClassName
+			}
+		}
+
+		return nil
+	}
+
+	return pvc, f
+}
+
+// FinalBackupJob runs pg_dump against the database described by the database secret and writes
+// the dump, along with the encryption key and the database secret, to the FinalBackupPVC. Both
+// secrets are owned by the CR and removed with it, the dump is of no use without them.
+func FinalBackupJob(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtime.Scheme) (*batchv1.Job, controllerutil.MutateFn) {
+	var backoffLimit int32 = 2
+
+	// The final backup is laid out like the dumps of a ManageIQBackup, so that it is restored the same way
+	container := corev1.Container{
+		Name:            "postgresql-backup",
+		Image:           cr.Spec.PostgresqlImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/bash", "-c", backupScript(&miqv1alpha1.ManageIQBackup{}, false, false)},
+		Env:             databaseEnv(cr),
+		SecurityContext: DefaultSecurityContext(),
+		VolumeMounts: append([]corev1.VolumeMount{
+			corev1.VolumeMount{Name: "backups", MountPath: "/backups"},
+			corev1.VolumeMount{Name: "database-secret", MountPath: "/run/secrets/postgresql", ReadOnly: true},
+		}, backupSecretVolumeMounts()...),
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      finalBackupName(cr),
+			Namespace: cr.ObjectMeta.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, job, scheme); err != nil {
+			return err
+		}
+
+		addAppLabel(cr.Spec.AppName, &job.ObjectMeta)
+
+		// The pod template of a Job is immutable
+		if !job.CreationTimestamp.IsZero() {
+			return nil
+		}
+
+		job.Spec.BackoffLimit = &backoffLimit
+		job.Spec.Template.ObjectMeta.Labels = map[string]string{"app": cr.Spec.AppName, "name": "postgresql-backup"}
+		job.Spec.Template.Spec.Containers = []corev1.Container{container}
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		job.Spec.Template.Spec.Volumes = append([]corev1.Volume{
+			corev1.Volume{
+				Name: "backups",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: finalBackupName(cr)},
+				},
+			},
+			databaseRootCertificateVolume(cr),
+		}, backupSecretVolumes(cr)...)
+
+		if cr.Spec.ImagePullSecret != "" {
+			job.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{corev1.LocalObjectReference{Name: cr.Spec.ImagePullSecret}}
+		}
+
+		miqutilsv1alpha1.SetPodTemplateNodeAffinity(&job.Spec.Template, cr.Namespace, client)
+		AddNodeScheduling(cr, "postgresql", &job.Spec.Template)
+
+		return nil
+	}
+
+	return job, f
+}
+
+// BackupNameLabel is set on the Jobs of a ManageIQBackup to the name of the ManageIQBackup
+const BackupNameLabel = "manageiq.org/backup-name"
+
+// backupRequestAnnotation is set on a backup Job to the take-backup annotation of the
+// ManageIQBackup it was created for
+const backupRequestAnnotation = "manageiq.org/backup-request"
+
+const defaultBackupS3Image = "quay.io/minio/mc:RELEASE.2024-11-21T17-21-54Z"
+
+// BackupClaimName returns the name of the PersistentVolumeClaim holding the backups of a ManageIQBackup
+func BackupClaimName(backup *miqv1alpha1.ManageIQBackup) string {
+	if backup.Spec.Storage.VolumeClaim.ClaimName == "" {
+		return backup.Name + "-backups"
+	}
+	return backup.Spec.Storage.VolumeClaim.ClaimName
+}
+
+// BackupJobLabels selects the Jobs of a ManageIQBackup, including the ones created by its CronJob
+func BackupJobLabels(backup *miqv1alpha1.ManageIQBackup) map[string]string {
+	return map[string]string{BackupNameLabel: backup.Name}
+}
+
+// BackupRequested returns whether none of the Jobs created for a ManageIQBackup without a schedule
+// took the backup requested by its current take-backup annotation, including the first one
+func BackupRequested(backup *miqv1alpha1.ManageIQBackup, jobs []batchv1.Job) bool {
+	request := backup.Annotations[miqv1alpha1.TakeBackupAnnotation]
+	for i := range jobs {
+		if metav1.IsControlledBy(&jobs[i], backup) && jobs[i].Annotations[backupRequestAnnotation] == request {
+			return false
+		}
+	}
+	return true
+}
+
+func backupMethod(backup *miqv1alpha1.ManageIQBackup) string {
+	if backup.Spec.Method == "" {
+		return miqv1alpha1.BackupMethodDump
+	}
+	return backup.Spec.Method
+}
+
+func backupRetention(backup *miqv1alpha1.ManageIQBackup) int32 {
+	if backup.Spec.Retention == nil {
+		return 7
+	}
+	return *backup.Spec.Retention
+}
+
+func backupS3Image(s3 *miqv1alpha1.BackupS3) string {
+	if s3.Image == "" {
+		return defaultBackupS3Image
+	}
+	return s3.Image
+}
+
+// backupS3Path is the path of the backups in the MinIO client alias named backup
+func backupS3Path(s3 *miqv1alpha1.BackupS3) string {
+	path := "backup/" + strings.Trim(s3.Bucket, "/")
+	if prefix := strings.Trim(s3.Prefix, "/"); prefix != "" {
+		path += "/" + prefix
+	}
+	return path
+}
+
+// BackupVolumeClaim holds the backups written to a volume claim. Like the FinalBackupPVC it is not
+// owned by the ManageIQBackup so that the backups outlive it.
+func BackupVolumeClaim(backup *miqv1alpha1.ManageIQBackup, cr *miqv1alpha1.ManageIQ) (*corev1.PersistentVolumeClaim, controllerutil.MutateFn) {
+	size := backup.Spec.Storage.VolumeClaim.Size
+	if size == "" {
+		size = cr.Spec.DatabaseVolumeCapacity
+	}
+	storageClassName := backup.Spec.Storage.VolumeClaim.StorageClassName
+	if storageClassName == "" {
+		storageClassName = cr.Spec.StorageClassName
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      BackupClaimName(backup),
+			Namespace: backup.Namespace,
+		},
+	}
+
+	f := func() error {
+		addAppLabel(cr.Spec.AppName, &pvc.ObjectMeta)
+		addBackupLabelDB(cr.Spec.BackupLabelName, &pvc.ObjectMeta)
+
+		// The spec of a bound claim is immutable
+		if pvc.CreationTimestamp.IsZero() {
+			storageReq, err := resource.ParseQuantity(size)
+			if err != nil {
+				return err
+			}
+
+			pvc.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"}
+			pvc.Spec.Resources = corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{"storage": storageReq},
+			}
+
+			if storageClassName != "" {
+				pvc.Spec.StorageClassName = &storageClassName
+			}
+		}
+
+		return nil
+	}
+
+	return pvc, f
+}
+
+// backupScript writes the database backup along with the encryption key and the database secret
+// to a timestamped directory under /backups. The directory is renamed into place once complete so
+// that partial backups never count against the retention. A base backup records its first WAL
+// segment, the archived WAL preceding the oldest base backup kept is removed with it.
+func backupScript(backup *miqv1alpha1.ManageIQBackup, prune bool, walArchive bool) string {
+	script := `set -e
+BACKUP_ID=$(date -u +%Y%m%d%H%M%S)
+WORK_DIR=/backups/.${BACKUP_ID}
+trap 'rm -rf "${WORK_DIR}"' EXIT
+mkdir -p "${WORK_DIR}/database-secret"
+`
+	if backupMethod(backup) == miqv1alpha1.BackupMethodBaseBackup {
+		script += `pg_basebackup --pgdata="${WORK_DIR}/basebackup" --format=tar --gzip --wal-method=stream --checkpoint=fast
+tar -xzOf "${WORK_DIR}/basebackup/base.tar.gz" backup_label | sed -n 's/^START WAL LOCATION: .*(file \([0-9A-F]*\))$/\1/p' > "${WORK_DIR}/wal-start"
+`
+	} else {
+		script += `pg_dump --format=custom --file="${WORK_DIR}/database.dump"
+`
+	}
+	script += `cp -L /run/secrets/database/* "${WORK_DIR}/database-secret/"
+cp -L /run/secrets/app/encryption-key "${WORK_DIR}/encryption-key"
+mv "${WORK_DIR}" "/backups/${BACKUP_ID}"
+echo "Backup ${BACKUP_ID} has been written"
+`
+	if prune {
+		script += fmt.Sprintf(`ls -1d /backups/[0-9]*/ | sort | head -n -%d | xargs -r rm -rf
+`, backupRetention(backup))
+	}
+	if prune && walArchive {
+		script += `OLDEST_DIR=$(ls -1d /backups/[0-9]*/ | sort | head -n 1)
+if [ -s "${OLDEST_DIR}wal-start" ] && [ -d /backups/wal ]; then
+  pg_archivecleanup /backups/wal "$(cat "${OLDEST_DIR}wal-start")"
+fi
+`
+	}
+
+	return script
+}
+
+// backupUploadScript copies the backup written by the backupScript to the bucket and removes the
+// backups exceeding the retention, along with the archived WAL preceding the oldest one kept. The
+// timeline prefix of the segment names is ignored like pg_archivecleanup does.
+func backupUploadScript(backup *miqv1alpha1.ManageIQBackup, walArchive bool) string {
+	script := fmt.Sprintf(`set -e
+MC="mc ${MC_FLAGS}"
+${MC} alias set backup "${S3_ENDPOINT}" "${AWS_ACCESS_KEY_ID}" "${AWS_SECRET_ACCESS_KEY}"
+for dir in /backups/[0-9]*/; do
+  ${MC} cp --recursive "${dir}" "%[1]s/$(basename "${dir}")/"
+done
+${MC} ls "%[1]s/" | while read -r line; do
+  case "${line##* }" in [0-9]*/) echo "${line##* }";; esac
+done | sort | head -n -%[2]d | while read -r old; do
+  ${MC} rm --recursive --force "%[1]s/${old}"
+done
+`, backupS3Path(backup.Spec.Storage.S3), backupRetention(backup))
+	if !walArchive {
+		return script
+	}
+
+	return script + fmt.Sprintf(`OLDEST=$(${MC} ls "%[1]s/" | while read -r line; do
+  case "${line##* }" in [0-9]*/) echo "${line##* }";; esac
+done | sort | head -n 1)
+START=$(${MC} cat "%[1]s/${OLDEST}wal-start" 2>/dev/null || true)
+if [ -n "${START}" ]; then
+  ${MC} ls "%[1]s/wal/" | while read -r line; do
+    segment="${line##* }"
+    case "${segment}" in *.history) continue;; esac
+    if [ "$(printf '%%s\n%%s\n' "${segment#????????}" "${START#????????}" | sort | head -n 1)" != "${START#????????}" ]; then
+      ${MC} rm "%[1]s/wal/${segment}"
+    fi
+  done
+fi
+`, backupS3Path(backup.Spec.Storage.S3))
+}
+
+// s3Container runs the MinIO client with the credentials of the bucket
+func s3Container(s3 *miqv1alpha1.BackupS3, name string, script string) corev1.Container {
+	secretEnvVar := func(name, key string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: s3.CredentialsSecret},
+					Key:                  key,
+				},
+			},
+		}
+	}
+
+	mcFlags := ""
+	if s3.InsecureSkipTLSVerify {
+		mcFlags = "--insecure"
+	}
+
+	return corev1.Container{
+		Name:            name,
+		Image:           backupS3Image(s3),
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/sh", "-c", script},
+		Env: []corev1.EnvVar{
+			secretEnvVar("AWS_ACCESS_KEY_ID", "access-key-id"),
+			secretEnvVar("AWS_SECRET_ACCESS_KEY", "secret-access-key"),
+			corev1.EnvVar{Name: "MC_CONFIG_DIR", Value: "/tmp/.mc"},
+			corev1.EnvVar{Name: "MC_FLAGS", Value: mcFlags},
+			corev1.EnvVar{Name: "S3_ENDPOINT", Value: s3.Endpoint},
+		},
+		SecurityContext: DefaultSecurityContext(),
+		VolumeMounts: []corev1.VolumeMount{
+			corev1.VolumeMount{Name: "backups", MountPath: "/backups"},
+			corev1.VolumeMount{Name: "mc-config", MountPath: "/tmp/.mc"},
+		},
+	}
+}
+
+// backupStorageVolumes returns the backups volume, the backup claim or a scratch directory which is
+// copied to the bucket
+func backupStorageVolumes(backup *miqv1alpha1.ManageIQBackup, readOnly bool) []corev1.Volume {
+	if backup.Spec.Storage.S3 != nil {
+		return []corev1.Volume{
+			corev1.Volume{Name: "backups", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+			corev1.Volume{Name: "mc-config", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		}
+	}
+
+	return []corev1.Volume{
+		corev1.Volume{
+			Name: "backups",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: BackupClaimName(backup), ReadOnly: readOnly},
+			},
+		},
+	}
+}
+
+// backupSecretVolumes are the database secret and the encryption key which the backupScript copies
+// next to the database backup, the backup cannot be restored without them
+func backupSecretVolumes(cr *miqv1alpha1.ManageIQ) []corev1.Volume {
+	return []corev1.Volume{
+		corev1.Volume{
+			Name:         "database-secret-backup",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: cr.Spec.DatabaseSecret}},
+		},
+		corev1.Volume{
+			Name: "app-secrets",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: appSecretName(cr),
+					Items:      []corev1.KeyToPath{corev1.KeyToPath{Key: "encryption-key", Path: "encryption-key"}},
+				},
+			},
+		},
+	}
+}
+
+func backupSecretVolumeMounts() []corev1.VolumeMount {
+	return []corev1.VolumeMount{
+		corev1.VolumeMount{Name: "database-secret-backup", MountPath: "/run/secrets/database", ReadOnly: true},
+		corev1.VolumeMount{Name: "app-secrets", MountPath: "/run/secrets/app", ReadOnly: true},
+	}
+}
+
+// backupPodTemplate runs the backup in the postgresql image. With an S3 storage the backup is
+// written by an init container and uploaded by the MinIO client.
+func backupPodTemplate(backup *miqv1alpha1.ManageIQBackup, cr *miqv1alpha1.ManageIQ, client client.Client) corev1.PodTemplateSpec {
+	s3 := backup.Spec.Storage.S3
+	walArchive := PostgresqlWalArchiveEnabled(cr) && cr.Spec.PostgresqlWalArchiveBackup == backup.Name
+
+	container := corev1.Container{
+		Name:            "postgresql-backup",
+		Image:           cr.Spec.PostgresqlImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/bash", "-c", backupScript(backup, s3 == nil, walArchive)},
+		Env:             databaseEnv(cr),
+		SecurityContext: DefaultSecurityContext(),
+		VolumeMounts: append([]corev1.VolumeMount{
+			corev1.VolumeMount{Name: "backups", MountPath: "/backups"},
+			corev1.VolumeMount{Name: "database-secret", MountPath: "/run/secrets/postgresql", ReadOnly: true},
+		}, backupSecretVolumeMounts()...),
+	}
+
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{"app": cr.Spec.AppName, "name": "postgresql-backup", BackupNameLabel: backup.Name},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Volumes:       append(backupStorageVolumes(backup, false), databaseRootCertificateVolume(cr)),
+		},
+	}
+	template.Spec.Volumes = append(template.Spec.Volumes, backupSecretVolumes(cr)...)
+
+	if s3 == nil {
+		template.Spec.Containers = []corev1.Container{container}
+	} else {
+		template.Spec.InitContainers = []corev1.Container{container}
+		template.Spec.Containers = []corev1.Container{s3Container(s3, "upload", backupUploadScript(backup, walArchive))}
+	}
+
+	if cr.Spec.ImagePullSecret != "" {
+		template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{corev1.LocalObjectReference{Name: cr.Spec.ImagePullSecret}}
+	}
+
+	miqutilsv1alpha1.SetPodTemplateNodeAffinity(&template, cr.Namespace, client)
+	AddNodeScheduling(cr, "postgresql", &template)
+
+	// The claim also receives the WAL of the running database, the backup is taken on its node
+	if walArchive && s3 == nil {
+		template.Spec.Affinity = miqutilsv1alpha1.MergeAffinity(template.Spec.Affinity, &corev1.Affinity{PodAffinity: &corev1.PodAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+				corev1.PodAffinityTerm{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": cr.Spec.AppName, "name": "postgresql"}},
+					TopologyKey:   "kubernetes.io/hostname",
+				},
+			},
+		}})
+	}
+
+	return template
+}
+
+// BackupJob takes a single backup for a ManageIQBackup without a schedule
+func BackupJob(backup *miqv1alpha1.ManageIQBackup, cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtime.Scheme) (*batchv1.Job, controllerutil.MutateFn) {
+	var backoffLimit int32 = 2
+
+	// The pod template of a Job is immutable, each backup gets a Job of its own
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%d", backup.Name, time.Now().Unix()),
+			Namespace: backup.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(backup, job, scheme); err != nil {
+			return err
+		}
+
+		addAppLabel(cr.Spec.AppName, &job.ObjectMeta)
+		AddLabel(BackupNameLabel, backup.Name, &job.ObjectMeta)
+		metav1.SetMetaDataAnnotation(&job.ObjectMeta, backupRequestAnnotation, backup.Annotations[miqv1alpha1.TakeBackupAnnotation])
+
+		if !job.CreationTimestamp.IsZero() {
+			return nil
+		}
+
+		job.Spec.BackoffLimit = &backoffLimit
+		job.Spec.Template = backupPodTemplate(backup, cr, client)
+
+		return nil
+	}
+
+	return job, f
+}
+
+// BackupCronJob takes the backups of a ManageIQBackup on its schedule
+func BackupCronJob(backup *miqv1alpha1.ManageIQBackup, cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtime.Scheme) (*batchv1.CronJob, controllerutil.MutateFn) {
+	var backoffLimit int32 = 2
+	var historyLimit int32 = 3
+
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backup.Name,
+			Namespace: backup.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(backup, cronJob, scheme); err != nil {
+			return err
+		}
+
+		addAppLabel(cr.Spec.AppName, &cronJob.ObjectMeta)
+		AddLabel(BackupNameLabel, backup.Name, &cronJob.ObjectMeta)
+
+		cronJob.Spec.Schedule = backup.Spec.Schedule
+		cronJob.Spec.Suspend = backup.Spec.Suspend
+		cronJob.Spec.ConcurrencyPolicy = batchv1.ForbidConcurrent
+		cronJob.Spec.SuccessfulJobsHistoryLimit = &historyLimit
+		cronJob.Spec.FailedJobsHistoryLimit = &historyLimit
+		cronJob.Spec.JobTemplate.ObjectMeta.Labels = BackupJobLabels(backup)
+		cronJob.Spec.JobTemplate.Spec.BackoffLimit = &backoffLimit
+		cronJob.Spec.JobTemplate.Spec.Template = backupPodTemplate(backup, cr, client)
+
+		return nil
+	}
+
+	return cronJob, f
+}
@@ -35,12 +35,28 @@ func databaseRegion(cr *miqv1alpha1.ManageIQ) string {
 
 func databaseSecret(cr *miqv1alpha1.ManageIQ) string {
 	if cr.Spec.DatabaseSecret == "" {
-		return "postgresql-secrets"
+		return ResourceName(cr, "postgresql-secrets")
 	} else {
 		return cr.Spec.DatabaseSecret
 	}
 }
 
+func databaseSnapshotPauseOrchestrator(cr *miqv1alpha1.ManageIQ) bool {
+	if cr.Spec.DatabaseSnapshotPauseOrchestrator == nil {
+		return false
+	} else {
+		return *cr.Spec.DatabaseSnapshotPauseOrchestrator
+	}
+}
+
+func databaseSnapshotRetention(cr *miqv1alpha1.ManageIQ) int32 {
+	if cr.Spec.DatabaseSnapshotRetention == nil {
+		return 7
+	} else {
+		return *cr.Spec.DatabaseSnapshotRetention
+	}
+}
+
 func databaseVolumeCapacity(cr *miqv1alpha1.ManageIQ) string {
 	if cr.Spec.DatabaseVolumeCapacity == "" {
 		return "15Gi"
@@ -49,6 +65,14 @@ func databaseVolumeCapacity(cr *miqv1alpha1.ManageIQ) string {
 	}
 }
 
+func deletionPolicy(cr *miqv1alpha1.ManageIQ) string {
+	if cr.Spec.DeletionPolicy == "" {
+		return miqv1alpha1.DeletionPolicyDelete
+	} else {
+		return cr.Spec.DeletionPolicy
+	}
+}
+
 func deployMessagingService(cr *miqv1alpha1.ManageIQ) bool {
 	if cr.Spec.DeployMessagingService == nil {
 		return true
@@ -57,6 +81,22 @@ func deployMessagingService(cr *miqv1alpha1.ManageIQ) bool {
 	}
 }
 
+func deployPgbouncer(cr *miqv1alpha1.ManageIQ) bool {
+	if cr.Spec.DeployPgbouncer == nil {
+		return false
+	} else {
+		return *cr.Spec.DeployPgbouncer
+	}
+}
+
+func driftDetection(cr *miqv1alpha1.ManageIQ) string {
+	if cr.Spec.DriftDetection == "" {
+		return miqv1alpha1.DriftDetectionCorrect
+	} else {
+		return cr.Spec.DriftDetection
+	}
+}
+
 func enableApplicationLocalLogin(cr *miqv1alpha1.ManageIQ) bool {
 	if cr.Spec.EnableApplicationLocalLogin == nil {
 		return true
@@ -81,6 +121,22 @@ func enforceWorkerResourceConstraints(cr *miqv1alpha1.ManageIQ) bool {
 	}
 }
 
+func externalDatabasePort(cr *miqv1alpha1.ManageIQ) int32 {
+	if cr.Spec.ExternalDatabasePort == nil {
+		return 5432
+	} else {
+		return *cr.Spec.ExternalDatabasePort
+	}
+}
+
+func externalDatabaseSSLMode(cr *miqv1alpha1.ManageIQ) string {
+	if cr.Spec.ExternalDatabaseSSLMode == "" {
+		return "verify-full"
+	} else {
+		return cr.Spec.ExternalDatabaseSSLMode
+	}
+}
+
 func httpdAuthenticationType(cr *miqv1alpha1.ManageIQ) string {
 	if cr.Spec.HttpdAuthenticationType == "" {
 		return "internal"
@@ -89,6 +145,14 @@ func httpdAuthenticationType(cr *miqv1alpha1.ManageIQ) string {
 	}
 }
 
+func httpdAutoscaleTargetCPUUtilization(cr *miqv1alpha1.ManageIQ) int32 {
+	if cr.Spec.HttpdAutoscaleTargetCPUUtilization == nil {
+		return 75
+	} else {
+		return *cr.Spec.HttpdAutoscaleTargetCPUUtilization
+	}
+}
+
 func httpdImage(cr *miqv1alpha1.ManageIQ) string {
 	if cr.Spec.HttpdImage != "" {
 		return cr.Spec.HttpdImage
@@ -122,6 +186,14 @@ func httpdImageTag(cr *miqv1alpha1.ManageIQ) string {
 	}
 }
 
+func httpdReplicas(cr *miqv1alpha1.ManageIQ) int32 {
+	if cr.Spec.HttpdReplicas == nil {
+		return 1
+	} else {
+		return *cr.Spec.HttpdReplicas
+	}
+}
+
 func imagePullSecretName(cr *miqv1alpha1.ManageIQ, client client.Client) string {
 	// If the CR does not have the ImagePullSecret defined, set it to 'image-pull-secret' if a secret with that name exists
 	if cr.Spec.ImagePullSecret == "" {
@@ -146,6 +218,14 @@ func kafkaVolumeCapacity(cr *miqv1alpha1.ManageIQ) string {
 	}
 }
 
+func maintenanceMode(cr *miqv1alpha1.ManageIQ) bool {
+	if cr.Spec.MaintenanceMode == nil {
+		return false
+	} else {
+		return *cr.Spec.MaintenanceMode
+	}
+}
+
 func memcachedImage(cr *miqv1alpha1.ManageIQ) string {
 	if cr.Spec.MemcachedImage == "" {
 		return memcachedImageName(cr) + ":" + memcachedImageTag(cr)
@@ -242,6 +322,62 @@ func orchestratorInitialDelay(cr *miqv1alpha1.ManageIQ) string {
 	}
 }
 
+func pgbouncerDefaultPoolSize(cr *miqv1alpha1.ManageIQ) int32 {
+	if cr.Spec.PgbouncerDefaultPoolSize == nil {
+		return 20
+	} else {
+		return *cr.Spec.PgbouncerDefaultPoolSize
+	}
+}
+
+func pgbouncerImage(cr *miqv1alpha1.ManageIQ) string {
+	if cr.Spec.PgbouncerImage == "" {
+		return "ghcr.io/cloudnative-pg/pgbouncer:1.23.0"
+	} else {
+		return cr.Spec.PgbouncerImage
+	}
+}
+
+func pgbouncerMaxClientConnections(cr *miqv1alpha1.ManageIQ) int32 {
+	if cr.Spec.PgbouncerMaxClientConnections == nil {
+		return 1000
+	} else {
+		return *cr.Spec.PgbouncerMaxClientConnections
+	}
+}
+
+func pgbouncerMaxDatabaseConnections(cr *miqv1alpha1.ManageIQ) int32 {
+	if cr.Spec.PgbouncerMaxDatabaseConnections == nil {
+		return 0
+	} else {
+		return *cr.Spec.PgbouncerMaxDatabaseConnections
+	}
+}
+
+func pgbouncerPoolMode(cr *miqv1alpha1.ManageIQ) string {
+	if cr.Spec.PgbouncerPoolMode == "" {
+		return "session"
+	} else {
+		return cr.Spec.PgbouncerPoolMode
+	}
+}
+
+func postgresqlAllowEviction(cr *miqv1alpha1.ManageIQ) bool {
+	if cr.Spec.PostgresqlAllowEviction == nil {
+		return false
+	} else {
+		return *cr.Spec.PostgresqlAllowEviction
+	}
+}
+
+func postgresqlAutoTune(cr *miqv1alpha1.ManageIQ) bool {
+	if cr.Spec.PostgresqlAutoTune == nil {
+		return false
+	} else {
+		return *cr.Spec.PostgresqlAutoTune
+	}
+}
+
 func postgresqlImage(cr *miqv1alpha1.ManageIQ) string {
 	if cr.Spec.PostgresqlImage == "" {
 		return postgresqlImageName(cr) + ":" + postgresqlImageTag(cr)
@@ -274,6 +410,30 @@ func postgresqlMaxConnections(cr *miqv1alpha1.ManageIQ) string {
 	}
 }
 
+func postgresqlMode(cr *miqv1alpha1.ManageIQ) string {
+	if cr.Spec.PostgresqlMode == "" {
+		return miqv1alpha1.PostgresqlModeStandalone
+	} else {
+		return cr.Spec.PostgresqlMode
+	}
+}
+
+func postgresqlPrimary(cr *miqv1alpha1.ManageIQ) int32 {
+	if cr.Spec.PostgresqlPrimary == nil {
+		return 0
+	} else {
+		return *cr.Spec.PostgresqlPrimary
+	}
+}
+
+func postgresqlReplicas(cr *miqv1alpha1.ManageIQ) int32 {
+	if cr.Spec.PostgresqlReplicas == nil {
+		return 2
+	} else {
+		return *cr.Spec.PostgresqlReplicas
+	}
+}
+
 func postgresqlSharedBuffers(cr *miqv1alpha1.ManageIQ) string {
 	if cr.Spec.PostgresqlSharedBuffers == "" {
 		return "1GB"
@@ -284,7 +444,7 @@ func postgresqlSharedBuffers(cr *miqv1alpha1.ManageIQ) string {
 
 func serverGuid(cr *miqv1alpha1.ManageIQ, c *client.Client) string {
 	if cr.Spec.ServerGuid == "" {
-		if pod := orchestratorPod(*c); pod != nil {
+		if pod := orchestratorPod(cr, *c); pod != nil {
 			for _, env := range pod.Spec.Containers[0].Env {
 				if env.Name == "GUID" {
 					return env.Value
@@ -305,41 +465,95 @@ func zookeeperVolumeCapacity(cr *miqv1alpha1.ManageIQ) string {
 	}
 }
 
+// DefaultCR sets the defaults for the spec fields which do not depend on the
+// state of the cluster. It is used by the mutating webhook and by ManageCR.
+func DefaultCR(cr *miqv1alpha1.ManageIQ) {
+	varDeployMessagingService := deployMessagingService(cr)
+	varDeployPgbouncer := deployPgbouncer(cr)
+	varEnableApplicationLocalLogin := enableApplicationLocalLogin(cr)
+	varEnableSSO := enableSSO(cr)
+	varEnforceWorkerResourceConstraints := enforceWorkerResourceConstraints(cr)
+	varHttpdReplicas := httpdReplicas(cr)
+	varMaintenanceMode := maintenanceMode(cr)
+	varOIDCOAuthIntrospectionSSLVerify := oidcOAuthIntrospectionSSLVerify(cr)
+	varPostgresqlAllowEviction := postgresqlAllowEviction(cr)
+	varPostgresqlAutoTune := postgresqlAutoTune(cr)
+
+	cr.Spec.AppName = appName(cr)
+	cr.Spec.BackupLabelName = backupLabelName(cr)
+	cr.Spec.DatabaseRegion = databaseRegion(cr)
+	cr.Spec.DatabaseSecret = databaseSecret(cr)
+	if cr.Spec.DatabaseSnapshotSchedule != "" {
+		varDatabaseSnapshotPauseOrchestrator := databaseSnapshotPauseOrchestrator(cr)
+		varDatabaseSnapshotRetention := databaseSnapshotRetention(cr)
+		cr.Spec.DatabaseSnapshotPauseOrchestrator = &varDatabaseSnapshotPauseOrchestrator
+		cr.Spec.DatabaseSnapshotRetention = &varDatabaseSnapshotRetention
+	}
+	cr.Spec.DatabaseVolumeCapacity = databaseVolumeCapacity(cr)
+	cr.Spec.DeletionPolicy = deletionPolicy(cr)
+	cr.Spec.DeployMessagingService = &varDeployMessagingService
+	cr.Spec.DeployPgbouncer = &varDeployPgbouncer
+	cr.Spec.DriftDetection = driftDetection(cr)
+	cr.Spec.EnableApplicationLocalLogin = &varEnableApplicationLocalLogin
+	cr.Spec.EnableSSO = &varEnableSSO
+	cr.Spec.EnforceWorkerResourceConstraints = &varEnforceWorkerResourceConstraints
+	if cr.Spec.ExternalDatabaseHost != "" {
+		varExternalDatabasePort := externalDatabasePort(cr)
+		cr.Spec.ExternalDatabasePort = &varExternalDatabasePort
+		cr.Spec.ExternalDatabaseSSLMode = externalDatabaseSSLMode(cr)
+	}
+	cr.Spec.HttpdAuthenticationType = httpdAuthenticationType(cr)
+	if cr.Spec.HttpdAutoscaleMaxReplicas != nil {
+		varHttpdAutoscaleTargetCPUUtilization := httpdAutoscaleTargetCPUUtilization(cr)
+		cr.Spec.HttpdAutoscaleTargetCPUUtilization = &varHttpdAutoscaleTargetCPUUtilization
+	}
+	cr.Spec.HttpdImage = httpdImage(cr)
+	cr.Spec.HttpdReplicas = &varHttpdReplicas
+	cr.Spec.KafkaVolumeCapacity = kafkaVolumeCapacity(cr)
+	cr.Spec.MaintenanceMode = &varMaintenanceMode
+	cr.Spec.MemcachedImage = memcachedImage(cr)
+	cr.Spec.MemcachedMaxConnection = memcachedMaxConnection(cr)
+	cr.Spec.MemcachedMaxMemory = memcachedMaxMemory(cr)
+	cr.Spec.MemcachedSlabPageSize = memcachedSlabPageSize(cr)
+	cr.Spec.OIDCOAuthIntrospectionSSLVerify = &varOIDCOAuthIntrospectionSSLVerify
+	cr.Spec.OrchestratorImage = orchestratorImage(cr)
+	cr.Spec.OrchestratorInitialDelay = orchestratorInitialDelay(cr)
+	if *cr.Spec.DeployPgbouncer {
+		varPgbouncerDefaultPoolSize := pgbouncerDefaultPoolSize(cr)
+		varPgbouncerMaxClientConnections := pgbouncerMaxClientConnections(cr)
+		varPgbouncerMaxDatabaseConnections := pgbouncerMaxDatabaseConnections(cr)
+		cr.Spec.PgbouncerDefaultPoolSize = &varPgbouncerDefaultPoolSize
+		cr.Spec.PgbouncerImage = pgbouncerImage(cr)
+		cr.Spec.PgbouncerMaxClientConnections = &varPgbouncerMaxClientConnections
+		cr.Spec.PgbouncerMaxDatabaseConnections = &varPgbouncerMaxDatabaseConnections
+		cr.Spec.PgbouncerPoolMode = pgbouncerPoolMode(cr)
+	}
+	cr.Spec.PostgresqlAllowEviction = &varPostgresqlAllowEviction
+	cr.Spec.PostgresqlAutoTune = &varPostgresqlAutoTune
+	cr.Spec.PostgresqlImage = postgresqlImage(cr)
+	cr.Spec.PostgresqlMaxConnections = postgresqlMaxConnections(cr)
+	cr.Spec.PostgresqlMode = postgresqlMode(cr)
+	if cr.Spec.PostgresqlMode == miqv1alpha1.PostgresqlModeReplicated {
+		varPostgresqlPrimary := postgresqlPrimary(cr)
+		varPostgresqlReplicas := postgresqlReplicas(cr)
+		cr.Spec.PostgresqlPrimary = &varPostgresqlPrimary
+		cr.Spec.PostgresqlReplicas = &varPostgresqlReplicas
+	}
+	cr.Spec.PostgresqlSharedBuffers = postgresqlSharedBuffers(cr)
+	cr.Spec.ZookeeperVolumeCapacity = zookeeperVolumeCapacity(cr)
+
+	addBackupLabel(backupLabelName(cr), &cr.ObjectMeta)
+}
+
+// ManageCR fills in the remaining defaults, the image pull secret and server GUID
+// are looked up in the cluster and the server GUID requires the CR UID.
+// DefaultCR is applied again in case the CR was created without the webhook.
 func ManageCR(cr *miqv1alpha1.ManageIQ, c *client.Client) (*miqv1alpha1.ManageIQ, controllerutil.MutateFn) {
 	f := func() error {
-		varDeployMessagingService := deployMessagingService(cr)
-		varEnableApplicationLocalLogin := enableApplicationLocalLogin(cr)
-		varEnableSSO := enableSSO(cr)
-		varEnforceWorkerResourceConstraints := enforceWorkerResourceConstraints(cr)
-		varOIDCOAuthIntrospectionSSLVerify := oidcOAuthIntrospectionSSLVerify(cr)
-
-		cr.Spec.AppName = appName(cr)
-		cr.Spec.BackupLabelName = backupLabelName(cr)
-		cr.Spec.DatabaseRegion = databaseRegion(cr)
-		cr.Spec.DatabaseSecret = databaseSecret(cr)
-		cr.Spec.DatabaseVolumeCapacity = databaseVolumeCapacity(cr)
-		cr.Spec.DeployMessagingService = &varDeployMessagingService
-		cr.Spec.EnableApplicationLocalLogin = &varEnableApplicationLocalLogin
-		cr.Spec.EnableSSO = &varEnableSSO
-		cr.Spec.EnforceWorkerResourceConstraints = &varEnforceWorkerResourceConstraints
-		cr.Spec.HttpdAuthenticationType = httpdAuthenticationType(cr)
-		cr.Spec.HttpdImage = httpdImage(cr)
+		DefaultCR(cr)
+
 		cr.Spec.ImagePullSecret = imagePullSecretName(cr, *c)
-		cr.Spec.KafkaVolumeCapacity = kafkaVolumeCapacity(cr)
-		cr.Spec.MemcachedImage = memcachedImage(cr)
-		cr.Spec.MemcachedMaxConnection = memcachedMaxConnection(cr)
-		cr.Spec.MemcachedMaxMemory = memcachedMaxMemory(cr)
-		cr.Spec.MemcachedSlabPageSize = memcachedSlabPageSize(cr)
-		cr.Spec.OIDCOAuthIntrospectionSSLVerify = &varOIDCOAuthIntrospectionSSLVerify
-		cr.Spec.OrchestratorImage = orchestratorImage(cr)
-		cr.Spec.OrchestratorInitialDelay = orchestratorInitialDelay(cr)
-		cr.Spec.PostgresqlImage = postgresqlImage(cr)
-		cr.Spec.PostgresqlMaxConnections = postgresqlMaxConnections(cr)
-		cr.Spec.PostgresqlSharedBuffers = postgresqlSharedBuffers(cr)
 		cr.Spec.ServerGuid = serverGuid(cr, c)
-		cr.Spec.ZookeeperVolumeCapacity = zookeeperVolumeCapacity(cr)
-
-		addBackupLabel(backupLabelName(cr), &cr.ObjectMeta)
 
 		return nil
 	}
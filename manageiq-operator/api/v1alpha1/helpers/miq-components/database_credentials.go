@@ -0,0 +1,144 @@
+package miqtools
+
+import (
+	"time"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqutilsv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/miqutils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// DatabaseCredentialsRotatingCondition is True while the database password is rotated, and while
+// the orchestrator of an external database waits for a restart with the new password
+const DatabaseCredentialsRotatingCondition = "DatabaseCredentialsRotating"
+
+// DatabaseCredentialsRotatedAnnotation rolls the orchestrator, and so its workers, once the
+// password of the database deployed by the operator has been rotated
+const DatabaseCredentialsRotatedAnnotation = "manageiq.org/database-credentials-rotated"
+
+// The database connections authenticated with the former password are kept, the new password is
+// set with a psql variable so that it is not part of the command line
+const databaseCredentialsRotationScript = `set -e
+psql --set=ON_ERROR_STOP=1 --set=password="${NEW_PASSWORD}" <<'EOF'
+ALTER ROLE CURRENT_USER PASSWORD :'password';
+EOF
+`
+
+// DatabaseCredentialsRotating returns whether the database password is being rotated
+func DatabaseCredentialsRotating(cr *miqv1alpha1.ManageIQ) bool {
+	return apimeta.IsStatusConditionTrue(cr.Status.Conditions, DatabaseCredentialsRotatingCondition)
+}
+
+// DatabaseCredentialsRotationRequested returns whether the rotate-database-credentials annotation
+// asks for a rotation which has not been done yet
+func DatabaseCredentialsRotationRequested(cr *miqv1alpha1.ManageIQ) bool {
+	request, ok := cr.Annotations[miqv1alpha1.RotateDatabaseCredentialsAnnotation]
+	return ok && request != cr.Status.DatabaseCredentialsRotationRequest
+}
+
+// NextDatabaseCredentialsRotation returns the time of the first scheduled rotation after last, or
+// the zero time when no rotation is scheduled
+func NextDatabaseCredentialsRotation(cr *miqv1alpha1.ManageIQ, last time.Time) time.Time {
+	schedule, err := miqutilsv1alpha1.ParseSchedule(cr.Spec.DatabaseCredentialsRotationSchedule)
+	if cr.Spec.DatabaseCredentialsRotationSchedule == "" || err != nil {
+		return time.Time{}
+	}
+
+	return schedule.Next(last.UTC())
+}
+
+// DatabaseCredentialsRotationSecret holds the new password until it is set on the database and
+// copied to the database secret, the password is generated once
+func DatabaseCredentialsRotationSecret(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Secret, controllerutil.MutateFn) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceName(cr, "postgresql-credentials-rotation"),
+			Namespace: cr.ObjectMeta.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, secret, scheme); err != nil {
+			return err
+		}
+
+		addAppLabel(cr.Spec.AppName, &secret.ObjectMeta)
+
+		if len(secret.Data["password"]) == 0 {
+			secret.Data = map[string][]byte{"password": []byte(generatePassword())}
+		}
+
+		return nil
+	}
+
+	return secret, f
+}
+
+// DatabaseCredentialsRotationJob sets the password of the DatabaseCredentialsRotationSecret on the
+// role of the database secret, connecting with the current password
+func DatabaseCredentialsRotationJob(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtime.Scheme) (*batchv1.Job, controllerutil.MutateFn) {
+	var backoffLimit int32 = 2
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceName(cr, "postgresql-credentials-rotation"),
+			Namespace: cr.ObjectMeta.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, job, scheme); err != nil {
+			return err
+		}
+
+		addAppLabel(cr.Spec.AppName, &job.ObjectMeta)
+
+		// The pod template of a Job is immutable
+		if !job.CreationTimestamp.IsZero() {
+			return nil
+		}
+
+		newPassword := corev1.EnvVar{
+			Name: "NEW_PASSWORD",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: ResourceName(cr, "postgresql-credentials-rotation")},
+					Key:                  "password",
+				},
+			},
+		}
+
+		job.Spec.BackoffLimit = &backoffLimit
+		job.Spec.Template.ObjectMeta.Labels = map[string]string{"app": cr.Spec.AppName, "name": "postgresql-credentials-rotation"}
+		job.Spec.Template.Spec.Containers = []corev1.Container{corev1.Container{
+			Name:            "postgresql-credentials-rotation",
+			Image:           cr.Spec.PostgresqlImage,
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command:         []string{"/bin/bash", "-c", databaseCredentialsRotationScript},
+			Env:             append(databaseEnv(cr), newPassword),
+			SecurityContext: DefaultSecurityContext(),
+			VolumeMounts: []corev1.VolumeMount{
+				corev1.VolumeMount{Name: "database-secret", MountPath: "/run/secrets/postgresql", ReadOnly: true},
+			},
+		}}
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		job.Spec.Template.Spec.ServiceAccountName = defaultServiceAccountName(cr.Spec.AppName)
+		job.Spec.Template.Spec.Volumes = []corev1.Volume{databaseRootCertificateVolume(cr)}
+
+		if cr.Spec.ImagePullSecret != "" {
+			job.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{corev1.LocalObjectReference{Name: cr.Spec.ImagePullSecret}}
+		}
+
+		miqutilsv1alpha1.SetPodTemplateNodeAffinity(&job.Spec.Template, cr.Namespace, client)
+
+		return nil
+	}
+
+	return job, f
+}
@@ -0,0 +1,152 @@
+package miqtools
+
+import (
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqutilsv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/miqutils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// DatabaseMaintenanceTaskLabel is set on the Jobs of the maintenance CronJobs to their task
+const DatabaseMaintenanceTaskLabel = "manageiq.org/database-maintenance"
+
+// The database maintenance tasks, each one is run by its own CronJob
+const (
+	DatabaseMaintenanceReindex = "reindex"
+	DatabaseMaintenanceReport  = "report"
+	DatabaseMaintenanceVacuum  = "vacuum"
+)
+
+// DatabaseMaintenanceTasks lists the maintenance tasks in the order of the status
+var DatabaseMaintenanceTasks = []string{DatabaseMaintenanceReindex, DatabaseMaintenanceReport, DatabaseMaintenanceVacuum}
+
+// The report lists the largest tables with their dead tuples, it is written to the termination
+// message so that it shows up in the CR status
+const databaseMaintenanceReportScript = `set -eo pipefail
+psql --set=ON_ERROR_STOP=1 --no-align --field-separator=' ' --pset=footer=off --command="
+SELECT relid::regclass AS table,
+       pg_size_pretty(pg_total_relation_size(relid)) AS size,
+       n_live_tup AS live_tuples,
+       n_dead_tup AS dead_tuples,
+       round(100.0 * n_dead_tup / greatest(n_live_tup + n_dead_tup, 1), 1) AS dead_percent,
+       coalesce(to_char(greatest(last_vacuum, last_autovacuum), 'YYYY-MM-DD\"T\"HH24:MI:SS'), 'never') AS last_vacuum
+FROM pg_stat_user_tables
+ORDER BY pg_total_relation_size(relid) DESC
+LIMIT 20" | tee /dev/termination-log
+`
+
+// DatabaseMaintenanceEnabled returns whether any maintenance task is scheduled
+func DatabaseMaintenanceEnabled(cr *miqv1alpha1.ManageIQ) bool {
+	for _, task := range DatabaseMaintenanceTasks {
+		if DatabaseMaintenanceSchedule(cr, task) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// DatabaseMaintenanceSchedule returns the schedule of a maintenance task, the task is disabled
+// when it is empty
+func DatabaseMaintenanceSchedule(cr *miqv1alpha1.ManageIQ, task string) string {
+	switch task {
+	case DatabaseMaintenanceReindex:
+		return cr.Spec.DatabaseMaintenanceReindexSchedule
+	case DatabaseMaintenanceReport:
+		return cr.Spec.DatabaseMaintenanceReportSchedule
+	case DatabaseMaintenanceVacuum:
+		return cr.Spec.DatabaseMaintenanceVacuumSchedule
+	}
+	return ""
+}
+
+// databaseMaintenanceCommand returns the command of a maintenance task. VACUUM and REINDEX
+// CONCURRENTLY can not run in a transaction, each table gets its own --command.
+func databaseMaintenanceCommand(cr *miqv1alpha1.ManageIQ, task string) []string {
+	command := []string{"psql", "--set=ON_ERROR_STOP=1"}
+
+	switch task {
+	case DatabaseMaintenanceReindex:
+		for _, table := range cr.Spec.DatabaseMaintenanceReindexTables {
+			command = append(command, "--command=REINDEX TABLE CONCURRENTLY "+table)
+		}
+	case DatabaseMaintenanceReport:
+		return []string{"/bin/bash", "-c", databaseMaintenanceReportScript}
+	case DatabaseMaintenanceVacuum:
+		if len(cr.Spec.DatabaseMaintenanceVacuumTables) == 0 {
+			return append(command, "--command=VACUUM (ANALYZE)")
+		}
+		for _, table := range cr.Spec.DatabaseMaintenanceVacuumTables {
+			command = append(command, "--command=VACUUM (ANALYZE) "+table)
+		}
+	}
+
+	return command
+}
+
+// DatabaseMaintenanceCronJob runs a maintenance task against the database on its schedule. A failed
+// Job is not retried, the task runs again on the next schedule.
+func DatabaseMaintenanceCronJob(cr *miqv1alpha1.ManageIQ, task string, client client.Client, scheme *runtime.Scheme) (*batchv1.CronJob, controllerutil.MutateFn) {
+	var backoffLimit int32 = 0
+	var historyLimit int32 = 3
+	timeZone := "Etc/UTC"
+
+	container := corev1.Container{
+		Name:                     "postgresql-" + task,
+		Image:                    cr.Spec.PostgresqlImage,
+		ImagePullPolicy:          corev1.PullIfNotPresent,
+		Command:                  databaseMaintenanceCommand(cr, task),
+		Env:                      databaseEnv(cr),
+		SecurityContext:          DefaultSecurityContext(),
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+		VolumeMounts: []corev1.VolumeMount{
+			corev1.VolumeMount{Name: "database-secret", MountPath: "/run/secrets/postgresql", ReadOnly: true},
+		},
+	}
+	podSpec := corev1.PodSpec{Volumes: []corev1.Volume{databaseRootCertificateVolume(cr)}}
+	addExternalDatabaseTLS(cr, &podSpec, &container)
+
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceName(cr, "postgresql-"+task),
+			Namespace: cr.ObjectMeta.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, cronJob, scheme); err != nil {
+			return err
+		}
+
+		addAppLabel(cr.Spec.AppName, &cronJob.ObjectMeta)
+
+		cronJob.Spec.Schedule = DatabaseMaintenanceSchedule(cr, task)
+		cronJob.Spec.TimeZone = &timeZone
+		cronJob.Spec.ConcurrencyPolicy = batchv1.ForbidConcurrent
+		cronJob.Spec.SuccessfulJobsHistoryLimit = &historyLimit
+		cronJob.Spec.FailedJobsHistoryLimit = &historyLimit
+		cronJob.Spec.JobTemplate.ObjectMeta.Labels = map[string]string{"app": cr.Spec.AppName, DatabaseMaintenanceTaskLabel: task}
+		cronJob.Spec.JobTemplate.Spec.BackoffLimit = &backoffLimit
+
+		template := &cronJob.Spec.JobTemplate.Spec.Template
+		template.ObjectMeta.Labels = map[string]string{"app": cr.Spec.AppName, "name": "postgresql-maintenance", DatabaseMaintenanceTaskLabel: task}
+		template.Spec.Containers = []corev1.Container{container}
+		template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		template.Spec.ServiceAccountName = defaultServiceAccountName(cr.Spec.AppName)
+		template.Spec.Volumes = podSpec.Volumes
+
+		template.Spec.ImagePullSecrets = nil
+		if cr.Spec.ImagePullSecret != "" {
+			template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{corev1.LocalObjectReference{Name: cr.Spec.ImagePullSecret}}
+		}
+
+		miqutilsv1alpha1.SetPodTemplateNodeAffinity(template, cr.Namespace, client)
+
+		return nil
+	}
+
+	return cronJob, f
+}
@@ -0,0 +1,193 @@
+package miqtools
+
+import (
+	"time"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqutilsv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/miqutils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// DatabaseSnapshottingCondition is True from the moment a scheduled snapshot of the database starts
+// until the storage provider has cut it, the orchestrator is scaled down in the meantime when
+// DatabaseSnapshotPauseOrchestrator is set
+const DatabaseSnapshottingCondition = "DatabaseSnapshotting"
+
+// DatabaseSnapshotAnnotation records on the checkpoint Job the name of the snapshot it precedes
+const DatabaseSnapshotAnnotation = "manageiq.org/database-snapshot"
+
+var volumeSnapshotGVK = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"}
+
+// DatabaseSnapshotsEnabled returns whether snapshots of the database are scheduled
+func DatabaseSnapshotsEnabled(cr *miqv1alpha1.ManageIQ) bool {
+	return cr.Spec.DatabaseSnapshotSchedule != "" && !ExternalDatabase(cr)
+}
+
+// DatabaseSnapshotting returns whether a snapshot of the database is in progress
+func DatabaseSnapshotting(cr *miqv1alpha1.ManageIQ) bool {
+	return apimeta.IsStatusConditionTrue(cr.Status.Conditions, DatabaseSnapshottingCondition)
+}
+
+// DatabaseSnapshotPaused returns whether the orchestrator is scaled down for a snapshot
+func DatabaseSnapshotPaused(cr *miqv1alpha1.ManageIQ) bool {
+	return databaseSnapshotPauseOrchestrator(cr) && DatabaseSnapshotting(cr)
+}
+
+// NextDatabaseSnapshot returns the time of the first scheduled snapshot after last
+func NextDatabaseSnapshot(cr *miqv1alpha1.ManageIQ, last time.Time) time.Time {
+	schedule, err := miqutilsv1alpha1.ParseSchedule(cr.Spec.DatabaseSnapshotSchedule)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return schedule.Next(last.UTC())
+}
+
+// DatabaseSnapshotRetention returns the number of snapshots kept
+func DatabaseSnapshotRetention(cr *miqv1alpha1.ManageIQ) int {
+	return int(databaseSnapshotRetention(cr))
+}
+
+// DatabaseSnapshotName names a snapshot after the time it was started
+func DatabaseSnapshotName(cr *miqv1alpha1.ManageIQ, t time.Time) string {
+	return ResourceName(cr, "postgresql-"+t.UTC().Format("20060102-1504"))
+}
+
+// DatabaseSnapshotSelector selects the snapshots of the database taken by the operator
+func DatabaseSnapshotSelector(cr *miqv1alpha1.ManageIQ) map[string]string {
+	return map[string]string{"app": cr.Spec.AppName, "name": "postgresql-snapshot"}
+}
+
+// NewVolumeSnapshot returns an empty VolumeSnapshot, e.g. to get or delete one by name
+func NewVolumeSnapshot(namespace, name string) *unstructured.Unstructured {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	snapshot.SetNamespace(namespace)
+	snapshot.SetName(name)
+
+	return snapshot
+}
+
+// NewVolumeSnapshotList returns an empty list of VolumeSnapshots
+func NewVolumeSnapshotList() *unstructured.UnstructuredList {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(volumeSnapshotGVK.GroupVersion().WithKind("VolumeSnapshotList"))
+
+	return list
+}
+
+// DatabaseVolumeSnapshot snapshots the database PVC, the source of a snapshot is immutable
+func DatabaseVolumeSnapshot(cr *miqv1alpha1.ManageIQ, name string, scheme *runtime.Scheme) (*unstructured.Unstructured, controllerutil.MutateFn) {
+	snapshot := NewVolumeSnapshot(cr.Namespace, name)
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, snapshot, scheme); err != nil {
+			return err
+		}
+
+		labels := snapshot.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		for key, value := range DatabaseSnapshotSelector(cr) {
+			labels[key] = value
+		}
+		snapshot.SetLabels(labels)
+
+		if creationTimestamp := snapshot.GetCreationTimestamp(); !creationTimestamp.IsZero() {
+			return nil
+		}
+
+		spec := map[string]interface{}{
+			"source": map[string]interface{}{"persistentVolumeClaimName": PostgresqlClaimName(cr)},
+		}
+		if cr.Spec.DatabaseSnapshotClassName != "" {
+			spec["volumeSnapshotClassName"] = cr.Spec.DatabaseSnapshotClassName
+		}
+		snapshot.UnstructuredContent()["spec"] = spec
+
+		return nil
+	}
+
+	return snapshot, f
+}
+
+// VolumeSnapshotStatus returns the state of a VolumeSnapshot as reported in the CR status, along
+// with the error reported by the snapshot controller
+func VolumeSnapshotStatus(snapshot *unstructured.Unstructured) (miqv1alpha1.DatabaseSnapshot, string) {
+	status := miqv1alpha1.DatabaseSnapshot{Name: snapshot.GetName()}
+
+	if creationTime, found, _ := unstructured.NestedString(snapshot.Object, "status", "creationTime"); found {
+		if t, err := time.Parse(time.RFC3339, creationTime); err == nil {
+			status.CreationTime = &metav1.Time{Time: t}
+		}
+	}
+	status.ReadyToUse, _, _ = unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+	status.RestoreSize, _, _ = unstructured.NestedString(snapshot.Object, "status", "restoreSize")
+	message, _, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message")
+
+	return status, message
+}
+
+// DatabaseCheckpointJob issues a CHECKPOINT before the database is snapshotted, so that the
+// snapshot only replays the WAL written since then when it is restored
+func DatabaseCheckpointJob(cr *miqv1alpha1.ManageIQ, snapshotName string, client client.Client, scheme *runtime.Scheme) (*batchv1.Job, controllerutil.MutateFn) {
+	var backoffLimit int32 = 2
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceName(cr, "postgresql-checkpoint"),
+			Namespace: cr.ObjectMeta.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, job, scheme); err != nil {
+			return err
+		}
+
+		addAppLabel(cr.Spec.AppName, &job.ObjectMeta)
+
+		// The pod template of a Job is immutable
+		if !job.CreationTimestamp.IsZero() {
+			return nil
+		}
+
+		addAnnotations(map[string]string{DatabaseSnapshotAnnotation: snapshotName}, &job.ObjectMeta)
+		job.Spec.BackoffLimit = &backoffLimit
+		job.Spec.Template.ObjectMeta.Labels = map[string]string{"app": cr.Spec.AppName, "name": "postgresql-checkpoint"}
+		job.Spec.Template.Spec.Containers = []corev1.Container{corev1.Container{
+			Name:            "postgresql-checkpoint",
+			Image:           cr.Spec.PostgresqlImage,
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command:         []string{"psql", "--command=CHECKPOINT"},
+			Env:             databaseEnv(cr),
+			SecurityContext: DefaultSecurityContext(),
+			VolumeMounts: []corev1.VolumeMount{
+				corev1.VolumeMount{Name: "database-secret", MountPath: "/run/secrets/postgresql", ReadOnly: true},
+			},
+		}}
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		job.Spec.Template.Spec.ServiceAccountName = defaultServiceAccountName(cr.Spec.AppName)
+		job.Spec.Template.Spec.Volumes = []corev1.Volume{databaseRootCertificateVolume(cr)}
+
+		if cr.Spec.ImagePullSecret != "" {
+			job.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{corev1.LocalObjectReference{Name: cr.Spec.ImagePullSecret}}
+		}
+
+		miqutilsv1alpha1.SetPodTemplateNodeAffinity(&job.Spec.Template, cr.Namespace, client)
+		AddNodeScheduling(cr, "postgresql", &job.Spec.Template)
+
+		return nil
+	}
+
+	return job, f
+}
@@ -0,0 +1,222 @@
+package miqtools
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strconv"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqutilsv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/miqutils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// DatabasePreflightChecksumAnnotation records the connection settings the preflight Job checked
+const DatabasePreflightChecksumAnnotation = "manageiq.org/database-preflight-checksum"
+
+// Oldest PostgreSQL major version accepted by the preflight check of an external database
+const externalDatabaseMinimumVersion = 13
+
+const externalDatabaseTLSPath = "/run/secrets/postgresql-tls"
+
+// The preflight Job writes the server_version_num of the database to its termination message,
+// errors are reported through the log fallback of the termination message
+const databasePreflightScript = `set -e
+version=$(psql --no-align --tuples-only --command="SHOW server_version_num")
+if [ "${version}" -lt "$((MINIMUM_VERSION * 10000))" ]; then
+  echo "PostgreSQL ${version} is older than the minimum supported version ${MINIMUM_VERSION}" >&2
+  exit 1
+fi
+echo -n "${version}" > /dev/termination-log
+`
+
+// ExternalDatabase returns whether the CR explicitly configures an external database
+func ExternalDatabase(cr *miqv1alpha1.ManageIQ) bool {
+	return cr.Spec.ExternalDatabaseHost != ""
+}
+
+// externalDatabaseSecretData returns the keys of the database secret set from the external
+// database settings, the CA certificate is copied for the backup and restore Jobs
+func externalDatabaseSecretData(cr *miqv1alpha1.ManageIQ, client client.Client) map[string]string {
+	data := map[string]string{
+		"hostname": cr.Spec.ExternalDatabaseHost,
+		"port":     strconv.Itoa(int(externalDatabasePort(cr))),
+		"sslmode":  externalDatabaseSSLMode(cr),
+	}
+
+	if cr.Spec.ExternalDatabaseCASecret != "" {
+		caSecret := &corev1.Secret{}
+		if err := client.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: cr.Spec.ExternalDatabaseCASecret}, caSecret); err == nil {
+			data["rootcertificate"] = string(caSecret.Data["ca.crt"])
+		}
+	}
+
+	return data
+}
+
+// ExternalDatabaseChecksum sums up the settings and secrets used to connect to the database, the
+// preflight Job is run again when it changes
+func ExternalDatabaseChecksum(cr *miqv1alpha1.ManageIQ, client client.Client) string {
+	hash := sha256.New()
+	for _, name := range []string{cr.Spec.DatabaseSecret, cr.Spec.ExternalDatabaseCASecret, cr.Spec.ExternalDatabaseClientCertSecret} {
+		secret := &corev1.Secret{}
+		if name == "" || client.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: name}, secret) != nil {
+			fmt.Fprintf(hash, "%s\n", name)
+			continue
+		}
+
+		keys := make([]string, 0, len(secret.Data))
+		for key := range secret.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		fmt.Fprintf(hash, "%s\n", name)
+		for _, key := range keys {
+			fmt.Fprintf(hash, "%s=%x\n", key, secret.Data[key])
+		}
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// externalDatabaseTLSVolume projects the CA certificate and the client certificate of the external
+// database, it is mounted at externalDatabaseTLSPath
+func externalDatabaseTLSVolume(cr *miqv1alpha1.ManageIQ) corev1.Volume {
+	// libpq accepts a private key readable by the group when it is owned by root
+	var mode int32 = 0640
+
+	sources := []corev1.VolumeProjection{}
+	if cr.Spec.ExternalDatabaseCASecret != "" {
+		sources = append(sources, corev1.VolumeProjection{Secret: &corev1.SecretProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: cr.Spec.ExternalDatabaseCASecret},
+			Items:                []corev1.KeyToPath{corev1.KeyToPath{Key: "ca.crt", Path: "root.crt"}},
+		}})
+	}
+	if cr.Spec.ExternalDatabaseClientCertSecret != "" {
+		sources = append(sources, corev1.VolumeProjection{Secret: &corev1.SecretProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: cr.Spec.ExternalDatabaseClientCertSecret},
+			Items: []corev1.KeyToPath{
+				corev1.KeyToPath{Key: corev1.TLSCertKey, Path: "postgresql.crt"},
+				corev1.KeyToPath{Key: corev1.TLSPrivateKeyKey, Path: "postgresql.key"},
+			},
+		}})
+	}
+
+	return corev1.Volume{
+		Name: "database-tls",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{Sources: sources, DefaultMode: &mode},
+		},
+	}
+}
+
+// externalDatabaseTLSEnv points libpq at the certificates of externalDatabaseTLSVolume
+func externalDatabaseTLSEnv(cr *miqv1alpha1.ManageIQ) []corev1.EnvVar {
+	env := []corev1.EnvVar{corev1.EnvVar{Name: "PGSSLMODE", Value: externalDatabaseSSLMode(cr)}}
+	if cr.Spec.ExternalDatabaseCASecret != "" {
+		env = append(env, corev1.EnvVar{Name: "PGSSLROOTCERT", Value: externalDatabaseTLSPath + "/root.crt"})
+	}
+	if cr.Spec.ExternalDatabaseClientCertSecret != "" {
+		env = append(env,
+			corev1.EnvVar{Name: "PGSSLCERT", Value: externalDatabaseTLSPath + "/postgresql.crt"},
+			corev1.EnvVar{Name: "PGSSLKEY", Value: externalDatabaseTLSPath + "/postgresql.key"},
+		)
+	}
+
+	return env
+}
+
+// addExternalDatabaseTLS mounts the certificates of the external database in the container, or
+// removes them when no external database is configured
+func addExternalDatabaseTLS(cr *miqv1alpha1.ManageIQ, podSpec *corev1.PodSpec, c *corev1.Container) {
+	removeExternalDatabaseTLS(podSpec, c)
+
+	if !ExternalDatabase(cr) {
+		return
+	}
+
+	for _, env := range externalDatabaseTLSEnv(cr) {
+		c.Env = addOrUpdateEnvVar(c.Env, env)
+	}
+	if cr.Spec.ExternalDatabaseCASecret != "" || cr.Spec.ExternalDatabaseClientCertSecret != "" {
+		c.VolumeMounts = addOrUpdateVolumeMount(c.VolumeMounts, corev1.VolumeMount{Name: "database-tls", MountPath: externalDatabaseTLSPath, ReadOnly: true})
+		podSpec.Volumes = addOrUpdateVolume(podSpec.Volumes, externalDatabaseTLSVolume(cr))
+	}
+}
+
+func removeExternalDatabaseTLS(podSpec *corev1.PodSpec, c *corev1.Container) {
+	for _, name := range []string{"PGSSLCERT", "PGSSLKEY", "PGSSLMODE", "PGSSLROOTCERT"} {
+		c.Env = removeEnvVar(c.Env, name)
+	}
+	c.VolumeMounts = removeVolumeMount(c.VolumeMounts, "database-tls")
+	podSpec.Volumes = removeVolume(podSpec.Volumes, "database-tls")
+}
+
+// DatabasePreflightJob connects to an external database with the settings of the orchestrator and
+// checks its version. The checksum of the settings is recorded in an annotation.
+func DatabasePreflightJob(cr *miqv1alpha1.ManageIQ, checksum string, client client.Client, scheme *runtime.Scheme) (*batchv1.Job, controllerutil.MutateFn) {
+	var backoffLimit int32 = 0
+
+	container := corev1.Container{
+		Name:                     "database-preflight",
+		Image:                    cr.Spec.PostgresqlImage,
+		ImagePullPolicy:          corev1.PullIfNotPresent,
+		Command:                  []string{"/bin/bash", "-c", databasePreflightScript},
+		Env:                      append(databaseEnv(cr), corev1.EnvVar{Name: "MINIMUM_VERSION", Value: strconv.Itoa(externalDatabaseMinimumVersion)}),
+		SecurityContext:          DefaultSecurityContext(),
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+		VolumeMounts: []corev1.VolumeMount{
+			corev1.VolumeMount{Name: "database-secret", MountPath: "/run/secrets/postgresql", ReadOnly: true},
+		},
+	}
+	podSpec := corev1.PodSpec{Volumes: []corev1.Volume{databaseRootCertificateVolume(cr)}}
+	if ExternalDatabase(cr) {
+		addExternalDatabaseTLS(cr, &podSpec, &container)
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceName(cr, "database-preflight"),
+			Namespace: cr.ObjectMeta.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, job, scheme); err != nil {
+			return err
+		}
+
+		addAppLabel(cr.Spec.AppName, &job.ObjectMeta)
+
+		// The pod template of a Job is immutable
+		if !job.CreationTimestamp.IsZero() {
+			return nil
+		}
+
+		addAnnotations(map[string]string{DatabasePreflightChecksumAnnotation: checksum}, &job.ObjectMeta)
+		job.Spec.BackoffLimit = &backoffLimit
+		job.Spec.Template.ObjectMeta.Labels = map[string]string{"app": cr.Spec.AppName, "name": "database-preflight"}
+		job.Spec.Template.Spec.Containers = []corev1.Container{container}
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		job.Spec.Template.Spec.ServiceAccountName = defaultServiceAccountName(cr.Spec.AppName)
+		job.Spec.Template.Spec.Volumes = podSpec.Volumes
+
+		if cr.Spec.ImagePullSecret != "" {
+			job.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{corev1.LocalObjectReference{Name: cr.Spec.ImagePullSecret}}
+		}
+
+		miqutilsv1alpha1.SetPodTemplateNodeAffinity(&job.Spec.Template, cr.Namespace, client)
+
+		return nil
+	}
+
+	return job, f
+}
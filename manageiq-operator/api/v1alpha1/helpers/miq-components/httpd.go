@@ -13,6 +13,7 @@ import (
 	miqutilsv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/miqutils"
 	routev1 "github.com/openshift/api/route/v1"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -49,7 +50,7 @@ func HttpdServiceAccount(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*cor
 func Route(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, client client.Client) (*routev1.Route, controllerutil.MutateFn) {
 	route := &routev1.Route{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "httpd",
+			Name:      ResourceName(cr, "httpd"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 		Spec: routev1.RouteSpec{
@@ -62,7 +63,7 @@ func Route(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, client client.Clien
 			},
 			To: routev1.RouteTargetReference{
 				Kind: "Service",
-				Name: "httpd",
+				Name: ResourceName(cr, "httpd"),
 			},
 			WildcardPolicy: routev1.WildcardPolicyNone,
 		},
@@ -106,7 +107,7 @@ func Ingress(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*networkingv1.In
 	implementationSpecific := networkingv1.PathType("ImplementationSpecific")
 	ingress := &networkingv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "httpd",
+			Name:      ResourceName(cr, "httpd"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 	}
@@ -121,6 +122,13 @@ func Ingress(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*networkingv1.In
 		ingress.Annotations["nginx.ingress.kubernetes.io/backend-protocol"] = "HTTPS"
 		ingress.Annotations["nginx.ingress.kubernetes.io/ssl-redirect"] = "true"
 		ingress.Annotations["nginx.ingress.kubernetes.io/use-forwarded-headers"] = "true"
+		// The ingress controller balances over the pod endpoints rather than the Service, the SSO
+		// sessions of the privileged authentication types need its cookie affinity
+		if PrivilegedHttpd(cr.Spec.HttpdAuthenticationType) {
+			ingress.Annotations["nginx.ingress.kubernetes.io/affinity"] = "cookie"
+		} else {
+			delete(ingress.Annotations, "nginx.ingress.kubernetes.io/affinity")
+		}
 		if len(ingress.Spec.TLS) == 0 {
 			ingress.Spec.TLS = append(ingress.Spec.TLS, networkingv1.IngressTLS{})
 		}
@@ -140,7 +148,7 @@ func Ingress(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*networkingv1.In
 								PathType: &implementationSpecific,
 								Backend: networkingv1.IngressBackend{
 									Service: &networkingv1.IngressServiceBackend{
-										Name: "httpd",
+										Name: ResourceName(cr, "httpd"),
 										Port: networkingv1.ServiceBackendPort{
 											Number: 8080,
 										},
@@ -170,7 +178,7 @@ func HttpdConfigMap(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, client cli
 
 	configMap := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "httpd-configs",
+			Name:      ResourceName(cr, "httpd-configs"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 		Data: make(map[string]string),
@@ -192,7 +200,7 @@ func HttpdConfigMap(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, client cli
 			apiHttpProtocol = "https"
 		}
 
-		configMap.Data["application.conf"] = httpdApplicationConf(cr.Spec.ApplicationDomain, uiHttpProtocol, uiWebSocketProtocol, apiHttpProtocol)
+		configMap.Data["application.conf"] = httpdApplicationConf(cr.Spec.AppName, cr.Spec.ApplicationDomain, uiHttpProtocol, uiWebSocketProtocol, apiHttpProtocol)
 		configMap.Data["authentication.conf"] = httpdAuthenticationConf(&cr.Spec)
 		configMap.Data["health.conf"] = httpdHealthConf()
 
@@ -213,7 +221,7 @@ func HttpdConfigMap(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, client cli
 func HttpdAuthConfigMap(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.ConfigMap, controllerutil.MutateFn) {
 	configMap := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "httpd-auth-configs",
+			Name:      ResourceName(cr, "httpd-auth-configs"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 		Data: make(map[string]string),
@@ -298,11 +306,11 @@ func getHttpdAuthConfigVersion(client client.Client, namespace string, spec *miq
 	return httpd_auth_config_version
 }
 
-func addAuthConfigVolume(podSpec *corev1.PodSpec) {
+func addAuthConfigVolume(configMapName string, podSpec *corev1.PodSpec) {
 	volumeMount := corev1.VolumeMount{Name: "httpd-auth-config", MountPath: "/etc/httpd/auth-conf.d"}
 	podSpec.Containers[0].VolumeMounts = addOrUpdateVolumeMount(podSpec.Containers[0].VolumeMounts, volumeMount)
 
-	configMapVolumeSource := corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "httpd-auth-configs"}}
+	configMapVolumeSource := corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: configMapName}}
 	podSpec.Volumes = addOrUpdateVolume(podSpec.Volumes, corev1.Volume{Name: "httpd-auth-config", VolumeSource: corev1.VolumeSource{ConfigMap: &configMapVolumeSource}})
 }
 
@@ -325,6 +333,11 @@ func addOIDCCACertVolume(secretName string, podSpec *corev1.PodSpec) {
 func configureHttpdAuth(spec *miqv1alpha1.ManageIQSpec, podSpec *corev1.PodSpec) {
 	authType := spec.HttpdAuthenticationType
 
+	// The <app>-httpd-auth-configs ConfigMap is pruned when switching to one of these
+	if authType == "internal" || authType == "openid-connect" {
+		podSpec.Volumes = removeVolume(podSpec.Volumes, "httpd-auth-config")
+	}
+
 	if authType == "internal" {
 		return
 	}
@@ -340,7 +353,7 @@ func configureHttpdAuth(spec *miqv1alpha1.ManageIQSpec, podSpec *corev1.PodSpec)
 	if authType == "openid-connect" && spec.OIDCClientSecret != "" {
 		addOIDCEnv(spec.OIDCClientSecret, podSpec)
 	} else if authType != "openid-connect" {
-		addAuthConfigVolume(podSpec)
+		addAuthConfigVolume(spec.AppName+"-httpd-auth-configs", podSpec)
 	}
 }
 
@@ -427,7 +440,7 @@ func HttpdDeployment(client client.Client, cr *miqv1alpha1.ManageIQ, scheme *run
 
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "httpd",
+			Name:      ResourceName(cr, "httpd"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 		Spec: appsv1.DeploymentSpec{
@@ -448,16 +461,13 @@ func HttpdDeployment(client client.Client, cr *miqv1alpha1.ManageIQ, scheme *run
 			return err
 		}
 		addAppLabel(cr.Spec.AppName, &deployment.ObjectMeta)
-		var repNum int32 = 1
-		deployment.Spec.Replicas = &repNum
-		deployment.Spec.Strategy = appsv1.DeploymentStrategy{
-			Type: "Recreate",
-		}
+		deployment.Spec.Replicas = httpdDeploymentReplicas(cr, deployment.Spec.Replicas)
+		deployment.Spec.Strategy = httpdDeploymentStrategy(privileged)
 		addAnnotations(cr.Spec.AppAnnotations, &deployment.Spec.Template.ObjectMeta)
 		deployment.Spec.Template.Spec.Containers = []corev1.Container{container}
 		deployment.Spec.Template.Spec.Containers[0].SecurityContext = DefaultSecurityContext()
 
-		configMapVolumeSource := corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "httpd-configs"}}
+		configMapVolumeSource := corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: ResourceName(cr, "httpd-configs")}}
 		deployment.Spec.Template.Spec.Volumes = addOrUpdateVolume(deployment.Spec.Template.Spec.Volumes, corev1.Volume{Name: "httpd-config", VolumeSource: corev1.VolumeSource{ConfigMap: &configMapVolumeSource}})
 
 		// Only assign the service account if we need additional privileges
@@ -486,6 +496,7 @@ func HttpdDeployment(client client.Client, cr *miqv1alpha1.ManageIQ, scheme *run
 		}
 
 		miqutilsv1alpha1.SetDeploymentNodeAffinity(deployment, client)
+		addPodScheduling(cr, "httpd", &deployment.Spec.Template)
 
 		return nil
 	}
@@ -493,10 +504,84 @@ func HttpdDeployment(client client.Client, cr *miqv1alpha1.ManageIQ, scheme *run
 	return deployment, f, nil
 }
 
+// HttpdAutoscaleEnabled returns whether the httpd deployment is scaled by a HorizontalPodAutoscaler
+func HttpdAutoscaleEnabled(cr *miqv1alpha1.ManageIQ) bool {
+	return cr.Spec.HttpdAutoscaleMaxReplicas != nil
+}
+
+// httpdDeploymentReplicas returns the replica count of the httpd deployment. With autoscaling the
+// HorizontalPodAutoscaler owns the count of the running deployment, it is only set when the
+// deployment is scaled down or back up.
+func httpdDeploymentReplicas(cr *miqv1alpha1.ManageIQ, current *int32) *int32 {
+	if replicas := applicationReplicas(cr); *replicas == 0 {
+		return replicas
+	}
+
+	repNum := httpdReplicas(cr)
+	if HttpdAutoscaleEnabled(cr) && current != nil && *current > 0 {
+		repNum = *current
+	}
+
+	return &repNum
+}
+
+// httpdDeploymentStrategy replaces the httpd pods one at a time so that the UI stays up during
+// updates. The privileged authentication types keep the state of the logins in progress in the
+// pod, their pods are replaced all at once so that a login does not span two configurations.
+func httpdDeploymentStrategy(privileged bool) appsv1.DeploymentStrategy {
+	if privileged {
+		return appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	}
+
+	maxSurge := intstr.FromInt(1)
+	maxUnavailable := intstr.FromInt(0)
+	return appsv1.DeploymentStrategy{
+		Type:          appsv1.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateDeployment{MaxSurge: &maxSurge, MaxUnavailable: &maxUnavailable},
+	}
+}
+
+// HttpdHorizontalPodAutoscaler scales the httpd deployment between HttpdReplicas and
+// HttpdAutoscaleMaxReplicas on the CPU utilization of the pods. It leaves the deployment alone
+// while it is scaled down to 0 for the maintenance mode or a restore.
+func HttpdHorizontalPodAutoscaler(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*autoscalingv2.HorizontalPodAutoscaler, controllerutil.MutateFn) {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceName(cr, "httpd"),
+			Namespace: cr.ObjectMeta.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, hpa, scheme); err != nil {
+			return err
+		}
+		addAppLabel(cr.Spec.AppName, &hpa.ObjectMeta)
+
+		minReplicas := httpdReplicas(cr)
+		targetCPUUtilization := httpdAutoscaleTargetCPUUtilization(cr)
+		hpa.Spec.ScaleTargetRef = autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: ResourceName(cr, "httpd")}
+		hpa.Spec.MinReplicas = &minReplicas
+		hpa.Spec.MaxReplicas = *cr.Spec.HttpdAutoscaleMaxReplicas
+		hpa.Spec.Metrics = []autoscalingv2.MetricSpec{
+			autoscalingv2.MetricSpec{
+				Type: autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricSource{
+					Name:   corev1.ResourceCPU,
+					Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: &targetCPUUtilization},
+				},
+			},
+		}
+		return nil
+	}
+
+	return hpa, f
+}
+
 func UIService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Service, controllerutil.MutateFn) {
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "ui",
+			Name:      ResourceName(cr, "ui"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 	}
@@ -511,7 +596,7 @@ func UIService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Servic
 		}
 		service.Spec.Ports[0].Name = "ui-service-3000"
 		service.Spec.Ports[0].Port = 3000
-		service.Spec.Selector = map[string]string{"service": "ui"}
+		service.Spec.Selector = appSelector(cr, "service", "ui")
 		return nil
 	}
 
@@ -521,7 +606,7 @@ func UIService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Servic
 func WebService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Service, controllerutil.MutateFn) {
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "web-service",
+			Name:      ResourceName(cr, "web-service"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 	}
@@ -536,7 +621,7 @@ func WebService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Servi
 		}
 		service.Spec.Ports[0].Name = "web-service-3000"
 		service.Spec.Ports[0].Port = 3000
-		service.Spec.Selector = map[string]string{"service": "web-service"}
+		service.Spec.Selector = appSelector(cr, "service", "web-service")
 		return nil
 	}
 
@@ -546,7 +631,7 @@ func WebService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Servi
 func RemoteConsoleService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Service, controllerutil.MutateFn) {
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "remote-console",
+			Name:      ResourceName(cr, "remote-console"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 	}
@@ -561,7 +646,7 @@ func RemoteConsoleService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*co
 		}
 		service.Spec.Ports[0].Name = "remote-console-3000"
 		service.Spec.Ports[0].Port = 3000
-		service.Spec.Selector = map[string]string{"service": "remote-console"}
+		service.Spec.Selector = appSelector(cr, "service", "remote-console")
 		return nil
 	}
 
@@ -571,7 +656,7 @@ func RemoteConsoleService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*co
 func HttpdService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Service, controllerutil.MutateFn) {
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "httpd",
+			Name:      ResourceName(cr, "httpd"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 	}
@@ -586,17 +671,30 @@ func HttpdService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Ser
 		}
 		service.Spec.Ports[0].Name = "http"
 		service.Spec.Ports[0].Port = 8080
-		service.Spec.Selector = map[string]string{"name": "httpd"}
+		service.Spec.Selector = appSelector(cr, "name", "httpd")
+
+		// The privileged authentication types keep the SSO session of a client in the httpd pod
+		// which it logged in through, the client has to stick to that pod once httpd is scaled out
+		if PrivilegedHttpd(cr.Spec.HttpdAuthenticationType) {
+			timeout := int32(corev1.DefaultClientIPServiceAffinitySeconds)
+			service.Spec.SessionAffinity = corev1.ServiceAffinityClientIP
+			service.Spec.SessionAffinityConfig = &corev1.SessionAffinityConfig{ClientIP: &corev1.ClientIPConfig{TimeoutSeconds: &timeout}}
+		} else {
+			service.Spec.SessionAffinity = corev1.ServiceAffinityNone
+			service.Spec.SessionAffinityConfig = nil
+		}
 		return nil
 	}
 
 	return service, f
 }
 
+// HttpdDbusAPIService is found by the application through the HTTPD_DBUS_API_SERVICE_HOST variable,
+// which the orchestrator sets to the prefixed name rather than relying on the service links
 func HttpdDbusAPIService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Service, controllerutil.MutateFn) {
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "httpd-dbus-api",
+			Name:      ResourceName(cr, "httpd-dbus-api"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 	}
@@ -611,7 +709,7 @@ func HttpdDbusAPIService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*cor
 		}
 		service.Spec.Ports[0].Name = "httpd-dbus-api"
 		service.Spec.Ports[0].Port = 8081
-		service.Spec.Selector = map[string]string{"name": "httpd"}
+		service.Spec.Selector = appSelector(cr, "name", "httpd")
 		return nil
 	}
 
@@ -665,7 +763,7 @@ func defaultTLSSecret(cr *miqv1alpha1.ManageIQ) (*corev1.Secret, error) {
 }
 
 func tlsSecretName(cr *miqv1alpha1.ManageIQ) string {
-	secretName := "tls-secret"
+	secretName := ResourceName(cr, "tls-secret")
 	if cr.Spec.TLSSecret != "" {
 		secretName = cr.Spec.TLSSecret
 	}
@@ -16,7 +16,7 @@ func httpdAuthConfigurationConf() string {
 }
 
 // application.conf
-func httpdApplicationConf(applicationDomain string, uiHttpProtocol string, uiWebSocketProtocol string, apiHttpProtocol string) string {
+func httpdApplicationConf(appName string, applicationDomain string, uiHttpProtocol string, uiWebSocketProtocol string, apiHttpProtocol string) string {
 	s := `
 Listen 8080
 
@@ -52,22 +52,22 @@ Options SymLinksIfOwnerMatch
   Header always set Reporting-Endpoints "csp-endpoint=\"https://%[1]s/dashboard/csp_report\""
 
   # Send API requests to the API pods
-  ProxyPass /api %[2]s://web-service:3000/api
-  ProxyPassReverse /api %[2]s://web-service:3000/api
+  ProxyPass /api %[2]s://%[5]s-web-service:3000/api
+  ProxyPassReverse /api %[2]s://%[5]s-web-service:3000/api
 
   # Send Notifications requests to the UI pods
   RewriteCond %%{REQUEST_URI}     ^/ws/notifications [NC]
   RewriteCond %%{HTTP:UPGRADE}    ^websocket$ [NC]
   RewriteCond %%{HTTP:CONNECTION} ^Upgrade$   [NC]
-  RewriteRule .* %[3]s://ui:3000%%{REQUEST_URI}  [P,QSA,L]
-  ProxyPassReverse /ws/notifications %[3]s://ui:3000/ws/notifications
+  RewriteRule .* %[3]s://%[5]s-ui:3000%%{REQUEST_URI}  [P,QSA,L]
+  ProxyPassReverse /ws/notifications %[3]s://%[5]s-ui:3000/ws/notifications
 
   # Send Console sessions to the remote-console pods
   RewriteCond %%{REQUEST_URI}     ^/ws/console [NC]
   RewriteCond %%{HTTP:UPGRADE}    ^websocket$  [NC]
   RewriteCond %%{HTTP:CONNECTION} ^Upgrade$    [NC]
-  RewriteRule .* ws://remote-console:3000%%{REQUEST_URI}  [P,QSA,L]
-  ProxyPassReverse /ws/console ws://remote-console:3000/ws/console
+  RewriteRule .* ws://%[5]s-remote-console:3000%%{REQUEST_URI}  [P,QSA,L]
+  ProxyPassReverse /ws/console ws://%[5]s-remote-console:3000/ws/console
 
   # Send everything else that is not handled locally to the UI pods
   RewriteCond %%{REQUEST_URI} !^/api
@@ -77,15 +77,15 @@ Options SymLinksIfOwnerMatch
   RewriteCond %%{REQUEST_URI} !^/saml2
   # For OpenID-Connect /openid-connect is only served by mod_auth_openidc
   RewriteCond %%{REQUEST_URI} !^/openid-connect
-  RewriteRule ^/ %[4]s://ui:3000%%{REQUEST_URI} [P,QSA,L]
-  ProxyPassReverse / %[4]s://ui:3000/
+  RewriteRule ^/ %[4]s://%[5]s-ui:3000%%{REQUEST_URI} [P,QSA,L]
+  ProxyPassReverse / %[4]s://%[5]s-ui:3000/
 
   # Ensures httpd stdout/stderr are seen by 'docker logs'.
   ErrorLog  "/dev/stderr"
   CustomLog "/dev/stdout" common
 </VirtualHost>
 `
-	return fmt.Sprintf(s, applicationDomain, apiHttpProtocol, uiWebSocketProtocol, uiHttpProtocol)
+	return fmt.Sprintf(s, applicationDomain, apiHttpProtocol, uiWebSocketProtocol, uiHttpProtocol, appName)
 }
 
 // authentication.conf
@@ -556,7 +556,7 @@ LimitRequestFieldSize 524288
 	return fmt.Sprintf(s, protocol)
 }
 
-func remoteConsoleHttpdConfig(protocol string) string {
+func remoteConsoleHttpdConfig(protocol string, appName string) string {
 	s := `
 ## ManageIQ HTTP Virtual Host Context
 
@@ -586,19 +586,19 @@ LimitRequestFieldSize 524288
 <VirtualHost *:3000>
   IncludeOptional conf.d/*_config
 
-  ServerName %s://remote-console
+  ServerName %[1]s://remote-console
   DocumentRoot /var/www/miq/vmdb/public
 
   RewriteCond %%{REQUEST_URI}     ^/ws/console [NC]
   RewriteCond %%{HTTP:UPGRADE}    ^websocket$  [NC]
   RewriteCond %%{HTTP:CONNECTION} ^Upgrade$    [NC]
-  RewriteRule .* ws://remote-console:3000%%{REQUEST_URI}  [P,QSA,L]
-  ProxyPassReverse /ws/console ws://remote-console:3000/ws/console
+  RewriteRule .* ws://%[2]s-remote-console:3000%%{REQUEST_URI}  [P,QSA,L]
+  ProxyPassReverse /ws/console ws://%[2]s-remote-console:3000/ws/console
 
   ProxyPreserveHost on
 </VirtualHost>
 `
-	return fmt.Sprintf(s, protocol)
+	return fmt.Sprintf(s, protocol, appName)
 }
 
 func httpdSslConfig() string {
@@ -13,6 +13,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -111,7 +112,7 @@ func updateKafka(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtime
 // If manual certificates are introduced after Strimzi has generated its own certificates,
 // then the certificates must be "renewed" to replace the old. This process is outlined here:
 // https://strimzi.io/docs/operators/in-development/deploying#proc-replacing-your-own-private-keys-str
-func renewKafkaCASecret(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtime.Scheme) error {
+func renewKafkaCASecret(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtime.Scheme, recorder record.EventRecorder) error {
 	if renewKafkaCASecretCheck(cr, client) {
 		kafkaCASecret := miqutilsv1alpha1.FindSecretByName(client, cr.Namespace, cr.Spec.AppName+"-cluster-ca-cert")
 		kafkaCAKeySecret := miqutilsv1alpha1.FindSecretByName(client, cr.Namespace, cr.Spec.AppName+"-cluster-ca")
@@ -168,6 +169,8 @@ func renewKafkaCASecret(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *
 			return kafka
 		}
 		updateKafka(cr, client, scheme, pauseReconcile)
+
+		recorder.Eventf(cr, corev1.EventTypeNormal, "KafkaCARenewed", "Kafka cluster CA has been replaced by the internal certificates root CA (generation %s)", caGenStr)
 	}
 
 	return nil
@@ -335,7 +338,7 @@ func KafkaClusterSpec() map[string]interface{} {
 	}
 }
 
-func KafkaCluster(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtime.Scheme) (*unstructured.Unstructured, controllerutil.MutateFn) {
+func KafkaCluster(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtime.Scheme, recorder record.EventRecorder) (*unstructured.Unstructured, controllerutil.MutateFn) {
 	kafkaClusterCR := &unstructured.Unstructured{}
 	kafkaClusterCR.SetGroupVersionKind(schema.GroupVersionKind{
 		Group:   "kafka.strimzi.io",
@@ -354,7 +357,11 @@ func KafkaCluster(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtim
 		zookeeperStorage["class"] = cr.Spec.StorageClassName
 	}
 
-	kafkaCRSpec = miqutilsv1alpha1.SetKafkaNodeAffinity(kafkaCRSpec, []string{"amd64", "arm64", "ppc64le", "s390x"})
+	// Strimzi keeps the volumes when the Kafka CR is garbage collected if the data is retained
+	if cr.Spec.DeletionPolicy == miqv1alpha1.DeletionPolicyRetain {
+		kafkaCRSpec["kafka"].(map[string]interface{})["storage"].(map[string]interface{})["deleteClaim"] = false
+		kafkaCRSpec["zookeeper"].(map[string]interface{})["storage"].(map[string]interface{})["deleteClaim"] = false
+	}
 
 	mutateFunc := func() error {
 		if err := controllerutil.SetControllerReference(cr, kafkaClusterCR, scheme); err != nil {
@@ -378,7 +385,7 @@ func KafkaCluster(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtim
 		}
 
 		if certSecret := miqtool.InternalCertificatesSecret(cr, client); certSecret.Data["root_crt"] != nil && certSecret.Data["root_key"] != nil {
-			if err := renewKafkaCASecret(cr, client, scheme); err != nil {
+			if err := renewKafkaCASecret(cr, client, scheme, recorder); err != nil {
 				return err
 			} else {
 				kafkaCRSpec["clusterCa"] = map[string]interface{}{
@@ -393,6 +400,10 @@ func KafkaCluster(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtim
 		zookeeperStorage := kafkaCRSpec["zookeeper"].(map[string]interface{})["storage"].(map[string]interface{})
 		zookeeperStorage["size"] = cr.Spec.ZookeeperVolumeCapacity
 
+		if err := setKafkaPodScheduling(cr, kafkaCRSpec); err != nil {
+			return err
+		}
+
 		kafkaClusterCR.UnstructuredContent()["spec"] = kafkaCRSpec
 
 		return nil
@@ -401,6 +412,51 @@ func KafkaCluster(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtim
 	return kafkaClusterCR, mutateFunc
 }
 
+// setKafkaPodScheduling applies the KafkaScheduling to the kafka, zookeeper and entity operator pods.
+// The pod template of Strimzi has no node selector, it is required through the node affinity.
+func setKafkaPodScheduling(cr *miqv1alpha1.ManageIQ, kafkaCRSpec map[string]interface{}) error {
+	scheduling := cr.Spec.KafkaScheduling
+	if scheduling == nil {
+		scheduling = &miqv1alpha1.PodScheduling{}
+	}
+
+	affinity := miqutilsv1alpha1.MergeAffinity(miqutilsv1alpha1.NodeSelectorAffinity(scheduling.NodeSelector), scheduling.Affinity)
+	if _, err := miqutilsv1alpha1.SetKafkaNodeAffinity(kafkaCRSpec, []string{"amd64", "arm64", "ppc64le", "s390x"}, affinity); err != nil {
+		return err
+	}
+
+	tolerations := []interface{}{}
+	for i := range scheduling.Tolerations {
+		toleration, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&scheduling.Tolerations[i])
+		if err != nil {
+			return err
+		}
+		tolerations = append(tolerations, toleration)
+	}
+
+	for _, component := range []string{"kafka", "zookeeper", "entityOperator"} {
+		pod := kafkaCRSpec[component].(map[string]interface{})["template"].(map[string]interface{})["pod"].(map[string]interface{})
+		if len(tolerations) > 0 {
+			pod["tolerations"] = tolerations
+		} else {
+			delete(pod, "tolerations")
+		}
+		if scheduling.PriorityClassName != "" {
+			pod["priorityClassName"] = scheduling.PriorityClassName
+		} else {
+			delete(pod, "priorityClassName")
+		}
+	}
+
+	return nil
+}
+
+// KafkaVolumeClaimLabels select the PVCs created by Strimzi for the kafka or zookeeper pods of the
+// cluster, Strimzi grows them when the storage size of the Kafka CR changes
+func KafkaVolumeClaimLabels(cr *miqv1alpha1.ManageIQ, component string) map[string]string {
+	return map[string]string{"strimzi.io/cluster": cr.Spec.AppName, "strimzi.io/name": cr.Spec.AppName + "-" + component}
+}
+
 func KafkaUserSpec() map[string]interface{} {
 	return map[string]interface{}{
 		"authentication": map[string]interface{}{
@@ -459,6 +515,11 @@ func KafkaUser(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*unstructured.
 	return kafkaUserCR, mutateFunc
 }
 
+// KafkaTopicNames are the topics used by the application
+func KafkaTopicNames() []string {
+	return []string{"manageiq.ems", "manageiq.ems-events", "manageiq.ems-inventory", "manageiq.metrics"}
+}
+
 func KafkaTopicSpec() map[string]interface{} {
 	return map[string]interface{}{
 		"partitions": 1,
@@ -469,6 +530,8 @@ func KafkaTopicSpec() map[string]interface{} {
 	}
 }
 
+// KafkaTopic is named after the AppName and the topic, the topic itself keeps its name in the Kafka
+// cluster of the instance
 func KafkaTopic(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, topicName string) (*unstructured.Unstructured, controllerutil.MutateFn) {
 	kafkaTopicCR := &unstructured.Unstructured{}
 
@@ -477,11 +540,12 @@ func KafkaTopic(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, topicName stri
 		Kind:    "KafkaTopic",
 		Version: "v1beta2",
 	})
-	kafkaTopicCR.SetName(topicName)
+	kafkaTopicCR.SetName(cr.Spec.AppName + "-" + topicName)
 	kafkaTopicCR.SetNamespace(cr.Namespace)
 	kafkaTopicCR.SetLabels(map[string]string{"strimzi.io/cluster": cr.Spec.AppName})
 
 	kafkaTopicSpec := KafkaTopicSpec()
+	kafkaTopicSpec["topicName"] = topicName
 
 	mutateFunc := func() error {
 		if err := controllerutil.SetControllerReference(cr, kafkaTopicCR, scheme); err != nil {
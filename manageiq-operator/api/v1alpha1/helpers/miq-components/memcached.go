@@ -70,7 +70,7 @@ func NewMemcachedDeployment(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, cl
 	// Values in this deployment are either immutable or used for lookup
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "memcached",
+			Name:      ResourceName(cr, "memcached"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 		Spec: appsv1.DeploymentSpec{
@@ -92,8 +92,7 @@ func NewMemcachedDeployment(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, cl
 			return err
 		}
 		addAppLabel(cr.Spec.AppName, &deployment.ObjectMeta)
-		var repNum int32 = 1
-		deployment.Spec.Replicas = &repNum
+		deployment.Spec.Replicas = applicationReplicas(cr)
 		deployment.Spec.Strategy = appsv1.DeploymentStrategy{
 			Type: "Recreate",
 		}
@@ -109,6 +108,7 @@ func NewMemcachedDeployment(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, cl
 		}
 
 		miqutilsv1alpha1.SetDeploymentNodeAffinity(deployment, client)
+		addPodScheduling(cr, "memcached", &deployment.Spec.Template)
 
 		return nil
 	}
@@ -119,7 +119,7 @@ func NewMemcachedDeployment(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, cl
 func NewMemcachedService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Service, controllerutil.MutateFn) {
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "memcached",
+			Name:      ResourceName(cr, "memcached"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 	}
@@ -134,7 +134,7 @@ func NewMemcachedService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*cor
 		}
 		service.Spec.Ports[0].Name = "memcached"
 		service.Spec.Ports[0].Port = 11211
-		service.Spec.Selector = map[string]string{"name": "memcached"}
+		service.Spec.Selector = appSelector(cr, "name", "memcached")
 		return nil
 	}
 
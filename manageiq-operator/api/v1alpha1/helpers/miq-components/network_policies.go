@@ -48,7 +48,7 @@ func NetworkPolicyAllowInboundHttpd(cr *miqv1alpha1.ManageIQ, scheme *runtime.Sc
 		addAppLabel(cr.Spec.AppName, &networkPolicy.ObjectMeta)
 		setIngressPolicyType(networkPolicy)
 
-		networkPolicy.Spec.PodSelector.MatchLabels = map[string]string{"name": "httpd"}
+		networkPolicy.Spec.PodSelector.MatchLabels = appSelector(cr, "name", "httpd")
 
 		ensureIngressRule(networkPolicy)
 		setFirstIngressTCPPort(networkPolicy, 8080)
@@ -86,7 +86,7 @@ func NetworkPolicyAllowHttpdApi(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme
 		addAppLabel(cr.Spec.AppName, &networkPolicy.ObjectMeta)
 		setIngressPolicyType(networkPolicy)
 
-		networkPolicy.Spec.PodSelector.MatchLabels = map[string]string{"service": "web-service"}
+		networkPolicy.Spec.PodSelector.MatchLabels = appSelector(cr, "service", "web-service")
 
 		ensureIngressRule(networkPolicy)
 		setFirstIngressTCPPort(networkPolicy, 3000)
@@ -96,7 +96,7 @@ func NetworkPolicyAllowHttpdApi(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme
 			}
 		}
 		networkPolicy.Spec.Ingress[0].From[0].PodSelector = &metav1.LabelSelector{}
-		networkPolicy.Spec.Ingress[0].From[0].PodSelector.MatchLabels = map[string]string{"name": "httpd"}
+		networkPolicy.Spec.Ingress[0].From[0].PodSelector.MatchLabels = appSelector(cr, "name", "httpd")
 
 		return nil
 	}
@@ -114,7 +114,7 @@ func NetworkPolicyAllowHttpdUi(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme)
 		addAppLabel(cr.Spec.AppName, &networkPolicy.ObjectMeta)
 		setIngressPolicyType(networkPolicy)
 
-		networkPolicy.Spec.PodSelector.MatchLabels = map[string]string{"service": "ui"}
+		networkPolicy.Spec.PodSelector.MatchLabels = appSelector(cr, "service", "ui")
 
 		ensureIngressRule(networkPolicy)
 		setFirstIngressTCPPort(networkPolicy, 3000)
@@ -124,7 +124,7 @@ func NetworkPolicyAllowHttpdUi(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme)
 			}
 		}
 		networkPolicy.Spec.Ingress[0].From[0].PodSelector = &metav1.LabelSelector{}
-		networkPolicy.Spec.Ingress[0].From[0].PodSelector.MatchLabels = map[string]string{"name": "httpd"}
+		networkPolicy.Spec.Ingress[0].From[0].PodSelector.MatchLabels = appSelector(cr, "name", "httpd")
 
 		return nil
 	}
@@ -142,7 +142,7 @@ func NetworkPolicyAllowHttpdRemoteConsole(cr *miqv1alpha1.ManageIQ, scheme *runt
 		addAppLabel(cr.Spec.AppName, &networkPolicy.ObjectMeta)
 		setIngressPolicyType(networkPolicy)
 
-		networkPolicy.Spec.PodSelector.MatchLabels = map[string]string{"service": "remote-console"}
+		networkPolicy.Spec.PodSelector.MatchLabels = appSelector(cr, "service", "remote-console")
 
 		ensureIngressRule(networkPolicy)
 		setFirstIngressTCPPort(networkPolicy, 3000)
@@ -152,7 +152,7 @@ func NetworkPolicyAllowHttpdRemoteConsole(cr *miqv1alpha1.ManageIQ, scheme *runt
 			}
 		}
 		networkPolicy.Spec.Ingress[0].From[0].PodSelector = &metav1.LabelSelector{}
-		networkPolicy.Spec.Ingress[0].From[0].PodSelector.MatchLabels = map[string]string{"name": "httpd"}
+		networkPolicy.Spec.Ingress[0].From[0].PodSelector.MatchLabels = appSelector(cr, "name", "httpd")
 
 		return nil
 	}
@@ -170,9 +170,9 @@ func NetworkPolicyAllowMemcached(cr *miqv1alpha1.ManageIQ, scheme *runtime.Schem
 		addAppLabel(cr.Spec.AppName, &networkPolicy.ObjectMeta)
 		setIngressPolicyType(networkPolicy)
 
-		networkPolicy.Spec.PodSelector.MatchLabels = map[string]string{"name": "memcached"}
+		networkPolicy.Spec.PodSelector.MatchLabels = appSelector(cr, "name", "memcached")
 
-		pod := orchestratorPod(*c)
+		pod := orchestratorPod(cr, *c)
 		if pod == nil {
 			return nil
 		}
@@ -188,7 +188,45 @@ func NetworkPolicyAllowMemcached(cr *miqv1alpha1.ManageIQ, scheme *runtime.Schem
 		orchestratedByLabelKey := cr.Spec.AppName + "-orchestrated-by"
 		orchestratedByLabelValue := pod.Name
 		networkPolicy.Spec.Ingress[0].From[0].PodSelector = &metav1.LabelSelector{}
-		networkPolicy.Spec.Ingress[0].From[0].PodSelector.MatchLabels = map[string]string{"name": "orchestrator"}
+		networkPolicy.Spec.Ingress[0].From[0].PodSelector.MatchLabels = appSelector(cr, "name", "orchestrator")
+		networkPolicy.Spec.Ingress[0].From[1].PodSelector = &metav1.LabelSelector{}
+		networkPolicy.Spec.Ingress[0].From[1].PodSelector.MatchLabels = map[string]string{orchestratedByLabelKey: orchestratedByLabelValue}
+
+		return nil
+	}
+
+	return networkPolicy, f
+}
+
+func NetworkPolicyAllowPgbouncer(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, c *client.Client) (*networkingv1.NetworkPolicy, controllerutil.MutateFn) {
+	networkPolicy := newNetworkPolicy(cr, "allow-pgbouncer")
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, networkPolicy, scheme); err != nil {
+			return err
+		}
+		addAppLabel(cr.Spec.AppName, &networkPolicy.ObjectMeta)
+		setIngressPolicyType(networkPolicy)
+
+		networkPolicy.Spec.PodSelector.MatchLabels = appSelector(cr, "name", "pgbouncer")
+
+		pod := orchestratorPod(cr, *c)
+		if pod == nil {
+			return nil
+		}
+
+		ensureIngressRule(networkPolicy)
+		setFirstIngressTCPPort(networkPolicy, pgbouncerPort)
+		if len(networkPolicy.Spec.Ingress[0].From) != 2 {
+			networkPolicy.Spec.Ingress[0].From = []networkingv1.NetworkPolicyPeer{
+				networkingv1.NetworkPolicyPeer{},
+				networkingv1.NetworkPolicyPeer{},
+			}
+		}
+		orchestratedByLabelKey := cr.Spec.AppName + "-orchestrated-by"
+		orchestratedByLabelValue := pod.Name
+		networkPolicy.Spec.Ingress[0].From[0].PodSelector = &metav1.LabelSelector{}
+		networkPolicy.Spec.Ingress[0].From[0].PodSelector.MatchLabels = appSelector(cr, "name", "orchestrator")
 		networkPolicy.Spec.Ingress[0].From[1].PodSelector = &metav1.LabelSelector{}
 		networkPolicy.Spec.Ingress[0].From[1].PodSelector.MatchLabels = map[string]string{orchestratedByLabelKey: orchestratedByLabelValue}
 
@@ -208,27 +246,76 @@ func NetworkPolicyAllowPostgres(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme
 		addAppLabel(cr.Spec.AppName, &networkPolicy.ObjectMeta)
 		setIngressPolicyType(networkPolicy)
 
-		networkPolicy.Spec.PodSelector.MatchLabels = map[string]string{"name": "postgresql"}
+		networkPolicy.Spec.PodSelector.MatchLabels = appSelector(cr, "name", "postgresql")
 
-		pod := orchestratorPod(*c)
+		pod := orchestratorPod(cr, *c)
 		if pod == nil {
 			return nil
 		}
 
 		ensureIngressRule(networkPolicy)
 		setFirstIngressTCPPort(networkPolicy, 5432)
-		if len(networkPolicy.Spec.Ingress[0].From) != 2 {
+		if len(networkPolicy.Spec.Ingress[0].From) != 8 {
 			networkPolicy.Spec.Ingress[0].From = []networkingv1.NetworkPolicyPeer{
 				networkingv1.NetworkPolicyPeer{},
 				networkingv1.NetworkPolicyPeer{},
+				networkingv1.NetworkPolicyPeer{},
+				networkingv1.NetworkPolicyPeer{},
+				networkingv1.NetworkPolicyPeer{},
+				networkingv1.NetworkPolicyPeer{},
+				networkingv1.NetworkPolicyPeer{},
+				networkingv1.NetworkPolicyPeer{},
 			}
 		}
 		orchestratedByLabelKey := cr.Spec.AppName + "-orchestrated-by"
 		orchestratedByLabelValue := pod.Name
 		networkPolicy.Spec.Ingress[0].From[0].PodSelector = &metav1.LabelSelector{}
-		networkPolicy.Spec.Ingress[0].From[0].PodSelector.MatchLabels = map[string]string{"name": "orchestrator"}
+		networkPolicy.Spec.Ingress[0].From[0].PodSelector.MatchLabels = appSelector(cr, "name", "orchestrator")
 		networkPolicy.Spec.Ingress[0].From[1].PodSelector = &metav1.LabelSelector{}
 		networkPolicy.Spec.Ingress[0].From[1].PodSelector.MatchLabels = map[string]string{orchestratedByLabelKey: orchestratedByLabelValue}
+		networkPolicy.Spec.Ingress[0].From[2].PodSelector = &metav1.LabelSelector{}
+		networkPolicy.Spec.Ingress[0].From[2].PodSelector.MatchLabels = appSelector(cr, "name", "postgresql-backup")
+		networkPolicy.Spec.Ingress[0].From[3].PodSelector = &metav1.LabelSelector{}
+		networkPolicy.Spec.Ingress[0].From[3].PodSelector.MatchLabels = appSelector(cr, "name", "postgresql-restore")
+		// The standbys of the replicated mode stream from the primary
+		networkPolicy.Spec.Ingress[0].From[4].PodSelector = &metav1.LabelSelector{}
+		networkPolicy.Spec.Ingress[0].From[4].PodSelector.MatchLabels = appSelector(cr, "name", "postgresql")
+		networkPolicy.Spec.Ingress[0].From[5].PodSelector = &metav1.LabelSelector{}
+		networkPolicy.Spec.Ingress[0].From[5].PodSelector.MatchLabels = appSelector(cr, "name", "pgbouncer")
+		networkPolicy.Spec.Ingress[0].From[6].PodSelector = &metav1.LabelSelector{}
+		networkPolicy.Spec.Ingress[0].From[6].PodSelector.MatchLabels = appSelector(cr, "name", "postgresql-checkpoint")
+		networkPolicy.Spec.Ingress[0].From[7].PodSelector = &metav1.LabelSelector{}
+		networkPolicy.Spec.Ingress[0].From[7].PodSelector.MatchLabels = appSelector(cr, "name", "postgresql-credentials-rotation")
+
+		return nil
+	}
+
+	return networkPolicy, f
+}
+
+// NetworkPolicyAllowPostgresMaintenance lets the Jobs of the database maintenance CronJobs reach
+// the database
+func NetworkPolicyAllowPostgresMaintenance(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*networkingv1.NetworkPolicy, controllerutil.MutateFn) {
+	networkPolicy := newNetworkPolicy(cr, "allow-postgres-maintenance")
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, networkPolicy, scheme); err != nil {
+			return err
+		}
+		addAppLabel(cr.Spec.AppName, &networkPolicy.ObjectMeta)
+		setIngressPolicyType(networkPolicy)
+
+		networkPolicy.Spec.PodSelector.MatchLabels = appSelector(cr, "name", "postgresql")
+
+		ensureIngressRule(networkPolicy)
+		setFirstIngressTCPPort(networkPolicy, 5432)
+		if len(networkPolicy.Spec.Ingress[0].From) != 1 {
+			networkPolicy.Spec.Ingress[0].From = []networkingv1.NetworkPolicyPeer{
+				networkingv1.NetworkPolicyPeer{},
+			}
+		}
+		networkPolicy.Spec.Ingress[0].From[0].PodSelector = &metav1.LabelSelector{}
+		networkPolicy.Spec.Ingress[0].From[0].PodSelector.MatchLabels = appSelector(cr, "name", "postgresql-maintenance")
 
 		return nil
 	}
@@ -246,9 +333,9 @@ func NetworkPolicyAllowKafka(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, c
 		addAppLabel(cr.Spec.AppName, &networkPolicy.ObjectMeta)
 		setIngressPolicyType(networkPolicy)
 
-		networkPolicy.Spec.PodSelector.MatchLabels = map[string]string{"strimzi.io/pod-name": "manageiq-kafka-0"}
+		networkPolicy.Spec.PodSelector.MatchLabels = map[string]string{"strimzi.io/pod-name": cr.Spec.AppName + "-kafka-0"}
 
-		pod := orchestratorPod(*c)
+		pod := orchestratorPod(cr, *c)
 		if pod == nil {
 			return nil
 		}
@@ -264,7 +351,7 @@ func NetworkPolicyAllowKafka(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, c
 		orchestratedByLabelKey := cr.Spec.AppName + "-orchestrated-by"
 		orchestratedByLabelValue := pod.Name
 		networkPolicy.Spec.Ingress[0].From[0].PodSelector = &metav1.LabelSelector{}
-		networkPolicy.Spec.Ingress[0].From[0].PodSelector.MatchLabels = map[string]string{"name": "orchestrator"}
+		networkPolicy.Spec.Ingress[0].From[0].PodSelector.MatchLabels = appSelector(cr, "name", "orchestrator")
 		networkPolicy.Spec.Ingress[0].From[1].PodSelector = &metav1.LabelSelector{}
 		networkPolicy.Spec.Ingress[0].From[1].PodSelector.MatchLabels = map[string]string{orchestratedByLabelKey: orchestratedByLabelValue}
 
@@ -313,9 +400,9 @@ func NetworkPolicyAllowTfRunner(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme
 		addAppLabel(cr.Spec.AppName, &networkPolicy.ObjectMeta)
 		setIngressPolicyType(networkPolicy)
 
-		networkPolicy.Spec.PodSelector.MatchLabels = map[string]string{"service": "opentofu-runner"}
+		networkPolicy.Spec.PodSelector.MatchLabels = appSelector(cr, "service", "opentofu-runner")
 
-		pod := orchestratorPod(*c)
+		pod := orchestratorPod(cr, *c)
 		if pod == nil {
 			return nil
 		}
@@ -331,7 +418,7 @@ func NetworkPolicyAllowTfRunner(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme
 		orchestratedByLabelKey := cr.Spec.AppName + "-orchestrated-by"
 		orchestratedByLabelValue := pod.Name
 		networkPolicy.Spec.Ingress[0].From[0].PodSelector = &metav1.LabelSelector{}
-		networkPolicy.Spec.Ingress[0].From[0].PodSelector.MatchLabels = map[string]string{"name": "orchestrator"}
+		networkPolicy.Spec.Ingress[0].From[0].PodSelector.MatchLabels = appSelector(cr, "name", "orchestrator")
 		networkPolicy.Spec.Ingress[0].From[1].PodSelector = &metav1.LabelSelector{}
 		networkPolicy.Spec.Ingress[0].From[1].PodSelector.MatchLabels = map[string]string{orchestratedByLabelKey: orchestratedByLabelValue}
 
@@ -344,7 +431,7 @@ func NetworkPolicyAllowTfRunner(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme
 func newNetworkPolicy(cr *miqv1alpha1.ManageIQ, name string) *networkingv1.NetworkPolicy {
 	return &networkingv1.NetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      cr.Spec.AppName + "-" + name,
+			Name:      ResourceName(cr, name),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 	}
@@ -11,7 +11,7 @@ import (
 func TfRunnerService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Service, controllerutil.MutateFn) {
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "opentofu-runner",
+			Name:      ResourceName(cr, "opentofu-runner"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 	}
@@ -27,7 +27,7 @@ func TfRunnerService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.
 		}
 		service.Spec.Ports[0].Name = "opentofu-runner"
 		service.Spec.Ports[0].Port = 6000
-		service.Spec.Selector = map[string]string{"service": "opentofu-runner"}
+		service.Spec.Selector = appSelector(cr, "service", "opentofu-runner")
 		return nil
 	}
 
@@ -6,6 +6,7 @@ import (
 	"maps"
 	"strconv"
 	"strings"
+	"time"
 
 	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
 	miqutilsv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/miqutils"
@@ -157,19 +158,33 @@ func addMessagingEnv(cr *miqv1alpha1.ManageIQ, c *corev1.Container, client clien
 	return
 }
 
+// removeMessagingEnv drops the Kafka connection settings once the messaging service is disabled
+func removeMessagingEnv(c *corev1.Container) {
+	for _, name := range []string{"MESSAGING_HOSTNAME", "MESSAGING_PASSWORD", "MESSAGING_PORT", "MESSAGING_SASL_MECHANISM", "MESSAGING_TYPE", "MESSAGING_USERNAME"} {
+		c.Env = removeEnvVar(c.Env, name)
+	}
+}
+
 func addPostgresConfig(cr *miqv1alpha1.ManageIQ, d *appsv1.Deployment, client client.Client) {
 	d.Spec.Template.Spec.Containers[0].Env = addOrUpdateEnvVar(d.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "DATABASE_REGION", Value: cr.Spec.DatabaseRegion})
 }
 
 func updateOrchestratorEnv(cr *miqv1alpha1.ManageIQ, c *corev1.Container) {
 	c.Env = addOrUpdateEnvVar(c.Env, corev1.EnvVar{Name: "ADMIN_GROUP", Value: cr.Spec.InitialAdminGroupName})
+	c.Env = addOrUpdateEnvVar(c.Env, corev1.EnvVar{Name: "API_HTTPD_CONFIGS_NAME", Value: ResourceName(cr, "api-httpd-configs")})
 	c.Env = addOrUpdateEnvVar(c.Env, corev1.EnvVar{Name: "APP_NAME", Value: cr.Spec.AppName})
 	c.Env = addOrUpdateEnvVar(c.Env, corev1.EnvVar{Name: "APPLICATION_DOMAIN", Value: cr.Spec.ApplicationDomain})
 	c.Env = addOrUpdateEnvVar(c.Env, corev1.EnvVar{Name: "AUTH_SSO", Value: strconv.FormatBool(*cr.Spec.EnableSSO)})
 	c.Env = addOrUpdateEnvVar(c.Env, corev1.EnvVar{Name: "AUTH_TYPE", Value: cr.Spec.HttpdAuthenticationType})
+	c.Env = addOrUpdateEnvVar(c.Env, corev1.EnvVar{Name: "AUTOMATION_SERVICE_ACCOUNT", Value: ResourceName(cr, "automation")})
 	c.Env = addOrUpdateEnvVar(c.Env, corev1.EnvVar{Name: "GUID", Value: cr.Spec.ServerGuid})
+	c.Env = addOrUpdateEnvVar(c.Env, corev1.EnvVar{Name: "HTTPD_DBUS_API_SERVICE_HOST", Value: ResourceName(cr, "httpd-dbus-api")})
+	c.Env = addOrUpdateEnvVar(c.Env, corev1.EnvVar{Name: "HTTPD_DBUS_API_SERVICE_PORT", Value: "8081"})
 	c.Env = addOrUpdateEnvVar(c.Env, corev1.EnvVar{Name: "LOCAL_LOGIN_ENABLED", Value: strconv.FormatBool(*cr.Spec.EnableApplicationLocalLogin)})
-	c.Env = addOrUpdateEnvVar(c.Env, corev1.EnvVar{Name: "MEMCACHED_SERVER", Value: "memcached:11211"})
+	c.Env = addOrUpdateEnvVar(c.Env, corev1.EnvVar{Name: "MEMCACHED_SERVER", Value: ResourceName(cr, "memcached") + ":11211"})
+	c.Env = addOrUpdateEnvVar(c.Env, corev1.EnvVar{Name: "OPENTOFU_RUNNER_SERVICE_NAME", Value: ResourceName(cr, "opentofu-runner")})
+	c.Env = addOrUpdateEnvVar(c.Env, corev1.EnvVar{Name: "REMOTE_CONSOLE_HTTPD_CONFIGS_NAME", Value: ResourceName(cr, "remote-console-httpd-configs")})
+	c.Env = addOrUpdateEnvVar(c.Env, corev1.EnvVar{Name: "UI_HTTPD_CONFIGS_NAME", Value: ResourceName(cr, "ui-httpd-configs")})
 	c.Env = addOrUpdateEnvVar(c.Env, corev1.EnvVar{Name: "WORKER_RESOURCES", Value: strconv.FormatBool(*cr.Spec.EnforceWorkerResourceConstraints)})
 	c.Env = addOrUpdateEnvVar(c.Env, corev1.EnvVar{Name: "WORKER_SERVICE_ACCOUNT", Value: defaultServiceAccountName(cr.Spec.AppName)})
 
@@ -254,7 +269,7 @@ func OrchestratorDeployment(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, cl
 
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "orchestrator",
+			Name:      ResourceName(cr, "orchestrator"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 		Spec: appsv1.DeploymentSpec{
@@ -278,12 +293,18 @@ func OrchestratorDeployment(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, cl
 			return err
 		}
 		addAppLabel(cr.Spec.AppName, &deployment.ObjectMeta)
-		var repNum int32 = 1
-		deployment.Spec.Replicas = &repNum
+		deployment.Spec.Replicas = applicationReplicas(cr)
+		if DatabaseSnapshotPaused(cr) {
+			var zero int32 = 0
+			deployment.Spec.Replicas = &zero
+		}
 		deployment.Spec.Strategy = appsv1.DeploymentStrategy{
 			Type: "Recreate",
 		}
 		addAnnotations(cr.Spec.AppAnnotations, &deployment.Spec.Template.ObjectMeta)
+		if rotated := cr.Status.DatabaseCredentialsRotationTime; rotated != nil && !ExternalDatabase(cr) {
+			addAnnotations(map[string]string{DatabaseCredentialsRotatedAnnotation: rotated.UTC().Format(time.RFC3339)}, &deployment.Spec.Template.ObjectMeta)
+		}
 		var termSecs int64 = 90
 		deployment.Spec.Template.Spec.ServiceAccountName = cr.Spec.AppName + "-orchestrator"
 		deployment.Spec.Template.Spec.TerminationGracePeriodSeconds = &termSecs
@@ -318,10 +339,14 @@ func OrchestratorDeployment(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, cl
 
 		deployment.Spec.Template.Spec.Containers[0].Env = addOrUpdateEnvVar(deployment.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "MESSAGING_SSL_CA", Value: messagingCAPath})
 
+		if !*cr.Spec.DeployMessagingService {
+			removeMessagingEnv(&deployment.Spec.Template.Spec.Containers[0])
+		}
+
 		volumeMount := corev1.VolumeMount{Name: "encryption-key", MountPath: "/run/secrets/manageiq/application", ReadOnly: true}
 		deployment.Spec.Template.Spec.Containers[0].VolumeMounts = addOrUpdateVolumeMount(deployment.Spec.Template.Spec.Containers[0].VolumeMounts, volumeMount)
 
-		secretVolumeSource := corev1.SecretVolumeSource{SecretName: "app-secrets", Items: []corev1.KeyToPath{corev1.KeyToPath{Key: "encryption-key", Path: "encryption_key"}}}
+		secretVolumeSource := corev1.SecretVolumeSource{SecretName: appSecretName(cr), Items: []corev1.KeyToPath{corev1.KeyToPath{Key: "encryption-key", Path: "encryption_key"}}}
 		deployment.Spec.Template.Spec.Volumes = addOrUpdateVolume(deployment.Spec.Template.Spec.Volumes, corev1.Volume{Name: "encryption-key", VolumeSource: corev1.VolumeSource{Secret: &secretVolumeSource}})
 
 		databaseVolumeMount := corev1.VolumeMount{Name: "database-secret", MountPath: "/run/secrets/postgresql", ReadOnly: true}
@@ -335,8 +360,16 @@ func OrchestratorDeployment(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, cl
 			corev1.KeyToPath{Key: "username", Path: "POSTGRESQL_USER"},
 		}}
 		deployment.Spec.Template.Spec.Volumes = addOrUpdateVolume(deployment.Spec.Template.Spec.Volumes, corev1.Volume{Name: "database-secret", VolumeSource: corev1.VolumeSource{Secret: &databaseSecretVolumeSource}})
+		if PgbouncerEnabled(cr) {
+			// The pooler holds the TLS settings of the database, the orchestrator and its workers connect to it in plain text
+			deployment.Spec.Template.Spec.Volumes = addOrUpdateVolume(deployment.Spec.Template.Spec.Volumes, pgbouncerDatabaseVolume(cr))
+			removeExternalDatabaseTLS(&deployment.Spec.Template.Spec, &deployment.Spec.Template.Spec.Containers[0])
+		} else {
+			addExternalDatabaseTLS(cr, &deployment.Spec.Template.Spec, &deployment.Spec.Template.Spec.Containers[0])
+		}
 
 		miqutilsv1alpha1.SetDeploymentNodeAffinity(deployment, client)
+		addPodScheduling(cr, "orchestrator", &deployment.Spec.Template)
 
 		return nil
 	}
@@ -344,17 +377,15 @@ func OrchestratorDeployment(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme, cl
 	return deployment, f, nil
 }
 
-func orchestratorPod(c client.Client) *corev1.Pod {
+func orchestratorPod(cr *miqv1alpha1.ManageIQ, c client.Client) *corev1.Pod {
 	podList := &corev1.PodList{}
-	c.List(context.TODO(), podList)
+	c.List(context.TODO(), podList, client.InNamespace(cr.Namespace), client.MatchingLabels(appSelector(cr, "name", "orchestrator")))
 
-	for _, pod := range podList.Items {
-		if pod.ObjectMeta.Labels["name"] == "orchestrator" {
-			return &pod
-		}
+	if len(podList.Items) == 0 {
+		return nil
 	}
 
-	return nil
+	return &podList.Items[0]
 }
 
 func addInternalRootCertificate(cr *miqv1alpha1.ManageIQ, d *appsv1.Deployment, client client.Client) {
@@ -0,0 +1,290 @@
+package miqtools
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqutilsv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/miqutils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	intstr "k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// PgbouncerConfigChecksumAnnotation rolls the pgbouncer pods when the configuration or the
+// credentials of the database change
+const PgbouncerConfigChecksumAnnotation = "manageiq.org/pgbouncer-config-checksum"
+
+const pgbouncerPort = 6432
+
+// PgbouncerEnabled returns whether the orchestrator and its workers connect through PgBouncer
+func PgbouncerEnabled(cr *miqv1alpha1.ManageIQ) bool {
+	return cr.Spec.DeployPgbouncer != nil && *cr.Spec.DeployPgbouncer
+}
+
+// pgbouncerSecretData returns the pgbouncer.ini and the userlist.txt auth file built from the
+// database secret, along with the hostname and port of the pooler read by the orchestrator
+func pgbouncerSecretData(cr *miqv1alpha1.ManageIQ, client client.Client) (map[string]string, error) {
+	databaseSecret := &corev1.Secret{}
+	if err := client.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: cr.Spec.DatabaseSecret}, databaseSecret); err != nil {
+		return nil, fmt.Errorf("reading the database secret %s for pgbouncer: %w", cr.Spec.DatabaseSecret, err)
+	}
+
+	sslMode := string(databaseSecret.Data["sslmode"])
+	if sslMode == "" {
+		sslMode = "prefer"
+	}
+
+	ini := fmt.Sprintf(`[databases]
+* = host=%s port=%s
+
+[pgbouncer]
+listen_addr = *
+listen_port = %d
+unix_socket_dir =
+auth_type = scram-sha-256
+auth_file = /etc/pgbouncer/userlist.txt
+pool_mode = %s
+default_pool_size = %d
+max_client_conn = %d
+max_db_connections = %d
+ignore_startup_parameters = extra_float_digits
+server_tls_sslmode = %s
+`, databaseSecret.Data["hostname"], databaseSecret.Data["port"], pgbouncerPort, pgbouncerPoolMode(cr), pgbouncerDefaultPoolSize(cr), pgbouncerMaxClientConnections(cr), pgbouncerMaxDatabaseConnections(cr), sslMode)
+	if len(databaseSecret.Data["rootcertificate"]) > 0 {
+		ini += "server_tls_ca_file = /run/secrets/postgresql/root.crt\n"
+	}
+	if ExternalDatabase(cr) && cr.Spec.ExternalDatabaseClientCertSecret != "" {
+		ini += "server_tls_cert_file = " + externalDatabaseTLSPath + "/postgresql.crt\n"
+		ini += "server_tls_key_file = " + externalDatabaseTLSPath + "/postgresql.key\n"
+	}
+
+	// Double quotes are escaped by doubling them in the auth file
+	quote := func(s []byte) string { return `"` + strings.ReplaceAll(string(s), `"`, `""`) + `"` }
+
+	return map[string]string{
+		"hostname":      ResourceName(cr, "pgbouncer"),
+		"pgbouncer.ini": ini,
+		"port":          strconv.Itoa(pgbouncerPort),
+		"userlist.txt":  quote(databaseSecret.Data["username"]) + " " + quote(databaseSecret.Data["password"]) + "\n",
+	}, nil
+}
+
+func pgbouncerSecretName(cr *miqv1alpha1.ManageIQ) string {
+	return ResourceName(cr, "pgbouncer-secrets")
+}
+
+func PgbouncerSecret(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtime.Scheme) (*corev1.Secret, controllerutil.MutateFn, error) {
+	data, err := pgbouncerSecretData(cr, client)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pgbouncerSecretName(cr),
+			Namespace: cr.ObjectMeta.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, secret, scheme); err != nil {
+			return err
+		}
+		addAppLabel(cr.Spec.AppName, &secret.ObjectMeta)
+
+		secret.Data = map[string][]byte{}
+		for key, value := range data {
+			secret.Data[key] = []byte(value)
+		}
+
+		return nil
+	}
+
+	return secret, f, nil
+}
+
+func PgbouncerDeployment(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtime.Scheme) (*appsv1.Deployment, controllerutil.MutateFn, error) {
+	data, err := pgbouncerSecretData(cr, client)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hash := sha256.New()
+	for _, key := range slices.Sorted(maps.Keys(data)) {
+		fmt.Fprintf(hash, "%s=%s\n", key, data[key])
+	}
+	checksum := fmt.Sprintf("%x", hash.Sum(nil))
+
+	container := corev1.Container{
+		Name:            "pgbouncer",
+		Image:           cr.Spec.PgbouncerImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"pgbouncer", "/etc/pgbouncer/pgbouncer.ini"},
+		Ports: []corev1.ContainerPort{
+			corev1.ContainerPort{
+				ContainerPort: pgbouncerPort,
+				Protocol:      "TCP",
+			},
+		},
+		LivenessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				TCPSocket: &corev1.TCPSocketAction{
+					Port: intstr.FromInt(pgbouncerPort),
+				},
+			},
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				TCPSocket: &corev1.TCPSocketAction{
+					Port: intstr.FromInt(pgbouncerPort),
+				},
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			corev1.VolumeMount{Name: "pgbouncer-config", MountPath: "/etc/pgbouncer", ReadOnly: true},
+			corev1.VolumeMount{Name: "database-secret", MountPath: "/run/secrets/postgresql", ReadOnly: true},
+		},
+	}
+
+	err = addResourceReqs(cr.Spec.PgbouncerMemoryLimit, cr.Spec.PgbouncerMemoryRequest, cr.Spec.PgbouncerCpuLimit, cr.Spec.PgbouncerCpuRequest, &container)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	volumes := []corev1.Volume{
+		corev1.Volume{
+			Name: "pgbouncer-config",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: pgbouncerSecretName(cr),
+					Items: []corev1.KeyToPath{
+						corev1.KeyToPath{Key: "pgbouncer.ini", Path: "pgbouncer.ini"},
+						corev1.KeyToPath{Key: "userlist.txt", Path: "userlist.txt"},
+					},
+				},
+			},
+		},
+		databaseRootCertificateVolume(cr),
+	}
+	if ExternalDatabase(cr) && cr.Spec.ExternalDatabaseClientCertSecret != "" {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: "database-tls", MountPath: externalDatabaseTLSPath, ReadOnly: true})
+		volumes = append(volumes, externalDatabaseTLSVolume(cr))
+	}
+
+	deploymentLabels := map[string]string{
+		"name": "pgbouncer",
+		"app":  cr.Spec.AppName,
+	}
+	deploymentSelectorLabels := map[string]string{}
+	maps.Copy(deploymentSelectorLabels, deploymentLabels)
+
+	// Values in this deployment are either immutable or used for lookup
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceName(cr, "pgbouncer"),
+			Namespace: cr.ObjectMeta.Namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: deploymentSelectorLabels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "pgbouncer",
+					Labels: deploymentLabels,
+				},
+				Spec: corev1.PodSpec{},
+			},
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, deployment, scheme); err != nil {
+			return err
+		}
+		addAppLabel(cr.Spec.AppName, &deployment.ObjectMeta)
+		deployment.Spec.Replicas = applicationReplicas(cr)
+		addAnnotations(cr.Spec.AppAnnotations, &deployment.Spec.Template.ObjectMeta)
+		addAnnotations(map[string]string{PgbouncerConfigChecksumAnnotation: checksum}, &deployment.Spec.Template.ObjectMeta)
+		deployment.Spec.Template.Spec.Containers = []corev1.Container{container}
+		deployment.Spec.Template.Spec.Containers[0].SecurityContext = DefaultSecurityContext()
+		deployment.Spec.Template.Spec.ServiceAccountName = defaultServiceAccountName(cr.Spec.AppName)
+		deployment.Spec.Template.Spec.Volumes = volumes
+
+		if cr.Spec.ImagePullSecret != "" {
+			deployment.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{corev1.LocalObjectReference{Name: cr.Spec.ImagePullSecret}}
+		}
+
+		miqutilsv1alpha1.SetDeploymentNodeAffinity(deployment, client)
+		addPodScheduling(cr, "pgbouncer", &deployment.Spec.Template)
+
+		return nil
+	}
+
+	return deployment, f, nil
+}
+
+func PgbouncerService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Service, controllerutil.MutateFn) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceName(cr, "pgbouncer"),
+			Namespace: cr.ObjectMeta.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, service, scheme); err != nil {
+			return err
+		}
+		addAppLabel(cr.Spec.AppName, &service.ObjectMeta)
+		if len(service.Spec.Ports) == 0 {
+			service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{})
+		}
+		service.Spec.Ports[0].Name = "pgbouncer"
+		service.Spec.Ports[0].Port = pgbouncerPort
+		service.Spec.Selector = appSelector(cr, "name", "pgbouncer")
+		return nil
+	}
+
+	return service, f
+}
+
+// pgbouncerDatabaseVolume replaces the hostname and port of the database secret with the ones of
+// the pooler in the database-secret volume of the orchestrator
+func pgbouncerDatabaseVolume(cr *miqv1alpha1.ManageIQ) corev1.Volume {
+	return corev1.Volume{
+		Name: "database-secret",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					corev1.VolumeProjection{Secret: &corev1.SecretProjection{
+						LocalObjectReference: corev1.LocalObjectReference{Name: cr.Spec.DatabaseSecret},
+						Items: []corev1.KeyToPath{
+							corev1.KeyToPath{Key: "dbname", Path: "POSTGRESQL_DATABASE"},
+							corev1.KeyToPath{Key: "password", Path: "POSTGRESQL_PASSWORD"},
+							corev1.KeyToPath{Key: "username", Path: "POSTGRESQL_USER"},
+						},
+					}},
+					corev1.VolumeProjection{Secret: &corev1.SecretProjection{
+						LocalObjectReference: corev1.LocalObjectReference{Name: pgbouncerSecretName(cr)},
+						Items: []corev1.KeyToPath{
+							corev1.KeyToPath{Key: "hostname", Path: "POSTGRESQL_HOSTNAME"},
+							corev1.KeyToPath{Key: "port", Path: "POSTGRESQL_PORT"},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,74 @@
+package miqtools
+
+import (
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	intstr "k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// podDisruptionBudget limits how many pods of a component the voluntary evictions, e.g. of a node
+// drain, take down at once. The pods which are not ready can always be evicted, so that a crashing
+// pod does not block the drain.
+func podDisruptionBudget(cr *miqv1alpha1.ManageIQ, component string, maxUnavailable int, scheme *runtime.Scheme) (*policyv1.PodDisruptionBudget, controllerutil.MutateFn) {
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceName(cr, component),
+			Namespace: cr.ObjectMeta.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, pdb, scheme); err != nil {
+			return err
+		}
+		addAppLabel(cr.Spec.AppName, &pdb.ObjectMeta)
+
+		maxUnavailableValue := intstr.FromInt(maxUnavailable)
+		unhealthyPodEvictionPolicy := policyv1.AlwaysAllow
+		pdb.Spec.Selector = &metav1.LabelSelector{MatchLabels: appSelector(cr, "name", component)}
+		pdb.Spec.MaxUnavailable = &maxUnavailableValue
+		pdb.Spec.MinAvailable = nil
+		pdb.Spec.UnhealthyPodEvictionPolicy = &unhealthyPodEvictionPolicy
+		return nil
+	}
+
+	return pdb, f
+}
+
+// HttpdPodDisruptionBudget lets a node drain evict a single httpd pod at a time, the other pods
+// keep serving the UI
+func HttpdPodDisruptionBudget(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*policyv1.PodDisruptionBudget, controllerutil.MutateFn) {
+	return podDisruptionBudget(cr, "httpd", 1, scheme)
+}
+
+// MemcachedPodDisruptionBudget lets the memcached pod be evicted, it is started again on another
+// node and the users of the UI log in again
+func MemcachedPodDisruptionBudget(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*policyv1.PodDisruptionBudget, controllerutil.MutateFn) {
+	return podDisruptionBudget(cr, "memcached", 1, scheme)
+}
+
+// OrchestratorPodDisruptionBudget lets the orchestrator pod be evicted, it is started again on
+// another node
+func OrchestratorPodDisruptionBudget(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*policyv1.PodDisruptionBudget, controllerutil.MutateFn) {
+	return podDisruptionBudget(cr, "orchestrator", 1, scheme)
+}
+
+// PgbouncerPodDisruptionBudget lets the pgbouncer pod be evicted, the clients reconnect once it is
+// started again on another node
+func PgbouncerPodDisruptionBudget(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*policyv1.PodDisruptionBudget, controllerutil.MutateFn) {
+	return podDisruptionBudget(cr, "pgbouncer", 1, scheme)
+}
+
+// PostgresqlPodDisruptionBudget blocks the eviction of the single database pod of the standalone
+// mode unless PostgresqlAllowEviction is set, the replicated mode loses one pod at a time
+func PostgresqlPodDisruptionBudget(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*policyv1.PodDisruptionBudget, controllerutil.MutateFn) {
+	maxUnavailable := 0
+	if PostgresqlReplicated(cr) || postgresqlAllowEviction(cr) {
+		maxUnavailable = 1
+	}
+
+	return podDisruptionBudget(cr, "postgresql", maxUnavailable, scheme)
+}
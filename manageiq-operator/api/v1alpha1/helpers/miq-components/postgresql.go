@@ -2,6 +2,7 @@ package miqtools
 
 import (
 	"context"
+	"fmt"
 	"maps"
 
 	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
@@ -33,7 +34,14 @@ func ManagePostgresqlSecret(cr *miqv1alpha1.ManageIQ, client client.Client, sche
 		addAppLabel(cr.Spec.AppName, &secret.ObjectMeta)
 		addBackupLabelDB(cr.Spec.BackupLabelName, &secret.ObjectMeta)
 
-		if certSecret := InternalCertificatesSecret(cr, client); certSecret.Data["postgresql_crt"] != nil && certSecret.Data["postgresql_key"] != nil && string(secret.Data["hostname"]) == "postgresql" {
+		if ExternalDatabase(cr) {
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{}
+			}
+			for key, value := range externalDatabaseSecretData(cr, client) {
+				secret.Data[key] = []byte(value)
+			}
+		} else if certSecret := InternalCertificatesSecret(cr, client); certSecret.Data["postgresql_crt"] != nil && certSecret.Data["postgresql_key"] != nil && InClusterPostgresqlHostname(cr, string(secret.Data["hostname"])) {
 			d := map[string]string{
 				"rootcertificate": string(certSecret.Data["root_crt"]),
 				"sslmode":         "verify-full",
@@ -47,12 +55,19 @@ func ManagePostgresqlSecret(cr *miqv1alpha1.ManageIQ, client client.Client, sche
 	return secret, f
 }
 
+// InClusterPostgresqlHostname returns whether a database hostname points at the database deployed by
+// the operator. The migrated installs keep the legacy postgresql Service when their certificate can
+// not be reissued for the prefixed name.
+func InClusterPostgresqlHostname(cr *miqv1alpha1.ManageIQ, hostname string) bool {
+	return hostname == ResourceName(cr, "postgresql") || hostname == "postgresql"
+}
+
 func defaultPostgresqlSecret(cr *miqv1alpha1.ManageIQ) *corev1.Secret {
 	secretData := map[string]string{
 		"dbname":   "vmdb_production",
 		"username": "root",
 		"password": generatePassword(),
-		"hostname": "postgresql",
+		"hostname": ResourceName(cr, "postgresql"),
 		"port":     "5432",
 	}
 
@@ -80,7 +95,7 @@ func PostgresqlConfigMap(cr *miqv1alpha1.ManageIQ, client client.Client, scheme
 
 	configMap := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "postgresql-configs",
+			Name:      ResourceName(cr, "postgresql-configs"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 	}
@@ -89,6 +104,12 @@ func PostgresqlConfigMap(cr *miqv1alpha1.ManageIQ, client client.Client, scheme
 		if err := controllerutil.SetControllerReference(cr, configMap, scheme); err != nil {
 			return err
 		}
+
+		parameters, rules, err := postgresqlUserSettings(cr, client)
+		if err != nil {
+			return err
+		}
+
 		addAppLabel(cr.Spec.AppName, &configMap.ObjectMeta)
 		addBackupLabelDB(cr.Spec.BackupLabelName, &configMap.ObjectMeta)
 
@@ -96,6 +117,14 @@ func PostgresqlConfigMap(cr *miqv1alpha1.ManageIQ, client client.Client, scheme
 			configMap.Data = map[string]string{}
 		}
 		configMap.Data["01_miq_overrides.conf"] = postgresOverrideConfig
+		if tuning := PostgresqlTuning(cr); tuning != nil {
+			configMap.Data["02_auto_tune.conf"] = postgresqlTuningConf(tuning)
+		} else {
+			delete(configMap.Data, "02_auto_tune.conf")
+		}
+		configMap.Data["03_user.conf"] = postgresqlUserConf(parameters)
+		configMap.Data["pg_hba"] = postgresqlHbaConf(rules)
+		configMap.Data["reload.sh"] = postgresqlReloadStartScript()
 
 		if secret := InternalCertificatesSecret(cr, client); secret.Data["postgresql_crt"] != nil && secret.Data["postgresql_key"] != nil {
 			configMap.Data["02_ssl.conf"] = postgresqlSslConf()
@@ -103,13 +132,64 @@ func PostgresqlConfigMap(cr *miqv1alpha1.ManageIQ, client client.Client, scheme
 			delete(configMap.Data, "02_ssl.conf")
 		}
 
+		if PostgresqlWalArchiveEnabled(cr) {
+			backup, err := PostgresqlWalArchiveBackup(cr, client)
+			if err != nil {
+				return err
+			}
+			configMap.Data["02_wal_archive.conf"] = postgresqlWalArchiveConf()
+			configMap.Data["archive-wal.sh"] = postgresqlWalArchiveScript(backup)
+		} else {
+			delete(configMap.Data, "02_wal_archive.conf")
+			delete(configMap.Data, "archive-wal.sh")
+		}
+
+		if PostgresqlReplicated(cr) {
+			configMap.Data["replication.sh"] = postgresqlReplicationStartScript()
+			configMap.Data["standby-postgresql-conf"] = postgresqlStandbyConf(cr)
+		} else {
+			delete(configMap.Data, "replication.sh")
+			delete(configMap.Data, "standby-postgresql-conf")
+		}
+
 		return nil
 	}
 
 	return configMap, f
 }
 
+// PostgresqlPVC holds the data of the standalone database. With DatabaseSnapshotRestore it is named
+// after the VolumeSnapshot it is created from.
 func PostgresqlPVC(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.PersistentVolumeClaim, controllerutil.MutateFn) {
+	if cr.Spec.DatabaseSnapshotRestore == "" {
+		return postgresqlPVC(cr, PostgresqlClaimName(cr), scheme)
+	}
+
+	pvc, mutateFunc := postgresqlPVC(cr, cr.Spec.DatabaseSnapshotRestore, scheme)
+	apiGroup := volumeSnapshotGVK.Group
+
+	f := func() error {
+		if err := mutateFunc(); err != nil {
+			return err
+		}
+
+		if pvc.CreationTimestamp.IsZero() {
+			pvc.Spec.DataSource = &corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: volumeSnapshotGVK.Kind, Name: cr.Spec.DatabaseSnapshotRestore}
+		}
+		return nil
+	}
+
+	return pvc, f
+}
+
+// PostgresqlReplicaPVC is created ahead of the StatefulSet pod with the given ordinal, the
+// StatefulSet adopts it through its volume claim template. Unlike the claims created by the
+// StatefulSet it is owned by the CR and honors its deletion policy.
+func PostgresqlReplicaPVC(cr *miqv1alpha1.ManageIQ, ordinal int32, scheme *runtime.Scheme) (*corev1.PersistentVolumeClaim, controllerutil.MutateFn) {
+	return postgresqlPVC(cr, postgresqlReplicaClaimName(cr, ordinal), scheme)
+}
+
+func postgresqlPVC(cr *miqv1alpha1.ManageIQ, name string, scheme *runtime.Scheme) (*corev1.PersistentVolumeClaim, controllerutil.MutateFn) {
 	storageReq, _ := resource.ParseQuantity(cr.Spec.DatabaseVolumeCapacity)
 
 	resources := corev1.VolumeResourceRequirements{
@@ -124,7 +204,7 @@ func PostgresqlPVC(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Pe
 
 	pvc := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "postgresql",
+			Name:      name,
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 	}
@@ -136,11 +216,16 @@ func PostgresqlPVC(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Pe
 
 		addAppLabel(cr.Spec.AppName, &pvc.ObjectMeta)
 		addBackupLabelDB(cr.Spec.BackupLabelName, &pvc.ObjectMeta)
-		pvc.Spec.AccessModes = accessModes
-		pvc.Spec.Resources = resources
 
-		if cr.Spec.StorageClassName != "" {
-			pvc.Spec.StorageClassName = &cr.Spec.StorageClassName
+		// The spec of a claim is immutable once created, except for its storage request which is
+		// only grown after the StorageClass has been checked
+		if pvc.CreationTimestamp.IsZero() {
+			pvc.Spec.AccessModes = accessModes
+			pvc.Spec.Resources = resources
+
+			if cr.Spec.StorageClassName != "" {
+				pvc.Spec.StorageClassName = &cr.Spec.StorageClassName
+			}
 		}
 		return nil
 	}
@@ -151,7 +236,7 @@ func PostgresqlPVC(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Pe
 func PostgresqlService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Service, controllerutil.MutateFn) {
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "postgresql",
+			Name:      ResourceName(cr, "postgresql"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 	}
@@ -167,21 +252,86 @@ func PostgresqlService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev
 		}
 		service.Spec.Ports[0].Name = "postgresql"
 		service.Spec.Ports[0].Port = 5432
-		service.Spec.Selector = map[string]string{"name": "postgresql"}
+		service.Spec.Selector = appSelector(cr, "name", "postgresql")
+		if PostgresqlReplicated(cr) {
+			service.Spec.Selector["statefulset.kubernetes.io/pod-name"] = PostgresqlPodName(cr, postgresqlPrimary(cr))
+		}
 		return nil
 	}
 
 	return service, f
 }
 
-func PostgresqlDeployment(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtime.Scheme) (*appsv1.Deployment, controllerutil.MutateFn, error) {
-	deploymentLabels := map[string]string{
-		"name": "postgresql",
-		"app":  cr.Spec.AppName,
+// LegacyPostgresqlService is the postgresql Service kept by the migration to the prefixed names
+// when the internal certificate of the database is only valid for that name, it follows the
+// prefixed Service
+func LegacyPostgresqlService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Service, controllerutil.MutateFn) {
+	service, f := PostgresqlService(cr, scheme)
+	service.Name = "postgresql"
+
+	return service, f
+}
+
+// PostgresqlHeadlessService is the governing Service of the StatefulSet, it resolves the pod names
+// before they are ready so that the standbys find the primary while it starts
+func PostgresqlHeadlessService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Service, controllerutil.MutateFn) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceName(cr, "postgresql-headless"),
+			Namespace: cr.ObjectMeta.Namespace,
+		},
 	}
-	deploymentSelectorLabels := map[string]string{}
-	maps.Copy(deploymentSelectorLabels, deploymentLabels)
 
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, service, scheme); err != nil {
+			return err
+		}
+
+		addAppLabel(cr.Spec.AppName, &service.ObjectMeta)
+		if len(service.Spec.Ports) == 0 {
+			service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{})
+		}
+		service.Spec.ClusterIP = corev1.ClusterIPNone
+		service.Spec.PublishNotReadyAddresses = true
+		service.Spec.Ports[0].Name = "postgresql"
+		service.Spec.Ports[0].Port = 5432
+		service.Spec.Selector = appSelector(cr, "name", "postgresql")
+		return nil
+	}
+
+	return service, f
+}
+
+// PostgresqlReadonlyService balances read-only connections over the hot standbys
+func PostgresqlReadonlyService(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.Service, controllerutil.MutateFn) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceName(cr, "postgresql-readonly"),
+			Namespace: cr.ObjectMeta.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, service, scheme); err != nil {
+			return err
+		}
+
+		addAppLabel(cr.Spec.AppName, &service.ObjectMeta)
+		if len(service.Spec.Ports) == 0 {
+			service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{})
+		}
+		service.Spec.Ports[0].Name = "postgresql"
+		service.Spec.Ports[0].Port = 5432
+		service.Spec.Selector = appSelector(cr, "name", "postgresql")
+		service.Spec.Selector[PostgresqlRoleLabel] = PostgresqlRoleStandby
+		return nil
+	}
+
+	return service, f
+}
+
+// postgresqlContainer is the database container of the standalone Deployment and of the StatefulSet
+func postgresqlContainer(cr *miqv1alpha1.ManageIQ) (corev1.Container, error) {
 	var initialDelaySecs int32 = 60
 
 	container := corev1.Container{
@@ -209,23 +359,80 @@ func PostgresqlDeployment(cr *miqv1alpha1.ManageIQ, client client.Client, scheme
 			},
 			corev1.EnvVar{
 				Name:  "POSTGRESQL_SHARED_BUFFERS",
-				Value: cr.Spec.PostgresqlSharedBuffers,
+				Value: databaseSharedBuffers(cr),
 			},
 		},
 		VolumeMounts: []corev1.VolumeMount{
 			corev1.VolumeMount{Name: "miq-pgdb-volume", MountPath: "/var/lib/pgsql/data"},
 			corev1.VolumeMount{Name: "miq-pg-configs", MountPath: "/opt/app-root/src/postgresql-cfg/"},
+			corev1.VolumeMount{Name: "miq-pg-start", MountPath: "/opt/app-root/src/postgresql-start/"},
 		},
 	}
 
 	err := addResourceReqs(cr.Spec.PostgresqlMemoryLimit, cr.Spec.PostgresqlMemoryRequest, cr.Spec.PostgresqlCpuLimit, cr.Spec.PostgresqlCpuRequest, &container)
+
+	return container, err
+}
+
+// postgresqlConfigReloaderContainer applies the changes of the parameters which do not require a restart
+func postgresqlConfigReloaderContainer(cr *miqv1alpha1.ManageIQ) corev1.Container {
+	return corev1.Container{
+		Name:            "config-reloader",
+		Image:           cr.Spec.PostgresqlImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/bash", "-c", postgresqlConfigReloaderScript},
+		SecurityContext: DefaultSecurityContext(),
+		VolumeMounts: []corev1.VolumeMount{
+			corev1.VolumeMount{Name: "miq-pg-configs", MountPath: "/opt/app-root/src/postgresql-cfg/"},
+			corev1.VolumeMount{Name: "env-file", MountPath: "/run/secrets/postgresql", ReadOnly: true},
+		},
+	}
+}
+
+// postgresqlUserSettings returns the parameters of the PostgresqlConfigMap merged with the ones of
+// the CR, and the pg_hba.conf rules of the ConfigMap
+func postgresqlUserSettings(cr *miqv1alpha1.ManageIQ, client client.Client) (map[string]string, string, error) {
+	parameters := map[string]string{}
+	rules := ""
+
+	if cr.Spec.PostgresqlConfigMap != "" {
+		configMap := &corev1.ConfigMap{}
+		if err := client.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: cr.Spec.PostgresqlConfigMap}, configMap); err != nil {
+			return nil, "", fmt.Errorf("failed to read PostgresqlConfigMap %s: %w", cr.Spec.PostgresqlConfigMap, err)
+		}
+
+		parameters = parsePostgresqlConf(configMap.Data["postgresql.conf"])
+		if err := miqv1alpha1.ValidatePostgresqlParameters("ConfigMap "+configMap.Name+" parameter", parameters); err != nil {
+			return nil, "", err
+		}
+		rules = configMap.Data["pg_hba.conf"]
+	}
+	maps.Copy(parameters, cr.Spec.PostgresqlParameters)
+
+	return parameters, rules, nil
+}
+
+func PostgresqlDeployment(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtime.Scheme) (*appsv1.Deployment, controllerutil.MutateFn, error) {
+	deploymentLabels := map[string]string{
+		"name": "postgresql",
+		"app":  cr.Spec.AppName,
+	}
+	deploymentSelectorLabels := map[string]string{}
+	maps.Copy(deploymentSelectorLabels, deploymentLabels)
+
+	container, err := postgresqlContainer(cr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parameters, _, err := postgresqlUserSettings(cr, client)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "postgresql",
+			Name:      ResourceName(cr, "postgresql"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 		Spec: appsv1.DeploymentSpec{
@@ -250,13 +457,13 @@ func PostgresqlDeployment(cr *miqv1alpha1.ManageIQ, client client.Client, scheme
 		addBackupLabelDB(cr.Spec.BackupLabelName, &deployment.ObjectMeta)
 		addBackupLabelDB(cr.Spec.BackupLabelName, &deployment.Spec.Template.ObjectMeta)
 		addBackupAnnotation("miq-pgdb-volume", &deployment.Spec.Template.ObjectMeta)
-		var repNum int32 = 1
-		deployment.Spec.Replicas = &repNum
+		deployment.Spec.Replicas = databaseReplicas(cr)
 		deployment.Spec.Strategy = appsv1.DeploymentStrategy{
 			Type: "Recreate",
 		}
 		addAnnotations(cr.Spec.AppAnnotations, &deployment.Spec.Template.ObjectMeta)
-		deployment.Spec.Template.Spec.Containers = []corev1.Container{container}
+		addAnnotations(map[string]string{postgresqlRestartChecksumAnnotation: postgresqlRestartChecksum(parameters)}, &deployment.Spec.Template.ObjectMeta)
+		deployment.Spec.Template.Spec.Containers = []corev1.Container{container, postgresqlConfigReloaderContainer(cr)}
 		deployment.Spec.Template.Spec.Containers[0].SecurityContext = DefaultSecurityContext()
 		deployment.Spec.Template.Spec.ServiceAccountName = defaultServiceAccountName(cr.Spec.AppName)
 		deployment.Spec.Template.Spec.Volumes = []corev1.Volume{
@@ -264,7 +471,7 @@ func PostgresqlDeployment(cr *miqv1alpha1.ManageIQ, client client.Client, scheme
 				Name: "miq-pgdb-volume",
 				VolumeSource: corev1.VolumeSource{
 					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-						ClaimName: "postgresql",
+						ClaimName: PostgresqlClaimName(cr),
 					},
 				},
 			},
@@ -272,7 +479,16 @@ func PostgresqlDeployment(cr *miqv1alpha1.ManageIQ, client client.Client, scheme
 				Name: "miq-pg-configs",
 				VolumeSource: corev1.VolumeSource{
 					ConfigMap: &corev1.ConfigMapVolumeSource{
-						LocalObjectReference: corev1.LocalObjectReference{Name: "postgresql-configs"},
+						LocalObjectReference: corev1.LocalObjectReference{Name: ResourceName(cr, "postgresql-configs")},
+					},
+				},
+			},
+			corev1.Volume{
+				Name: "miq-pg-start",
+				VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: ResourceName(cr, "postgresql-configs")},
+						Items:                []corev1.KeyToPath{corev1.KeyToPath{Key: "reload.sh", Path: "reload.sh"}},
 					},
 				},
 			},
@@ -292,7 +508,16 @@ func PostgresqlDeployment(cr *miqv1alpha1.ManageIQ, client client.Client, scheme
 
 		addInternalCertificate(cr, deployment, client, "postgresql", "/opt/app-root/src/certificates")
 
+		if PostgresqlWalArchiveEnabled(cr) {
+			backup, err := PostgresqlWalArchiveBackup(cr, client)
+			if err != nil {
+				return err
+			}
+			addPostgresqlWalArchive(backup, &deployment.Spec.Template.Spec)
+		}
+
 		miqutilsv1alpha1.SetDeploymentNodeAffinity(deployment, client)
+		addPodScheduling(cr, "postgresql", &deployment.Spec.Template)
 
 		return nil
 	}
@@ -1,5 +1,65 @@
 package miqtools
 
+import (
+	"crypto/sha256"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// postgresqlRestartParameters can only be changed by restarting the server
+var postgresqlRestartParameters = map[string]bool{
+	"archive_mode":                        true,
+	"autovacuum_freeze_max_age":           true,
+	"autovacuum_max_workers":              true,
+	"autovacuum_multixact_freeze_max_age": true,
+	"bonjour":                             true,
+	"bonjour_name":                        true,
+	"cluster_name":                        true,
+	"data_sync_retry":                     true,
+	"dynamic_shared_memory_type":          true,
+	"event_source":                        true,
+	"huge_page_size":                      true,
+	"huge_pages":                          true,
+	"ignore_invalid_pages":                true,
+	"jit_provider":                        true,
+	"logging_collector":                   true,
+	"max_files_per_process":               true,
+	"max_locks_per_transaction":           true,
+	"max_logical_replication_workers":     true,
+	"max_pred_locks_per_transaction":      true,
+	"max_prepared_transactions":           true,
+	"max_replication_slots":               true,
+	"max_wal_senders":                     true,
+	"max_worker_processes":                true,
+	"min_dynamic_shared_memory":           true,
+	"old_snapshot_threshold":              true,
+	"recovery_target":                     true,
+	"recovery_target_action":              true,
+	"recovery_target_inclusive":           true,
+	"recovery_target_lsn":                 true,
+	"recovery_target_name":                true,
+	"recovery_target_time":                true,
+	"recovery_target_timeline":            true,
+	"recovery_target_xid":                 true,
+	"reserved_connections":                true,
+	"shared_memory_type":                  true,
+	"shared_preload_libraries":            true,
+	"superuser_reserved_connections":      true,
+	"track_activity_query_size":           true,
+	"track_commit_timestamp":              true,
+	"unix_socket_group":                   true,
+	"unix_socket_permissions":             true,
+	"wal_buffers":                         true,
+	"wal_decode_buffer_size":              true,
+	"wal_log_hints":                       true,
+}
+
 func postgresqlOverrideConf() string {
 	return `
 #------------------------------------------------------------------------------
@@ -12,6 +72,8 @@ tcp_keepalives_interval = 75
 
 password_encryption = scram-sha-256
 
+hba_file = '/opt/app-root/src/postgresql-cfg/pg_hba'
+
 #------------------------------------------------------------------------------
 # RESOURCE USAGE (except WAL)
 #------------------------------------------------------------------------------
@@ -102,3 +164,163 @@ ssl_key_file =  '/var/lib/pgsql/data/userdata/server.key' # server private key
 
 `
 }
+
+// postgresqlUserConf writes the parameters of the CR and its ConfigMap, it is included after the
+// defaults of the operator so that the parameters take precedence
+func postgresqlUserConf(parameters map[string]string) string {
+	conf := `
+#------------------------------------------------------------------------------
+# USER PARAMETERS
+#------------------------------------------------------------------------------
+
+`
+	for _, name := range slices.Sorted(maps.Keys(parameters)) {
+		conf += fmt.Sprintf("%s = '%s'\n", name, strings.ReplaceAll(parameters[name], "'", "''"))
+	}
+
+	return conf
+}
+
+// postgresqlHbaConf matches the rules of the ConfigMap before the defaults of the postgresql image
+func postgresqlHbaConf(rules string) string {
+	return fmt.Sprintf(`
+# Rules of the PostgresqlConfigMap
+%s
+# Default rules
+local all all peer
+host all all all md5
+host replication all all md5
+`, strings.TrimSpace(rules))
+}
+
+// parsePostgresqlConf reads the "name = value" lines of a postgresql.conf file
+func parsePostgresqlConf(conf string) map[string]string {
+	parameters := map[string]string{}
+
+	for _, line := range strings.Split(conf, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, found := strings.Cut(line, "=")
+		if !found {
+			name, value, _ = strings.Cut(line, " ")
+		}
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, "'") {
+			if end := strings.LastIndex(value, "'"); end > 0 {
+				value = strings.ReplaceAll(value[1:end], "''", "'")
+			}
+		} else if comment := strings.Index(value, "#"); comment >= 0 {
+			value = strings.TrimSpace(value[:comment])
+		}
+		parameters[strings.ToLower(strings.TrimSpace(name))] = value
+	}
+
+	return parameters
+}
+
+// postgresqlRestartChecksum changes with the user parameters which require a restart, it is set on
+// the pod template so that the database is only rolled when one of them changes
+func postgresqlRestartChecksum(parameters map[string]string) string {
+	restart := ""
+	for _, name := range slices.Sorted(maps.Keys(parameters)) {
+		if postgresqlRestartParameters[name] {
+			restart += name + "=" + parameters[name] + "\n"
+		}
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(restart)))
+}
+
+// postgresqlReloadStartScript is sourced by run-postgresql while the server is up, it allows the
+// config reloader to apply the changes of the configuration
+func postgresqlReloadStartScript() string {
+	return `
+psql --command "GRANT EXECUTE ON FUNCTION pg_reload_conf() TO \"${POSTGRESQL_USER}\";"
+`
+}
+
+// postgresqlConfigReloaderScript reloads the server once the kubelet has updated the mounted
+// configuration
+const postgresqlConfigReloaderScript = `
+export PGUSER="$(cat /run/secrets/postgresql/POSTGRESQL_USER)"
+export PGPASSWORD="$(cat /run/secrets/postgresql/POSTGRESQL_PASSWORD)"
+export PGDATABASE="$(cat /run/secrets/postgresql/POSTGRESQL_DATABASE)"
+last=""
+while true; do
+  current="$(cat /opt/app-root/src/postgresql-cfg/* 2>/dev/null | sha256sum)"
+  if [ -z "${last}" ]; then
+    last="${current}"
+  elif [ "${current}" != "${last}" ] && psql --host=127.0.0.1 --quiet --command "SELECT pg_reload_conf();" > /dev/null; then
+    echo "Configuration has been reloaded"
+    last="${current}"
+  fi
+  sleep 10
+done
+`
+
+// PostgresqlTuning derives the memory parameters from the resource limits of the database container
+// when PostgresqlAutoTune is enabled. A quarter of the memory goes to shared_buffers, the rest is
+// left to the page cache and split between the connections for work_mem, and between the parallel
+// workers allowed by the CPU count.
+func PostgresqlTuning(cr *miqv1alpha1.ManageIQ) map[string]string {
+	if !postgresqlAutoTune(cr) {
+		return nil
+	}
+
+	memoryLimit, err := resource.ParseQuantity(cr.Spec.PostgresqlMemoryLimit)
+	if err != nil {
+		return nil
+	}
+	memory := memoryLimit.Value() / 1024
+
+	connections, err := strconv.ParseInt(cr.Spec.PostgresqlMaxConnections, 10, 64)
+	if err != nil || connections <= 0 {
+		connections = 100
+	}
+
+	var cpus int64 = 1
+	for _, value := range []string{cr.Spec.PostgresqlCpuLimit, cr.Spec.PostgresqlCpuRequest} {
+		if cpu, err := resource.ParseQuantity(value); err == nil {
+			cpus = max(1, (cpu.MilliValue()+999)/1000)
+			break
+		}
+	}
+	parallelWorkers := min(4, max(1, cpus/2))
+
+	sharedBuffers := memory / 4
+	return map[string]string{
+		"effective_cache_size": postgresqlMemoryUnit(memory * 3 / 4),
+		"maintenance_work_mem": postgresqlMemoryUnit(min(memory/16, 2*1024*1024)),
+		"shared_buffers":       postgresqlMemoryUnit(sharedBuffers),
+		"work_mem":             postgresqlMemoryUnit(max(64, (memory-sharedBuffers)/(connections*3)/parallelWorkers)),
+	}
+}
+
+// postgresqlMemoryUnit formats a size in kB with the largest unit of postgresql.conf, rounded down to MB
+func postgresqlMemoryUnit(kb int64) string {
+	switch {
+	case kb >= 1024*1024 && kb%(1024*1024) == 0:
+		return fmt.Sprintf("%dGB", kb/(1024*1024))
+	case kb >= 1024:
+		return fmt.Sprintf("%dMB", kb/1024)
+	default:
+		return fmt.Sprintf("%dkB", kb)
+	}
+}
+
+func postgresqlTuningConf(tuning map[string]string) string {
+	conf := `
+#------------------------------------------------------------------------------
+# AUTO-TUNED PARAMETERS
+#------------------------------------------------------------------------------
+
+`
+	for _, name := range slices.Sorted(maps.Keys(tuning)) {
+		conf += fmt.Sprintf("%s = '%s'\n", name, tuning[name])
+	}
+
+	return conf
+}
@@ -0,0 +1,288 @@
+package miqtools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+)
+
+func TestParsePostgresqlConf(t *testing.T) {
+	conf := `
+# comment
+work_mem = 64MB
+log_line_prefix = '%t # %u'   # trailing comment
+search_path = 'it''s'
+Autovacuum_Naptime=1min
+log_min_messages warning
+  # indented comment
+`
+	want := map[string]string{
+		"work_mem":           "64MB",
+		"log_line_prefix":    "%t # %u",
+		"search_path":        "it's",
+		"autovacuum_naptime": "1min",
+		"log_min_messages":   "warning",
+	}
+
+	if parameters := parsePostgresqlConf(conf); !reflect.DeepEqual(parameters, want) {
+		t.Errorf("parsePostgresqlConf() = %v, want %v", parameters, want)
+	}
+}
+
+func TestPostgresqlUserSettings(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "manageiq", Name: "postgresql-user"},
+		Data: map[string]string{
+			"postgresql.conf": "work_mem = 64MB\nlog_min_duration_statement = 1000\n",
+			"pg_hba.conf":     "hostssl all all 10.0.0.0/8 scram-sha-256\n",
+		},
+	}
+	invalidConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "manageiq", Name: "postgresql-invalid"},
+		Data:       map[string]string{"postgresql.conf": "max_connections = 10\n"},
+	}
+	client := fake.NewClientBuilder().WithObjects(configMap, invalidConfigMap).Build()
+
+	tests := []struct {
+		name       string
+		configMap  string
+		parameters map[string]string
+		want       map[string]string
+		rules      string
+		err        string
+	}{
+		{
+			name: "no settings",
+			want: map[string]string{},
+		},
+		{
+			name:       "CR parameters",
+			parameters: map[string]string{"work_mem": "32MB"},
+			want:       map[string]string{"work_mem": "32MB"},
+		},
+		{
+			name:      "ConfigMap",
+			configMap: "postgresql-user",
+			want:      map[string]string{"work_mem": "64MB", "log_min_duration_statement": "1000"},
+			rules:     "hostssl all all 10.0.0.0/8 scram-sha-256\n",
+		},
+		{
+			name:       "CR parameters override the ConfigMap",
+			configMap:  "postgresql-user",
+			parameters: map[string]string{"work_mem": "128MB", "autovacuum_naptime": "1min"},
+			want:       map[string]string{"work_mem": "128MB", "log_min_duration_statement": "1000", "autovacuum_naptime": "1min"},
+			rules:      "hostssl all all 10.0.0.0/8 scram-sha-256\n",
+		},
+		{
+			name:      "managed parameter in the ConfigMap",
+			configMap: "postgresql-invalid",
+			err:       `"max_connections" is set by the operator`,
+		},
+		{
+			name:      "missing ConfigMap",
+			configMap: "postgresql-missing",
+			err:       "failed to read PostgresqlConfigMap postgresql-missing",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cr := &miqv1alpha1.ManageIQ{ObjectMeta: metav1.ObjectMeta{Namespace: "manageiq", Name: "miq"}}
+			cr.Spec.PostgresqlConfigMap = test.configMap
+			cr.Spec.PostgresqlParameters = test.parameters
+
+			parameters, rules, err := postgresqlUserSettings(cr, client)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("postgresqlUserSettings() error = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("postgresqlUserSettings() failed: %v", err)
+			}
+			if !reflect.DeepEqual(parameters, test.want) {
+				t.Errorf("postgresqlUserSettings() parameters = %v, want %v", parameters, test.want)
+			}
+			if rules != test.rules {
+				t.Errorf("postgresqlUserSettings() rules = %q, want %q", rules, test.rules)
+			}
+		})
+	}
+}
+
+func TestPostgresqlUserConf(t *testing.T) {
+	conf := postgresqlUserConf(map[string]string{"work_mem": "64MB", "search_path": "it's"})
+
+	if !strings.HasSuffix(conf, "search_path = 'it''s'\nwork_mem = '64MB'\n") {
+		t.Errorf("postgresqlUserConf() = %q, want the sorted and quoted parameters", conf)
+	}
+	if parameters := parsePostgresqlConf(conf); !reflect.DeepEqual(parameters, map[string]string{"work_mem": "64MB", "search_path": "it's"}) {
+		t.Errorf("parsePostgresqlConf(postgresqlUserConf()) = %v", parameters)
+	}
+}
+
+func TestPostgresqlHbaConf(t *testing.T) {
+	conf := postgresqlHbaConf("\nhostssl all all 10.0.0.0/8 scram-sha-256\n")
+
+	user := strings.Index(conf, "hostssl all all 10.0.0.0/8 scram-sha-256")
+	defaults := strings.Index(conf, "host all all all md5")
+	if user < 0 || defaults < 0 || user > defaults {
+		t.Errorf("postgresqlHbaConf() = %q, want the user rules before the default rules", conf)
+	}
+}
+
+func TestPostgresqlRestartChecksum(t *testing.T) {
+	base := postgresqlRestartChecksum(map[string]string{"work_mem": "64MB", "shared_preload_libraries": "pg_stat_statements"})
+
+	tests := []struct {
+		name       string
+		parameters map[string]string
+		changed    bool
+	}{
+		{"reloadable parameter changed", map[string]string{"work_mem": "128MB", "shared_preload_libraries": "pg_stat_statements"}, false},
+		{"reloadable parameter removed", map[string]string{"shared_preload_libraries": "pg_stat_statements"}, false},
+		{"restart parameter changed", map[string]string{"work_mem": "64MB", "shared_preload_libraries": "auto_explain"}, true},
+		{"restart parameter removed", map[string]string{"work_mem": "64MB"}, true},
+		{"restart parameter added", map[string]string{"work_mem": "64MB", "shared_preload_libraries": "pg_stat_statements", "wal_buffers": "32MB"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if changed := postgresqlRestartChecksum(test.parameters) != base; changed != test.changed {
+				t.Errorf("postgresqlRestartChecksum() changed = %v, want %v", changed, test.changed)
+			}
+		})
+	}
+}
+
+func TestPostgresqlTuning(t *testing.T) {
+	enabled, disabled := true, false
+
+	tests := []struct {
+		name        string
+		autoTune    *bool
+		memory      string
+		cpuLimit    string
+		cpuRequest  string
+		connections string
+		want        map[string]string
+	}{
+		{
+			name:     "auto tune unset",
+			memory:   "4Gi",
+			cpuLimit: "2",
+		},
+		{
+			name:     "auto tune disabled",
+			autoTune: &disabled,
+			memory:   "4Gi",
+			cpuLimit: "2",
+		},
+		{
+			name:     "no memory limit",
+			autoTune: &enabled,
+			cpuLimit: "2",
+		},
+		{
+			name:     "invalid memory limit",
+			autoTune: &enabled,
+			memory:   "lots",
+		},
+		{
+			name:     "default connections",
+			autoTune: &enabled,
+			memory:   "4Gi",
+			cpuLimit: "2",
+			want: map[string]string{
+				"effective_cache_size": "3GB",
+				"maintenance_work_mem": "256MB",
+				"shared_buffers":       "1GB",
+				"work_mem":             "10MB",
+			},
+		},
+		{
+			name:        "work_mem split across the parallel workers",
+			autoTune:    &enabled,
+			memory:      "16Gi",
+			cpuLimit:    "8",
+			cpuRequest:  "1",
+			connections: "200",
+			want: map[string]string{
+				"effective_cache_size": "12GB",
+				"maintenance_work_mem": "1GB",
+				"shared_buffers":       "4GB",
+				"work_mem":             "5MB",
+			},
+		},
+		{
+			name:        "maintenance_work_mem capped and CPU request rounded up",
+			autoTune:    &enabled,
+			memory:      "64Gi",
+			cpuRequest:  "1500m",
+			connections: "0",
+			want: map[string]string{
+				"effective_cache_size": "48GB",
+				"maintenance_work_mem": "2GB",
+				"shared_buffers":       "16GB",
+				"work_mem":             "163MB",
+			},
+		},
+		{
+			name:        "work_mem floor",
+			autoTune:    &enabled,
+			memory:      "256Mi",
+			connections: "2000",
+			want: map[string]string{
+				"effective_cache_size": "192MB",
+				"maintenance_work_mem": "16MB",
+				"shared_buffers":       "64MB",
+				"work_mem":             "64kB",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cr := &miqv1alpha1.ManageIQ{}
+			cr.Spec.PostgresqlAutoTune = test.autoTune
+			cr.Spec.PostgresqlMemoryLimit = test.memory
+			cr.Spec.PostgresqlCpuLimit = test.cpuLimit
+			cr.Spec.PostgresqlCpuRequest = test.cpuRequest
+			cr.Spec.PostgresqlMaxConnections = test.connections
+
+			if tuning := PostgresqlTuning(cr); !reflect.DeepEqual(tuning, test.want) {
+				t.Errorf("PostgresqlTuning() = %v, want %v", tuning, test.want)
+			}
+		})
+	}
+}
+
+func TestPostgresqlMemoryUnit(t *testing.T) {
+	tests := []struct {
+		kb   int64
+		want string
+	}{
+		{0, "0kB"},
+		{512, "512kB"},
+		{1023, "1023kB"},
+		{1024, "1MB"},
+		{1536, "1MB"},
+		{1048575, "1023MB"},
+		{1048576, "1GB"},
+		{1572864, "1536MB"},
+		{3145728, "3GB"},
+	}
+
+	for _, test := range tests {
+		if unit := postgresqlMemoryUnit(test.kb); unit != test.want {
+			t.Errorf("postgresqlMemoryUnit(%d) = %q, want %q", test.kb, unit, test.want)
+		}
+	}
+}
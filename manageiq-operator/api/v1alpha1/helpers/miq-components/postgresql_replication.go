@@ -0,0 +1,349 @@
+package miqtools
+
+import (
+	"fmt"
+	"maps"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqutilsv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/miqutils"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// PostgresqlRoleLabel is set by the operator on the database pods of the replicated mode
+	PostgresqlRoleLabel   = "manageiq.org/postgresql-role"
+	PostgresqlRolePrimary = "primary"
+	PostgresqlRoleStandby = "standby"
+
+	postgresqlRestartChecksumAnnotation = "manageiq.org/postgresql-restart-checksum"
+)
+
+// PostgresqlReplicated returns whether the in-cluster database runs as a StatefulSet with standbys
+func PostgresqlReplicated(cr *miqv1alpha1.ManageIQ) bool {
+	return cr.Spec.PostgresqlMode == miqv1alpha1.PostgresqlModeReplicated
+}
+
+// PostgresqlPodName returns the name of the StatefulSet pod with the given ordinal
+func PostgresqlPodName(cr *miqv1alpha1.ManageIQ, ordinal int32) string {
+	return fmt.Sprintf("%s-%d", ResourceName(cr, "postgresql"), ordinal)
+}
+
+// PostgresqlPodRole returns the role label value of a database pod of the replicated mode
+func PostgresqlPodRole(cr *miqv1alpha1.ManageIQ, pod *corev1.Pod) string {
+	if pod.Name == PostgresqlPodName(cr, postgresqlPrimary(cr)) {
+		return PostgresqlRolePrimary
+	}
+	return PostgresqlRoleStandby
+}
+
+// PostgresqlClaimName returns the name of the PersistentVolumeClaim holding the data of the
+// in-cluster database, the claim of the primary in replicated mode. The claim restored from
+// DatabaseSnapshotRestore is recorded in the status, and kept once the field is cleared.
+func PostgresqlClaimName(cr *miqv1alpha1.ManageIQ) string {
+	if PostgresqlReplicated(cr) {
+		return postgresqlReplicaClaimName(cr, postgresqlPrimary(cr))
+	}
+	if cr.Spec.DatabaseSnapshotRestore != "" {
+		return cr.Spec.DatabaseSnapshotRestore
+	}
+	if cr.Status.PostgresqlClaimName != "" {
+		return cr.Status.PostgresqlClaimName
+	}
+	return ResourceName(cr, "postgresql")
+}
+
+// postgresqlReplicaClaimName follows the naming of the claims created from a volume claim template
+func postgresqlReplicaClaimName(cr *miqv1alpha1.ManageIQ, ordinal int32) string {
+	return "miq-pgdb-volume-" + PostgresqlPodName(cr, ordinal)
+}
+
+func postgresqlPrimaryHost(cr *miqv1alpha1.ManageIQ) string {
+	return PostgresqlPodName(cr, postgresqlPrimary(cr)) + "." + ResourceName(cr, "postgresql-headless")
+}
+
+// postgresqlReplicationStartScript is sourced by run-postgresql on the primary while the server is
+// up, it allows the database user to clone the primary and stream its WAL. The replication
+// connections are allowed by the pg_hba.conf of the operator.
+func postgresqlReplicationStartScript() string {
+	return `
+psql --command "ALTER ROLE \"${POSTGRESQL_USER}\" WITH REPLICATION;" --command "SELECT pg_reload_conf();"
+`
+}
+
+// postgresqlStandbyConf is the configuration of the standbys. They are started without
+// run-postgresql, which writes to the database, so the settings it generates are repeated here.
+func postgresqlStandbyConf(cr *miqv1alpha1.ManageIQ) string {
+	return fmt.Sprintf(`
+listen_addresses = '*'
+max_connections = %s
+shared_buffers = %s
+ident_file = '/var/lib/pgsql/data/userdata/pg_ident.conf'
+include_dir '/opt/app-root/src/postgresql-cfg'
+`, cr.Spec.PostgresqlMaxConnections, databaseSharedBuffers(cr))
+}
+
+// postgresqlReplicatedScript starts the pod with the primary ordinal as the primary. A standby left
+// with its standby signal keeps streaming from the former primary until no other pod runs as a
+// primary, so that the WAL sent by the clean shutdown of the former primary is not lost, and is then
+// promoted. The other pods wait for the primary and rewind their data directory onto its timeline,
+// which also turns a former primary into a standby. They only clone the primary when they have no
+// data directory yet or the rewind fails.
+const postgresqlReplicatedScript = `set -e
+PGDATA=/var/lib/pgsql/data/userdata
+STANDBY_CONF=/opt/app-root/src/postgresql-standby/postgresql.conf
+export PGUSER="$(cat /run/secrets/postgresql/POSTGRESQL_USER)"
+export PGPASSWORD="$(cat /run/secrets/postgresql/POSTGRESQL_PASSWORD)"
+export PGCONNECT_TIMEOUT=5
+
+is_primary() {
+  [ "$(psql --host="$1" --dbname=postgres --tuples-only --no-align --command="SELECT pg_is_in_recovery()" 2>/dev/null)" = "f" ]
+}
+
+if [ "${HOSTNAME##*-}" = "${POSTGRESQL_PRIMARY_ORDINAL}" ]; then
+  if [ -f "${PGDATA}/standby.signal" ]; then
+    postgres -D "${PGDATA}" --config-file="${STANDBY_CONF}" &
+    for ordinal in $(seq 0 $((POSTGRESQL_REPLICAS - 1))); do
+      [ "${ordinal}" = "${POSTGRESQL_PRIMARY_ORDINAL}" ] && continue
+      host="${HOSTNAME%-*}-${ordinal}.${POSTGRESQL_PRIMARY_HOST#*.}"
+      while is_primary "${host}"; do
+        echo "Waiting for the former primary ${host} to stop"
+        sleep 5
+      done
+    done
+    echo "Promoting to the primary"
+    pg_ctl promote -D "${PGDATA}" --wait
+    pg_ctl stop -D "${PGDATA}" --mode=fast --wait
+  fi
+  exec run-postgresql
+fi
+
+until is_primary "${POSTGRESQL_PRIMARY_HOST}"; do
+  echo "Waiting for the primary ${POSTGRESQL_PRIMARY_HOST}"
+  sleep 5
+done
+
+SOURCE="host=${POSTGRESQL_PRIMARY_HOST} dbname=postgres"
+if [ -f "${PGDATA}/PG_VERSION" ] && pg_rewind --target-pgdata="${PGDATA}" --source-server="${SOURCE}" --write-recovery-conf; then
+  echo "Rewound onto the primary ${POSTGRESQL_PRIMARY_HOST}"
+else
+  echo "Cloning the primary ${POSTGRESQL_PRIMARY_HOST}"
+  rm -rf "${PGDATA}"
+  pg_basebackup --host="${POSTGRESQL_PRIMARY_HOST}" --pgdata="${PGDATA}" --wal-method=stream --checkpoint=fast --write-recovery-conf
+  chmod 700 "${PGDATA}"
+fi
+exec postgres -D "${PGDATA}" --config-file="${STANDBY_CONF}"
+`
+
+// PostgresqlStatefulSet runs the in-cluster database in replicated mode. The pods are managed in
+// parallel so that the standbys do not hold back the start of a primary with a higher ordinal, and
+// they are replaced by the operator on updates so that the primary ordinal is replaced first.
+func PostgresqlStatefulSet(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtime.Scheme) (*appsv1.StatefulSet, controllerutil.MutateFn, error) {
+	statefulSetLabels := map[string]string{
+		"name": "postgresql",
+		"app":  cr.Spec.AppName,
+	}
+	statefulSetSelectorLabels := map[string]string{}
+	maps.Copy(statefulSetSelectorLabels, statefulSetLabels)
+
+	container, err := postgresqlContainer(cr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parameters, _, err := postgresqlUserSettings(cr, client)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	container.Args = []string{"/bin/bash", "-c", postgresqlReplicatedScript}
+	container.Env = append(container.Env,
+		corev1.EnvVar{Name: "POSTGRESQL_PRIMARY_HOST", Value: postgresqlPrimaryHost(cr)},
+		corev1.EnvVar{Name: "POSTGRESQL_PRIMARY_ORDINAL", Value: fmt.Sprint(postgresqlPrimary(cr))},
+		corev1.EnvVar{Name: "POSTGRESQL_REPLICAS", Value: fmt.Sprint(postgresqlReplicas(cr))},
+	)
+	container.VolumeMounts = append(container.VolumeMounts,
+		corev1.VolumeMount{Name: "miq-pg-standby-configs", MountPath: "/opt/app-root/src/postgresql-standby/"},
+		corev1.VolumeMount{Name: "env-file", MountPath: "/run/secrets/postgresql", ReadOnly: true},
+	)
+	container.SecurityContext = DefaultSecurityContext()
+
+	configMapVolume := func(name string, items ...corev1.KeyToPath) corev1.Volume {
+		return corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: ResourceName(cr, "postgresql-configs")},
+					Items:                items,
+				},
+			},
+		}
+	}
+
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceName(cr, "postgresql"),
+			Namespace: cr.ObjectMeta.Namespace,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: statefulSetSelectorLabels,
+			},
+			ServiceName:         ResourceName(cr, "postgresql-headless"),
+			PodManagementPolicy: appsv1.ParallelPodManagement,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: statefulSetLabels,
+					Name:   "postgresql",
+				},
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+				corev1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{Name: "miq-pgdb-volume"},
+				},
+			},
+		},
+	}
+
+	pvc, pvcMutateFunc := postgresqlPVC(cr, "miq-pgdb-volume", scheme)
+	if err := pvcMutateFunc(); err != nil {
+		return nil, nil, err
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, statefulSet, scheme); err != nil {
+			return err
+		}
+		addAppLabel(cr.Spec.AppName, &statefulSet.ObjectMeta)
+		addBackupLabelDB(cr.Spec.BackupLabelName, &statefulSet.ObjectMeta)
+		addBackupLabelDB(cr.Spec.BackupLabelName, &statefulSet.Spec.Template.ObjectMeta)
+		addBackupAnnotation("miq-pgdb-volume", &statefulSet.Spec.Template.ObjectMeta)
+		statefulSet.Spec.Replicas = databaseReplicas(cr)
+		statefulSet.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType}
+		addAnnotations(cr.Spec.AppAnnotations, &statefulSet.Spec.Template.ObjectMeta)
+		addAnnotations(map[string]string{postgresqlRestartChecksumAnnotation: postgresqlRestartChecksum(parameters)}, &statefulSet.Spec.Template.ObjectMeta)
+		statefulSet.Spec.Template.Spec.Containers = []corev1.Container{container, postgresqlConfigReloaderContainer(cr)}
+		statefulSet.Spec.Template.Spec.ServiceAccountName = defaultServiceAccountName(cr.Spec.AppName)
+		statefulSet.Spec.Template.Spec.Volumes = []corev1.Volume{
+			configMapVolume("miq-pg-configs"),
+			configMapVolume("miq-pg-start", corev1.KeyToPath{Key: "reload.sh", Path: "reload.sh"}, corev1.KeyToPath{Key: "replication.sh", Path: "replication.sh"}),
+			configMapVolume("miq-pg-standby-configs", corev1.KeyToPath{Key: "standby-postgresql-conf", Path: "postgresql.conf"}),
+			corev1.Volume{
+				Name: "env-file",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName: cr.Spec.DatabaseSecret,
+						Items: []corev1.KeyToPath{
+							corev1.KeyToPath{Key: "dbname", Path: "POSTGRESQL_DATABASE"},
+							corev1.KeyToPath{Key: "password", Path: "POSTGRESQL_PASSWORD"},
+							corev1.KeyToPath{Key: "username", Path: "POSTGRESQL_USER"},
+						},
+					},
+				},
+			},
+		}
+
+		// The volume claim templates of a StatefulSet are immutable
+		if statefulSet.CreationTimestamp.IsZero() {
+			statefulSet.Spec.VolumeClaimTemplates[0].ObjectMeta.Labels = pvc.Labels
+			statefulSet.Spec.VolumeClaimTemplates[0].Spec = pvc.Spec
+		}
+
+		addInternalCertificateToTemplate(cr, &statefulSet.Spec.Template, client, "postgresql", "/opt/app-root/src/certificates")
+
+		miqutilsv1alpha1.SetPodTemplateNodeAffinity(&statefulSet.Spec.Template, cr.Namespace, client)
+		addPodScheduling(cr, "postgresql", &statefulSet.Spec.Template)
+
+		return nil
+	}
+
+	return statefulSet, f, nil
+}
+
+// PostgresqlDataCopyJob copies the data directory of the stopped database when it moves between the
+// standalone Deployment and the StatefulSet
+func PostgresqlDataCopyJob(cr *miqv1alpha1.ManageIQ, sourceClaim string, targetClaim string, client client.Client, scheme *runtime.Scheme) (*batchv1.Job, controllerutil.MutateFn) {
+	return postgresqlCopyJob(cr, "postgresql-copy", sourceClaim, targetClaim, client, scheme)
+}
+
+// postgresqlCopyJob copies the data directory of the stopped database from the sourceClaim to the
+// targetClaim, replacing the one found there
+func postgresqlCopyJob(cr *miqv1alpha1.ManageIQ, name string, sourceClaim string, targetClaim string, client client.Client, scheme *runtime.Scheme) (*batchv1.Job, controllerutil.MutateFn) {
+	var backoffLimit int32 = 2
+
+	claimVolume := func(name, claimName string) corev1.Volume {
+		return corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+			},
+		}
+	}
+
+	container := corev1.Container{
+		Name:            "postgresql-copy",
+		Image:           cr.Spec.PostgresqlImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command: []string{"/bin/bash", "-c", `set -e
+if [ ! -d /source/userdata ]; then
+  echo "No data directory to copy"
+  exit 0
+fi
+rm -rf /target/userdata
+cp -a /source/userdata /target/userdata
+rm -f /target/userdata/standby.signal /target/userdata/postmaster.pid
+echo "Data directory has been copied"
+`},
+		SecurityContext: DefaultSecurityContext(),
+		VolumeMounts: []corev1.VolumeMount{
+			corev1.VolumeMount{Name: "source", MountPath: "/source", ReadOnly: true},
+			corev1.VolumeMount{Name: "target", MountPath: "/target"},
+		},
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceName(cr, name),
+			Namespace: cr.ObjectMeta.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, job, scheme); err != nil {
+			return err
+		}
+
+		addAppLabel(cr.Spec.AppName, &job.ObjectMeta)
+
+		// The pod template of a Job is immutable
+		if !job.CreationTimestamp.IsZero() {
+			return nil
+		}
+
+		job.Spec.BackoffLimit = &backoffLimit
+		job.Spec.Template.ObjectMeta.Labels = map[string]string{"app": cr.Spec.AppName, "name": name}
+		job.Spec.Template.Spec.Containers = []corev1.Container{container}
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		job.Spec.Template.Spec.ServiceAccountName = defaultServiceAccountName(cr.Spec.AppName)
+		job.Spec.Template.Spec.Volumes = []corev1.Volume{
+			claimVolume("source", sourceClaim),
+			claimVolume("target", targetClaim),
+		}
+
+		if cr.Spec.ImagePullSecret != "" {
+			job.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{corev1.LocalObjectReference{Name: cr.Spec.ImagePullSecret}}
+		}
+
+		miqutilsv1alpha1.SetPodTemplateNodeAffinity(&job.Spec.Template, cr.Namespace, client)
+		AddNodeScheduling(cr, "postgresql", &job.Spec.Template)
+
+		return nil
+	}
+
+	return job, f
+}
@@ -0,0 +1,217 @@
+package miqtools
+
+import (
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqutilsv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/miqutils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// PostgresqlUpgradingCondition is True from the moment a major version upgrade of the database
+// starts until its Job has completed, the application is scaled down in the meantime
+const PostgresqlUpgradingCondition = "PostgresqlUpgrading"
+
+// The version Job writes the major version of the data directory, or none, and the one of the image
+const postgresqlVersionScript = `set -e
+data_version=none
+if [ -f /var/lib/pgsql/data/userdata/PG_VERSION ]; then
+  data_version=$(cat /var/lib/pgsql/data/userdata/PG_VERSION)
+fi
+image_version=$(postgres --version | sed -E 's/^[^0-9]*([0-9]+).*$/\1/')
+echo "${data_version} ${image_version}" > /dev/termination-log
+`
+
+// The old binaries are copied along with the libraries they load, except the ones of glibc which
+// are backwards compatible, and are run through wrappers pointing them at those libraries. The
+// server finds its share and lib directories relative to the copied binaries.
+const postgresqlOldBinariesScript = `set -e
+bindir=$(dirname "$(command -v postgres)")
+prefix=$(dirname "${bindir}")
+mkdir -p /old/bin /old/lib /old/usr/bin /old/usr/lib64 /old/usr/share
+cp -a "${bindir}/pg_controldata" "${bindir}/pg_ctl" "${bindir}/postgres" /old/usr/bin/
+cp -a "${prefix}/lib64/pgsql" /old/usr/lib64/
+cp -a "${prefix}/share/pgsql" /old/usr/share/
+for file in /old/usr/bin/* /old/usr/lib64/pgsql/*.so; do
+  ldd "${file}" | awk '/=> \// { print $3 }'
+done | sort -u | grep -v -E '/(ld-linux[^/]*|libc|libdl|libm|libpthread|libresolv|librt)\.so' | xargs -r cp -L -t /old/lib
+for binary in /old/usr/bin/*; do
+  printf '#!/bin/bash\nLD_LIBRARY_PATH=/old/lib exec %s "$@"\n' "${binary}" > "/old/bin/$(basename "${binary}")"
+  chmod +x "/old/bin/$(basename "${binary}")"
+done
+`
+
+// The upgrade runs the servers against an empty configuration with trust authentication over a
+// socket in /tmp, the include lines of the image are only restored once the data directories are
+// swapped. It is resumed after a failure once the new data directory is in place.
+const postgresqlUpgradeScript = `set -e
+data=/var/lib/pgsql/data
+old_data=${data}/userdata
+new_data=${data}/userdata-upgrade
+new_bindir=$(dirname "$(command -v postgres)")
+new_version=$(postgres --version | sed -E 's/^[^0-9]*([0-9]+).*$/\1/')
+old_version=$(cat "${old_data}/PG_VERSION")
+
+if ! whoami > /dev/null 2>&1; then
+  echo "postgres:x:$(id -u):0:PostgreSQL Server:/var/lib/pgsql:/bin/bash" > /tmp/passwd
+  export LD_PRELOAD=libnss_wrapper.so NSS_WRAPPER_PASSWD=/tmp/passwd NSS_WRAPPER_GROUP=/etc/group
+fi
+
+cd /tmp
+echo "local all all trust" > /tmp/pg_hba.conf
+: > /tmp/postgresql.conf
+options="-c config_file=/tmp/postgresql.conf -c hba_file=/tmp/pg_hba.conf -c listen_addresses='' -c unix_socket_directories=/tmp"
+
+if [ "${old_version}" != "${new_version}" ]; then
+  echo "Upgrading the data directory from PostgreSQL ${old_version} to PostgreSQL ${new_version}"
+  /old/bin/pg_ctl --pgdata="${old_data}" --options="${options}" --wait start
+  read -r encoding collate ctype < <(psql --host=/tmp --username=postgres --dbname=postgres --no-align --tuples-only --field-separator=' ' \
+    --command="SELECT pg_encoding_to_char(encoding), datcollate, datctype FROM pg_database WHERE datname = 'template0'")
+  /old/bin/pg_ctl --pgdata="${old_data}" --wait stop
+
+  rm -rf "${new_data}"
+  initdb --username=postgres --encoding="${encoding}" --lc-collate="${collate}" --lc-ctype="${ctype}" --pgdata="${new_data}"
+  pg_upgrade --username=postgres --link --socketdir=/tmp \
+    --old-bindir=/old/bin --new-bindir="${new_bindir}" \
+    --old-datadir="${old_data}" --new-datadir="${new_data}" \
+    --old-options="-c config_file=/tmp/postgresql.conf -c hba_file=/tmp/pg_hba.conf" \
+    --new-options="-c hba_file=/tmp/pg_hba.conf"
+
+  grep -E '^include' "${old_data}/postgresql.conf" >> "${new_data}/postgresql.conf" || true
+  cp -a "${old_data}/pg_hba.conf" "${old_data}/pg_ident.conf" "${new_data}/"
+  mv "${old_data}" "${data}/userdata-${old_version}"
+  mv "${new_data}" "${old_data}"
+  rm -rf "${data}/userdata-${old_version}"
+fi
+
+echo "Updating the planner statistics"
+pg_ctl --pgdata="${old_data}" --options="${options}" --wait start
+vacuumdb --host=/tmp --username=postgres --all --analyze-in-stages
+pg_ctl --pgdata="${old_data}" --wait stop
+echo "Data directory has been upgraded to PostgreSQL ${new_version}"
+`
+
+// PostgresqlUpgrading returns whether a major version upgrade of the database is in progress
+func PostgresqlUpgrading(cr *miqv1alpha1.ManageIQ) bool {
+	return apimeta.IsStatusConditionTrue(cr.Status.Conditions, PostgresqlUpgradingCondition)
+}
+
+// PostgresqlUpgradeBackupPVC receives a copy of the data directory before it is upgraded, it is
+// kept once the upgrade has completed
+func PostgresqlUpgradeBackupPVC(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.PersistentVolumeClaim, controllerutil.MutateFn) {
+	return postgresqlPVC(cr, ResourceName(cr, "postgresql-upgrade-backup"), scheme)
+}
+
+// PostgresqlUpgradeBackupJob copies the data directory of the stopped database to the
+// PostgresqlUpgradeBackupPVC before pg_upgrade runs
+func PostgresqlUpgradeBackupJob(cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtime.Scheme) (*batchv1.Job, controllerutil.MutateFn) {
+	return postgresqlCopyJob(cr, "postgresql-upgrade-backup", PostgresqlClaimName(cr), ResourceName(cr, "postgresql-upgrade-backup"), client, scheme)
+}
+
+// PostgresqlVersionJob reports the major versions of the data directory and of the database image
+// in the termination message of its pod. The database pod may still be running, the Job is then
+// scheduled on its node to share the ReadWriteOnce PVC.
+func PostgresqlVersionJob(cr *miqv1alpha1.ManageIQ, nodeName string, client client.Client, scheme *runtime.Scheme) (*batchv1.Job, controllerutil.MutateFn) {
+	container := corev1.Container{
+		Name:            "postgresql-version",
+		Image:           cr.Spec.PostgresqlImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/bash", "-c", postgresqlVersionScript},
+		SecurityContext: DefaultSecurityContext(),
+		VolumeMounts: []corev1.VolumeMount{
+			corev1.VolumeMount{Name: "miq-pgdb-volume", MountPath: "/var/lib/pgsql/data", ReadOnly: true},
+		},
+	}
+
+	return postgresqlUpgradeJob(cr, "postgresql-version", nodeName, nil, container, client, scheme)
+}
+
+// PostgresqlUpgradeJob runs pg_upgrade on the data directory of the database, with the binaries of
+// the image it was last run with copied from an init container
+func PostgresqlUpgradeJob(cr *miqv1alpha1.ManageIQ, oldImage string, client client.Client, scheme *runtime.Scheme) (*batchv1.Job, controllerutil.MutateFn) {
+	oldBinariesMount := corev1.VolumeMount{Name: "old-binaries", MountPath: "/old"}
+
+	initContainer := corev1.Container{
+		Name:            "postgresql-old-binaries",
+		Image:           oldImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/bash", "-c", postgresqlOldBinariesScript},
+		SecurityContext: DefaultSecurityContext(),
+		VolumeMounts:    []corev1.VolumeMount{oldBinariesMount},
+	}
+
+	container := corev1.Container{
+		Name:            "postgresql-upgrade",
+		Image:           cr.Spec.PostgresqlImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/bash", "-c", postgresqlUpgradeScript},
+		SecurityContext: DefaultSecurityContext(),
+		VolumeMounts: []corev1.VolumeMount{
+			corev1.VolumeMount{Name: "miq-pgdb-volume", MountPath: "/var/lib/pgsql/data"},
+			oldBinariesMount,
+		},
+	}
+
+	return postgresqlUpgradeJob(cr, "postgresql-upgrade", "", []corev1.Container{initContainer}, container, client, scheme)
+}
+
+func postgresqlUpgradeJob(cr *miqv1alpha1.ManageIQ, name string, nodeName string, initContainers []corev1.Container, container corev1.Container, client client.Client, scheme *runtime.Scheme) (*batchv1.Job, controllerutil.MutateFn) {
+	var backoffLimit int32 = 2
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceName(cr, name),
+			Namespace: cr.ObjectMeta.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(cr, job, scheme); err != nil {
+			return err
+		}
+
+		addAppLabel(cr.Spec.AppName, &job.ObjectMeta)
+
+		// The pod template of a Job is immutable
+		if !job.CreationTimestamp.IsZero() {
+			return nil
+		}
+
+		job.Spec.BackoffLimit = &backoffLimit
+		job.Spec.Template.ObjectMeta.Labels = map[string]string{"app": cr.Spec.AppName, "name": name}
+		job.Spec.Template.Spec.InitContainers = initContainers
+		job.Spec.Template.Spec.Containers = []corev1.Container{container}
+		job.Spec.Template.Spec.NodeName = nodeName
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		job.Spec.Template.Spec.ServiceAccountName = defaultServiceAccountName(cr.Spec.AppName)
+		job.Spec.Template.Spec.Volumes = []corev1.Volume{
+			corev1.Volume{
+				Name: "miq-pgdb-volume",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: PostgresqlClaimName(cr)},
+				},
+			},
+		}
+		if len(initContainers) > 0 {
+			job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, corev1.Volume{
+				Name:         "old-binaries",
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			})
+		}
+
+		if cr.Spec.ImagePullSecret != "" {
+			job.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{corev1.LocalObjectReference{Name: cr.Spec.ImagePullSecret}}
+		}
+
+		miqutilsv1alpha1.SetPodTemplateNodeAffinity(&job.Spec.Template, cr.Namespace, client)
+		AddNodeScheduling(cr, "postgresql", &job.Spec.Template)
+
+		return nil
+	}
+
+	return job, f
+}
@@ -0,0 +1,120 @@
+package miqtools
+
+import (
+	"context"
+	"fmt"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PostgresqlWalArchiveEnabled returns whether the WAL of the standalone database deployed by the
+// operator is archived to the storage of the PostgresqlWalArchiveBackup
+func PostgresqlWalArchiveEnabled(cr *miqv1alpha1.ManageIQ) bool {
+	return cr.Spec.PostgresqlWalArchiveBackup != "" && !ExternalDatabase(cr) && !PostgresqlReplicated(cr)
+}
+
+// PostgresqlWalArchiveBackup returns the ManageIQBackup whose storage receives the WAL, it must take
+// base backups so that the WAL can be replayed over them
+func PostgresqlWalArchiveBackup(cr *miqv1alpha1.ManageIQ, client client.Client) (*miqv1alpha1.ManageIQBackup, error) {
+	backup := &miqv1alpha1.ManageIQBackup{}
+	if err := client.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: cr.Spec.PostgresqlWalArchiveBackup}, backup); err != nil {
+		return nil, fmt.Errorf("failed to read PostgresqlWalArchiveBackup %s: %w", cr.Spec.PostgresqlWalArchiveBackup, err)
+	}
+	if backupMethod(backup) != miqv1alpha1.BackupMethodBaseBackup {
+		return nil, fmt.Errorf("PostgresqlWalArchiveBackup %s must use the %s method", backup.Name, miqv1alpha1.BackupMethodBaseBackup)
+	}
+
+	return backup, nil
+}
+
+// postgresqlWalArchiveConf is written before the user parameters so that archive_timeout can be
+// overridden, the other settings are rejected by the validation
+func postgresqlWalArchiveConf() string {
+	return `
+#------------------------------------------------------------------------------
+# WAL ARCHIVING
+#------------------------------------------------------------------------------
+
+archive_mode = on
+archive_command = 'bash /opt/app-root/src/postgresql-cfg/archive-wal.sh "%p" "%f"'
+archive_timeout = 5min
+`
+}
+
+// postgresqlWalArchiveScript is the archive_command of the database. A segment is written under a
+// temporary name and renamed so that the archive never holds a partial one. With an S3 storage the
+// segment is left for the wal-archiver container, and only reported as archived once uploaded.
+func postgresqlWalArchiveScript(backup *miqv1alpha1.ManageIQBackup) string {
+	script := `set -e
+ARCHIVE_DIR=/wal-archive/wal
+mkdir -p "${ARCHIVE_DIR}"
+`
+	if backup.Spec.Storage.S3 == nil {
+		return script + `if [ -f "${ARCHIVE_DIR}/$2" ]; then
+  cmp --silent "$1" "${ARCHIVE_DIR}/$2"
+  exit 0
+fi
+cp "$1" "${ARCHIVE_DIR}/.$2"
+sync "${ARCHIVE_DIR}/.$2"
+mv "${ARCHIVE_DIR}/.$2" "${ARCHIVE_DIR}/$2"
+`
+	}
+
+	return script + `cp "$1" "${ARCHIVE_DIR}/.$2"
+mv "${ARCHIVE_DIR}/.$2" "${ARCHIVE_DIR}/$2"
+for i in $(seq 300); do
+  [ -e "${ARCHIVE_DIR}/$2" ] || exit 0
+  sleep 1
+done
+echo "WAL segment $2 has not been uploaded to the archive"
+exit 1
+`
+}
+
+// walArchiverScript uploads the segments left by the archive_command to the wal directory of the
+// bucket, next to the base backups
+func walArchiverScript(backup *miqv1alpha1.ManageIQBackup) string {
+	return fmt.Sprintf(`set -e
+MC="mc ${MC_FLAGS}"
+${MC} alias set backup "${S3_ENDPOINT}" "${AWS_ACCESS_KEY_ID}" "${AWS_SECRET_ACCESS_KEY}"
+mkdir -p /wal-archive/wal
+while true; do
+  for segment in /wal-archive/wal/*; do
+    [ -f "${segment}" ] || continue
+    if ${MC} cp --quiet "${segment}" "%s/wal/${segment##*/}"; then
+      rm -f "${segment}"
+    fi
+  done
+  sleep 1
+done
+`, backupS3Path(backup.Spec.Storage.S3))
+}
+
+// addPostgresqlWalArchive mounts the WAL archive in the database container at /wal-archive. The
+// claim of the backups is mounted directly, an S3 storage goes through the wal-archiver container.
+func addPostgresqlWalArchive(backup *miqv1alpha1.ManageIQBackup, podSpec *corev1.PodSpec) {
+	mount := corev1.VolumeMount{Name: "wal-archive", MountPath: "/wal-archive"}
+	podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, mount)
+
+	s3 := backup.Spec.Storage.S3
+	if s3 == nil {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: "wal-archive",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: BackupClaimName(backup)},
+			},
+		})
+		return
+	}
+
+	archiver := s3Container(s3, "wal-archiver", walArchiverScript(backup))
+	archiver.VolumeMounts = []corev1.VolumeMount{mount, corev1.VolumeMount{Name: "mc-config", MountPath: "/tmp/.mc"}}
+	podSpec.Containers = append(podSpec.Containers, archiver)
+	podSpec.Volumes = append(podSpec.Volumes,
+		corev1.Volume{Name: "wal-archive", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		corev1.Volume{Name: "mc-config", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	)
+}
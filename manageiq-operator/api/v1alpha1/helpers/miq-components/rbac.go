@@ -54,10 +54,12 @@ func DefaultServiceAccount(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*c
 	return sa, f
 }
 
+// The automation objects keep the name manageiq-automation with the default AppName, the name of the
+// ServiceAccount is passed to the orchestrator for the automation pods
 func AutomationRole(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*rbacv1.Role, controllerutil.MutateFn) {
 	role := &rbacv1.Role{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "manageiq-automation",
+			Name:      ResourceName(cr, "automation"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 	}
@@ -89,7 +91,7 @@ func AutomationRole(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*rbacv1.R
 func AutomationRoleBinding(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*rbacv1.RoleBinding, controllerutil.MutateFn) {
 	rb := &rbacv1.RoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "manageiq-automation",
+			Name:      ResourceName(cr, "automation"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 	}
@@ -101,13 +103,13 @@ func AutomationRoleBinding(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*r
 
 		rb.RoleRef = rbacv1.RoleRef{
 			Kind:     "Role",
-			Name:     "manageiq-automation",
+			Name:     ResourceName(cr, "automation"),
 			APIGroup: "rbac.authorization.k8s.io",
 		}
 		rb.Subjects = []rbacv1.Subject{
 			rbacv1.Subject{
 				Kind: "ServiceAccount",
-				Name: "manageiq-automation",
+				Name: ResourceName(cr, "automation"),
 			},
 		}
 
@@ -120,7 +122,7 @@ func AutomationRoleBinding(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*r
 func AutomationServiceAccount(cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.ServiceAccount, controllerutil.MutateFn) {
 	sa := &corev1.ServiceAccount{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "manageiq-automation",
+			Name:      ResourceName(cr, "automation"),
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 	}
@@ -0,0 +1,289 @@
+package miqtools
+
+import (
+	"fmt"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqutilsv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/miqutils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// RestoreServiceAccount lets the restore Job put the encryption key of the backup back into the
+// app-secrets
+func RestoreServiceAccount(restore *miqv1alpha1.ManageIQRestore, cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*corev1.ServiceAccount, controllerutil.MutateFn) {
+	serviceAccount := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restore.Name,
+			Namespace: restore.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(restore, serviceAccount, scheme); err != nil {
+			return err
+		}
+		addAppLabel(cr.Spec.AppName, &serviceAccount.ObjectMeta)
+
+		return nil
+	}
+
+	return serviceAccount, f
+}
+
+func RestoreRole(restore *miqv1alpha1.ManageIQRestore, cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*rbacv1.Role, controllerutil.MutateFn) {
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restore.Name,
+			Namespace: restore.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(restore, role, scheme); err != nil {
+			return err
+		}
+		addAppLabel(cr.Spec.AppName, &role.ObjectMeta)
+
+		role.Rules = []rbacv1.PolicyRule{
+			rbacv1.PolicyRule{
+				APIGroups:     []string{""},
+				Resources:     []string{"secrets"},
+				ResourceNames: []string{appSecretName(cr)},
+				Verbs:         []string{"get", "patch"},
+			},
+		}
+
+		return nil
+	}
+
+	return role, f
+}
+
+func RestoreRoleBinding(restore *miqv1alpha1.ManageIQRestore, cr *miqv1alpha1.ManageIQ, scheme *runtime.Scheme) (*rbacv1.RoleBinding, controllerutil.MutateFn) {
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restore.Name,
+			Namespace: restore.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(restore, roleBinding, scheme); err != nil {
+			return err
+		}
+		addAppLabel(cr.Spec.AppName, &roleBinding.ObjectMeta)
+
+		roleBinding.RoleRef = rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "Role",
+			Name:     restore.Name,
+		}
+		roleBinding.Subjects = []rbacv1.Subject{
+			rbacv1.Subject{
+				Kind:      "ServiceAccount",
+				Name:      restore.Name,
+				Namespace: restore.Namespace,
+			},
+		}
+
+		return nil
+	}
+
+	return roleBinding, f
+}
+
+// restoreSelectScript picks the requested backup, or the most recent one taken before the
+// TARGET_ID, from /backups
+const restoreSelectScript = `set -e
+if [ -z "${BACKUP_ID}" ]; then
+  BACKUP_ID=$(ls -1d /backups/[0-9]*/ 2>/dev/null | xargs -r -n 1 basename | sort | while read -r id; do
+    if [ -z "${TARGET_ID}" ] || [ "${id}" -le "${TARGET_ID}" ]; then echo "${id}"; fi
+  done | tail -n 1)
+fi
+if [ -z "${BACKUP_ID}" ] || [ ! -d "/backups/${BACKUP_ID}" ]; then
+  echo "No backup found in the storage"
+  exit 1
+fi
+BACKUP_DIR="/backups/${BACKUP_ID}"
+echo "Restoring backup ${BACKUP_ID}"
+`
+
+// restoreRecoveryScript replays the archived WAL over the base backup up to the TARGET_TIME. The
+// server is started without run-postgresql, which writes to the database, and stopped once it has
+// been promoted, the database then starts from the recovered data directory.
+func restoreRecoveryScript(cr *miqv1alpha1.ManageIQ) string {
+	return fmt.Sprintf(`if [ -n "${TARGET_TIME}" ]; then
+  echo "Replaying the WAL up to ${TARGET_TIME}"
+  RECOVERY_DIR=/data/recovery
+  rm -rf "${RECOVERY_DIR}"
+  mkdir -p "${RECOVERY_DIR}"
+  touch "${RECOVERY_DIR}/pg_ident.conf"
+  echo "local all all trust" > "${RECOVERY_DIR}/pg_hba.conf"
+  cat > "${RECOVERY_DIR}/postgresql.conf" <<EOF
+listen_addresses = ''
+unix_socket_directories = '${RECOVERY_DIR}'
+hba_file = '${RECOVERY_DIR}/pg_hba.conf'
+ident_file = '${RECOVERY_DIR}/pg_ident.conf'
+max_connections = %s
+hot_standby = off
+archive_mode = off
+restore_command = 'cp /backups/wal/%%f "%%p"'
+recovery_target_time = '${TARGET_TIME}'
+recovery_target_action = 'promote'
+EOF
+  touch /data/userdata/recovery.signal
+  postgres -D /data/userdata --config-file="${RECOVERY_DIR}/postgresql.conf" &
+  POSTGRES_PID=$!
+  until pg_isready --quiet --host="${RECOVERY_DIR}"; do
+    if ! kill -0 "${POSTGRES_PID}" 2>/dev/null; then
+      echo "The WAL could not be replayed up to ${TARGET_TIME}"
+      exit 1
+    fi
+    sleep 5
+  done
+  pg_ctl stop --pgdata=/data/userdata --mode=fast
+  rm -rf "${RECOVERY_DIR}"
+  echo "The database has been recovered up to ${TARGET_TIME}"
+fi
+`, cr.Spec.PostgresqlMaxConnections)
+}
+
+// restoreScript restores the database from the backup and puts its encryption key back into the
+// app-secrets. A base backup replaces the data directory of the stopped in-cluster database, the
+// archived WAL is then replayed over it up to the TARGET_TIME.
+func restoreScript(cr *miqv1alpha1.ManageIQ) string {
+	return restoreSelectScript + fmt.Sprintf(`if [ -d "${BACKUP_DIR}/basebackup" ]; then
+  if [ ! -d /data ]; then
+    echo "Backup ${BACKUP_ID} was taken with the BaseBackup method"
+    exit 1
+  fi
+  rm -rf /data/userdata
+  mkdir -p /data/userdata/pg_wal
+  tar -xzf "${BACKUP_DIR}/basebackup/base.tar.gz" -C /data/userdata
+  tar -xzf "${BACKUP_DIR}/basebackup/pg_wal.tar.gz" -C /data/userdata/pg_wal
+  chmod 700 /data/userdata
+%[2]selse
+  pg_restore --clean --if-exists --exit-on-error --single-transaction --dbname="${PGDATABASE}" "${BACKUP_DIR}/database.dump"
+fi
+TOKEN_DIR=/var/run/secrets/kubernetes.io/serviceaccount
+curl --fail --silent --show-error --cacert "${TOKEN_DIR}/ca.crt" \
+  -H "Authorization: Bearer $(cat "${TOKEN_DIR}/token")" \
+  -H "Content-Type: application/merge-patch+json" -X PATCH \
+  --data "{\"data\":{\"encryption-key\":\"$(base64 -w0 < "${BACKUP_DIR}/encryption-key")\"}}" \
+  "https://kubernetes.default.svc/api/v1/namespaces/$(cat "${TOKEN_DIR}/namespace")/secrets/%[1]s" > /dev/null
+echo "Backup ${BACKUP_ID} has been restored"
+`, appSecretName(cr), restoreRecoveryScript(cr))
+}
+
+// restoreDownloadScript copies the requested backup, or the most recent one taken before the
+// TARGET_ID, from the bucket along with the archived WAL when it is replayed
+func restoreDownloadScript(backup *miqv1alpha1.ManageIQBackup) string {
+	return fmt.Sprintf(`set -e
+MC="mc ${MC_FLAGS}"
+${MC} alias set backup "${S3_ENDPOINT}" "${AWS_ACCESS_KEY_ID}" "${AWS_SECRET_ACCESS_KEY}"
+if [ -z "${BACKUP_ID}" ]; then
+  BACKUP_ID=$(${MC} ls "%[1]s/" | while read -r line; do
+    case "${line##* }" in [0-9]*/) echo "${line##* }";; esac
+  done | sort | while read -r id; do
+    if [ -z "${TARGET_ID}" ] || [ "${id%%/}" -le "${TARGET_ID}" ]; then echo "${id%%/}"; fi
+  done | tail -n 1)
+fi
+if [ -z "${BACKUP_ID}" ]; then
+  echo "No backup found in the storage"
+  exit 1
+fi
+${MC} cp --recursive "%[1]s/${BACKUP_ID}/" "/backups/${BACKUP_ID}/"
+if [ -n "${TARGET_TIME}" ]; then
+  ${MC} cp --recursive "%[1]s/wal/" "/backups/wal/"
+fi
+`, backupS3Path(backup.Spec.Storage.S3))
+}
+
+// RestoreJob restores a backup of a ManageIQBackup while the application is quiesced. With the
+// BaseBackup method the database volume is mounted in place of the stopped database.
+func RestoreJob(restore *miqv1alpha1.ManageIQRestore, backup *miqv1alpha1.ManageIQBackup, cr *miqv1alpha1.ManageIQ, client client.Client, scheme *runtime.Scheme) (*batchv1.Job, controllerutil.MutateFn) {
+	var backoffLimit int32 = 0
+	s3 := backup.Spec.Storage.S3
+	env := []corev1.EnvVar{corev1.EnvVar{Name: "BACKUP_ID", Value: restore.Spec.Backup}}
+	if restore.Spec.TargetTime != nil {
+		target := restore.Spec.TargetTime.UTC()
+		env = append(env,
+			corev1.EnvVar{Name: "TARGET_ID", Value: target.Format("20060102150405")},
+			corev1.EnvVar{Name: "TARGET_TIME", Value: target.Format("2006-01-02 15:04:05+00")},
+		)
+	}
+
+	container := corev1.Container{
+		Name:            "postgresql-restore",
+		Image:           cr.Spec.PostgresqlImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/bash", "-c", restoreScript(cr)},
+		Env:             append(databaseEnv(cr), env...),
+		SecurityContext: DefaultSecurityContext(),
+		VolumeMounts: []corev1.VolumeMount{
+			corev1.VolumeMount{Name: "backups", MountPath: "/backups", ReadOnly: s3 == nil},
+			corev1.VolumeMount{Name: "database-secret", MountPath: "/run/secrets/postgresql", ReadOnly: true},
+		},
+	}
+
+	volumes := append(backupStorageVolumes(backup, true), databaseRootCertificateVolume(cr))
+	if backupMethod(backup) == miqv1alpha1.BackupMethodBaseBackup {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: "miq-pgdb-volume", MountPath: "/data"})
+		volumes = append(volumes, corev1.Volume{
+			Name: "miq-pgdb-volume",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: PostgresqlClaimName(cr)},
+			},
+		})
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restore.Name,
+			Namespace: restore.Namespace,
+		},
+	}
+
+	f := func() error {
+		if err := controllerutil.SetControllerReference(restore, job, scheme); err != nil {
+			return err
+		}
+
+		addAppLabel(cr.Spec.AppName, &job.ObjectMeta)
+
+		// The pod template of a Job is immutable
+		if !job.CreationTimestamp.IsZero() {
+			return nil
+		}
+
+		job.Spec.BackoffLimit = &backoffLimit
+		job.Spec.Template.ObjectMeta.Labels = map[string]string{"app": cr.Spec.AppName, "name": "postgresql-restore"}
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		job.Spec.Template.Spec.ServiceAccountName = restore.Name
+		job.Spec.Template.Spec.Containers = []corev1.Container{container}
+		job.Spec.Template.Spec.Volumes = volumes
+
+		if s3 != nil {
+			download := s3Container(s3, "download", restoreDownloadScript(backup))
+			download.Env = append(download.Env, env...)
+			job.Spec.Template.Spec.InitContainers = []corev1.Container{download}
+		}
+
+		if cr.Spec.ImagePullSecret != "" {
+			job.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{corev1.LocalObjectReference{Name: cr.Spec.ImagePullSecret}}
+		}
+
+		miqutilsv1alpha1.SetPodTemplateNodeAffinity(&job.Spec.Template, cr.Namespace, client)
+		AddNodeScheduling(cr, "postgresql", &job.Spec.Template)
+
+		return nil
+	}
+
+	return job, f
+}
@@ -0,0 +1,89 @@
+package miqtools
+
+import (
+	"maps"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqutilsv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/miqutils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// addPodScheduling places and spreads the pods of a component with the scheduling of the component
+// and the TopologySpreadConstraints and PodAntiAffinity of the CR. It is called after the node
+// affinity of the operator architecture is set, which replaces the whole affinity of the template,
+// and merges the affinities with it.
+func addPodScheduling(cr *miqv1alpha1.ManageIQ, component string, template *corev1.PodTemplateSpec) {
+	selector := &metav1.LabelSelector{MatchLabels: appSelector(cr, "name", component)}
+
+	template.Spec.TopologySpreadConstraints = nil
+	for _, c := range cr.Spec.TopologySpreadConstraints {
+		constraint := c.DeepCopy()
+		if constraint.LabelSelector == nil {
+			constraint.LabelSelector = selector
+		}
+		template.Spec.TopologySpreadConstraints = append(template.Spec.TopologySpreadConstraints, *constraint)
+	}
+
+	AddNodeScheduling(cr, component, template)
+	if podAntiAffinity := podAntiAffinity(cr, selector); podAntiAffinity != nil {
+		template.Spec.Affinity = miqutilsv1alpha1.MergeAffinity(template.Spec.Affinity, &corev1.Affinity{PodAntiAffinity: podAntiAffinity})
+	}
+}
+
+// AddNodeScheduling places pods on the nodes of a component with the nodeSelector, tolerations,
+// priorityClassName and affinity of its scheduling, e.g. the Jobs mounting the database volume on
+// the nodes of postgresql. It is called after the node affinity of the operator architecture is set.
+func AddNodeScheduling(cr *miqv1alpha1.ManageIQ, component string, template *corev1.PodTemplateSpec) {
+	scheduling := componentPodScheduling(cr, component)
+	template.Spec.NodeSelector = maps.Clone(scheduling.NodeSelector)
+	template.Spec.Tolerations = nil
+	for _, toleration := range scheduling.Tolerations {
+		template.Spec.Tolerations = append(template.Spec.Tolerations, *toleration.DeepCopy())
+	}
+	template.Spec.PriorityClassName = scheduling.PriorityClassName
+
+	template.Spec.Affinity = miqutilsv1alpha1.MergeAffinity(template.Spec.Affinity, scheduling.Affinity)
+}
+
+// componentPodScheduling returns the scheduling of the pods of a component, empty when not set
+func componentPodScheduling(cr *miqv1alpha1.ManageIQ, component string) *miqv1alpha1.PodScheduling {
+	var scheduling *miqv1alpha1.PodScheduling
+	switch component {
+	case "httpd":
+		scheduling = cr.Spec.HttpdScheduling
+	case "memcached":
+		scheduling = cr.Spec.MemcachedScheduling
+	case "orchestrator":
+		scheduling = cr.Spec.OrchestratorScheduling
+	case "pgbouncer":
+		scheduling = cr.Spec.PgbouncerScheduling
+	case "postgresql":
+		scheduling = cr.Spec.PostgresqlScheduling
+	}
+
+	if scheduling == nil {
+		return &miqv1alpha1.PodScheduling{}
+	}
+	return scheduling
+}
+
+// podAntiAffinity keeps the pods matched by the selector on different nodes
+func podAntiAffinity(cr *miqv1alpha1.ManageIQ, selector *metav1.LabelSelector) *corev1.PodAntiAffinity {
+	term := corev1.PodAffinityTerm{LabelSelector: selector, TopologyKey: "kubernetes.io/hostname"}
+
+	switch cr.Spec.PodAntiAffinity {
+	case "preferred":
+		return &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+				corev1.WeightedPodAffinityTerm{Weight: 100, PodAffinityTerm: term},
+			},
+		}
+	case "required":
+		return &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{term},
+		}
+	}
+
+	return nil
+}
@@ -62,6 +62,17 @@ func addResourceReqs(memLimit, memReq, cpuLimit, cpuReq string, c *corev1.Contai
 	return nil
 }
 
+// ResourceName prefixes the name of an object generated for the CR with the AppName so that
+// several ManageIQ instances can share a namespace
+func ResourceName(cr *miqv1alpha1.ManageIQ, name string) string {
+	return cr.Spec.AppName + "-" + name
+}
+
+// appSelector narrows a label selector to the pods of the CR's application
+func appSelector(cr *miqv1alpha1.ManageIQ, key string, value string) map[string]string {
+	return map[string]string{"app": cr.Spec.AppName, key: value}
+}
+
 func addAppLabel(appName string, meta *metav1.ObjectMeta) {
 	AddLabel("app", appName, meta)
 }
@@ -121,17 +132,21 @@ func InternalCertificatesSecret(cr *miqv1alpha1.ManageIQ, client client.Client)
 }
 
 func addInternalCertificate(cr *miqv1alpha1.ManageIQ, d *appsv1.Deployment, client client.Client, name string, mountPoint string) {
+	addInternalCertificateToTemplate(cr, &d.Spec.Template, client, name, mountPoint)
+}
+
+func addInternalCertificateToTemplate(cr *miqv1alpha1.ManageIQ, template *corev1.PodTemplateSpec, client client.Client, name string, mountPoint string) {
 	secret := InternalCertificatesSecret(cr, client)
 	if secret.Data[fmt.Sprintf("%s_crt", name)] != nil && secret.Data[fmt.Sprintf("%s_key", name)] != nil {
 		volumeName := fmt.Sprintf("%s-certificate", name)
 
 		volumeMount := corev1.VolumeMount{Name: volumeName, MountPath: mountPoint, ReadOnly: true}
-		d.Spec.Template.Spec.Containers[0].VolumeMounts = addOrUpdateVolumeMount(d.Spec.Template.Spec.Containers[0].VolumeMounts, volumeMount)
+		template.Spec.Containers[0].VolumeMounts = addOrUpdateVolumeMount(template.Spec.Containers[0].VolumeMounts, volumeMount)
 
 		secretVolumeSource := corev1.SecretVolumeSource{SecretName: secret.Name, Items: []corev1.KeyToPath{corev1.KeyToPath{Key: fmt.Sprintf("%s_crt", name), Path: "server.crt"}, corev1.KeyToPath{Key: fmt.Sprintf("%s_key", name), Path: "server.key"}}}
-		d.Spec.Template.Spec.Volumes = addOrUpdateVolume(d.Spec.Template.Spec.Volumes, corev1.Volume{Name: volumeName, VolumeSource: corev1.VolumeSource{Secret: &secretVolumeSource}})
+		template.Spec.Volumes = addOrUpdateVolume(template.Spec.Volumes, corev1.Volume{Name: volumeName, VolumeSource: corev1.VolumeSource{Secret: &secretVolumeSource}})
 
-		AddLabel("ssl-certificate-resource-version", secret.ObjectMeta.ResourceVersion, &d.Spec.Template.ObjectMeta)
+		AddLabel("ssl-certificate-resource-version", secret.ObjectMeta.ResourceVersion, &template.ObjectMeta)
 	}
 }
 
@@ -152,6 +167,16 @@ func addOrUpdateEnvVar(environment []corev1.EnvVar, variable corev1.EnvVar) []co
 	return environment
 }
 
+func removeEnvVar(environment []corev1.EnvVar, name string) []corev1.EnvVar {
+	for i, env := range environment {
+		if env.Name == name {
+			return append(environment[:i], environment[i+1:]...)
+		}
+	}
+
+	return environment
+}
+
 func addOrUpdateProjectedSecretVolumeSource(volumeName string, volumes []corev1.Volume, volumeProjection *corev1.VolumeProjection) corev1.ProjectedVolumeSource {
 	projectedVolumeSource := corev1.ProjectedVolumeSource{}
 
@@ -210,6 +235,71 @@ func addOrUpdateVolume(volumes []corev1.Volume, volume corev1.Volume) []corev1.V
 	return volumes
 }
 
+func removeVolume(volumes []corev1.Volume, name string) []corev1.Volume {
+	for i, volume := range volumes {
+		if volume.Name == name {
+			return append(volumes[:i], volumes[i+1:]...)
+		}
+	}
+
+	return volumes
+}
+
+func removeVolumeMount(volumeMounts []corev1.VolumeMount, name string) []corev1.VolumeMount {
+	for i, volumeMount := range volumeMounts {
+		if volumeMount.Name == name {
+			return append(volumeMounts[:i], volumeMounts[i+1:]...)
+		}
+	}
+
+	return volumeMounts
+}
+
+// applicationReplicas returns the replica count of the application deployments, which are
+// scaled down while the CR is in maintenance mode, quiesced for a restore or while the database
+// is upgraded
+func applicationReplicas(cr *miqv1alpha1.ManageIQ) *int32 {
+	var repNum int32 = 1
+	if cr.Spec.MaintenanceMode != nil && *cr.Spec.MaintenanceMode {
+		repNum = 0
+	}
+	if Quiesced(cr) || PostgresqlUpgrading(cr) {
+		repNum = 0
+	}
+
+	return &repNum
+}
+
+// databaseReplicas returns the replica count of the in-cluster database, the primary and its
+// standbys in replicated mode. It is only scaled down while a restore replaces its data directory.
+func databaseReplicas(cr *miqv1alpha1.ManageIQ) *int32 {
+	var repNum int32 = 1
+	if cr.Spec.PostgresqlMode == miqv1alpha1.PostgresqlModeReplicated {
+		repNum = postgresqlReplicas(cr)
+	}
+	if cr.Annotations[miqv1alpha1.QuiesceAnnotation] == miqv1alpha1.QuiesceDatabase {
+		repNum = 0
+	}
+
+	return &repNum
+}
+
+// databaseSharedBuffers returns the shared_buffers of the in-cluster database, derived from the
+// memory limit when PostgresqlAutoTune is enabled
+func databaseSharedBuffers(cr *miqv1alpha1.ManageIQ) string {
+	if tuning := PostgresqlTuning(cr); tuning != nil {
+		return tuning["shared_buffers"]
+	}
+
+	return cr.Spec.PostgresqlSharedBuffers
+}
+
+// Quiesced returns whether a ManageIQRestore has quiesced the application
+func Quiesced(cr *miqv1alpha1.ManageIQ) bool {
+	_, ok := cr.Annotations[miqv1alpha1.QuiesceAnnotation]
+	return ok
+}
+
 func DefaultSecurityContext() *corev1.SecurityContext {
 	dropCapability := []corev1.Capability{"ALL"}
 	varFalse := false
@@ -0,0 +1,48 @@
+package miqtools
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// VolumeResizingCondition is True while a PVC grown after a capacity change of the CR has not
+// reached its new size
+const VolumeResizingCondition = "Resizing"
+
+// StorageClassAllowsExpansion returns whether the StorageClass of the claim allows growing its
+// volume, along with the name of the StorageClass. Claims without a StorageClass can not grow.
+func StorageClassAllowsExpansion(pvc *corev1.PersistentVolumeClaim, c client.Client) (bool, string, error) {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return false, "", nil
+	}
+
+	storageClass := &storagev1.StorageClass{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: *pvc.Spec.StorageClassName}, storageClass); errors.IsNotFound(err) {
+		return false, *pvc.Spec.StorageClassName, nil
+	} else if err != nil {
+		return false, *pvc.Spec.StorageClassName, err
+	}
+
+	return storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion, storageClass.Name, nil
+}
+
+// VolumeClaimResizing returns whether the volume of a bound claim is being grown to its storage
+// request, which includes the file system resize done by the kubelet
+func VolumeClaimResizing(pvc *corev1.PersistentVolumeClaim) bool {
+	if pvc.Status.Phase != corev1.ClaimBound {
+		return false
+	}
+
+	for _, condition := range pvc.Status.Conditions {
+		if (condition.Type == corev1.PersistentVolumeClaimResizing || condition.Type == corev1.PersistentVolumeClaimFileSystemResizePending) && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	return pvc.Status.Capacity.Storage().Cmp(*pvc.Spec.Resources.Requests.Storage()) < 0
+}
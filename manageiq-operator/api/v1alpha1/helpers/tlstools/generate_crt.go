@@ -1,11 +1,13 @@
 package tlstools
 
 import (
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"errors"
 	"math/big"
 	"time"
 )
@@ -50,3 +52,85 @@ func GenerateCrt(CN string) (crt []byte, key []byte, err error) {
 
 	return newcrt, newkey, nil
 }
+
+// GenerateSignedCrt issues a server certificate for the hosts signed by the CA, the first host is the
+// common name
+func GenerateSignedCrt(hosts []string, caCrt []byte, caKey []byte) (crt []byte, key []byte, err error) {
+	caBlock, _ := pem.Decode(caCrt)
+	if caBlock == nil {
+		return nil, nil, errors.New("the CA certificate is not PEM encoded")
+	}
+	ca, err := x509.ParseCertificate(caBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	caSigner, err := parsePrivateKey(caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	notBefore := time.Now()
+	// One year from now
+	notAfter := notBefore.AddDate(1, 0, 0)
+
+	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName: hosts[0],
+		},
+		DNSNames:  hosts,
+		NotBefore: notBefore,
+		NotAfter:  notAfter,
+
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, ca, &priv.PublicKey, caSigner)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	privBytes := x509.MarshalPKCS1PrivateKey(priv)
+
+	newcrt := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
+	newkey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: privBytes})
+
+	return newcrt, newkey, nil
+}
+
+// parsePrivateKey decodes a PKCS#1, PKCS#8 or EC private key
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("the private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("the private key can not sign certificates")
+	}
+
+	return signer, nil
+}
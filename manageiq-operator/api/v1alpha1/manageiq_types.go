@@ -17,16 +17,35 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"errors"
-	"fmt"
-	"strings"
-
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+const (
+	DeletionPolicyDelete   = "Delete"
+	DeletionPolicyRetain   = "Retain"
+	DeletionPolicySnapshot = "Snapshot"
+
+	DriftDetectionCorrect = "Correct"
+	DriftDetectionReport  = "Report"
+
+	PostgresqlModeStandalone = "standalone"
+	PostgresqlModeReplicated = "replicated"
+
+	// QuiesceAnnotation is set on the CR by a ManageIQRestore to scale down the orchestrator, httpd
+	// and memcached (Application), or also the in-cluster database (Database), for the restore
+	QuiesceAnnotation  = "manageiq.org/quiesce"
+	QuiesceApplication = "Application"
+	QuiesceDatabase    = "Database"
+
+	// RotateDatabaseCredentialsAnnotation is set on the CR to rotate the database password, a new
+	// value of the annotation triggers another rotation
+	RotateDatabaseCredentialsAnnotation = "manageiq.org/rotate-database-credentials"
+)
+
 // ManageIQSpec defines the desired state of ManageIQ
 type ManageIQSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -52,22 +71,88 @@ type ManageIQSpec struct {
 	// +optional
 	BaseWorkerImage string `json:"baseWorkerImage,omitempty"`
 
+	// Cron schedule, in UTC, of the rotation of the database password, the password is only rotated on demand if not provided
+	// Note: the password of an external database is only changed in the DatabaseSecret, it must then be set on the database
+	// +optional
+	DatabaseCredentialsRotationSchedule string `json:"databaseCredentialsRotationSchedule,omitempty"`
+
+	// Cron schedule, in UTC, of the REINDEX CONCURRENTLY of the DatabaseMaintenanceReindexTables, no reindex is run if not provided
+	// +optional
+	DatabaseMaintenanceReindexSchedule string `json:"databaseMaintenanceReindexSchedule,omitempty"`
+
+	// Tables reindexed on the DatabaseMaintenanceReindexSchedule, e.g. metrics or public.vim_performance_states
+	// +optional
+	DatabaseMaintenanceReindexTables []string `json:"databaseMaintenanceReindexTables,omitempty"`
+
+	// Cron schedule, in UTC, of the report of the size and the dead tuples of the largest tables, no report is made if not provided
+	// +optional
+	DatabaseMaintenanceReportSchedule string `json:"databaseMaintenanceReportSchedule,omitempty"`
+
+	// Cron schedule, in UTC, of the VACUUM ANALYZE of the DatabaseMaintenanceVacuumTables, no vacuum is run if not provided
+	// +optional
+	DatabaseMaintenanceVacuumSchedule string `json:"databaseMaintenanceVacuumSchedule,omitempty"`
+
+	// Tables vacuumed on the DatabaseMaintenanceVacuumSchedule (default: the whole database)
+	// +optional
+	DatabaseMaintenanceVacuumTables []string `json:"databaseMaintenanceVacuumTables,omitempty"`
+
 	// Database region number (default: 0)
 	// +optional
 	DatabaseRegion string `json:"databaseRegion,omitempty"`
 
-	// Secret containing the database access information, content generated if not provided (default: postgresql-secrets)
+	// Secret containing the database access information, content generated if not provided (default: <AppName>-postgresql-secrets)
 	// +optional
 	DatabaseSecret string `json:"databaseSecret,omitempty"`
 
+	// VolumeSnapshotClass of the database snapshots (default: the default VolumeSnapshotClass of the cluster)
+	// +optional
+	DatabaseSnapshotClassName string `json:"databaseSnapshotClassName,omitempty"`
+
+	// Flag to scale down the orchestrator and its workers while a database snapshot is taken (default: false)
+	// +optional
+	DatabaseSnapshotPauseOrchestrator *bool `json:"databaseSnapshotPauseOrchestrator,omitempty"`
+
+	// Name of a database VolumeSnapshot to restore, the database is moved to a new PVC created from the snapshot
+	// Note: the database is restarted on the restored PVC, the previous PVC is kept; clearing the field keeps the database on the restored PVC, see status.postgresqlClaimName
+	// +optional
+	DatabaseSnapshotRestore string `json:"databaseSnapshotRestore,omitempty"`
+
+	// Number of database VolumeSnapshots kept, the oldest ones are removed after each snapshot (default: 7)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	DatabaseSnapshotRetention *int32 `json:"databaseSnapshotRetention,omitempty"`
+
+	// Cron schedule, in UTC, of the VolumeSnapshots of the database PVC, no snapshots are taken if not provided
+	// Note: a CHECKPOINT is issued before each snapshot, the snapshots are crash consistent
+	// +optional
+	DatabaseSnapshotSchedule string `json:"databaseSnapshotSchedule,omitempty"`
+
 	// Database volume size (default: 15Gi)
 	// +optional
 	DatabaseVolumeCapacity string `json:"databaseVolumeCapacity,omitempty"`
 
+	// What happens to the database PVC and the encryption key when the CR is deleted (default: Delete)
+	// Options: Delete, Retain, Snapshot
+	// Note: Snapshot takes a final pg_dump of the database, along with the encryption key and the database secret, to the <AppName>-final-backup PVC before the teardown
+	// +optional
+	// +kubebuilder:validation:Enum=Delete;Retain;Snapshot
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+
 	// Deprecated: Flag to indicate if Kafka and Zookeeper should be deployed (default: true)
 	// +optional
 	DeployMessagingService *bool `json:"deployMessagingService,omitempty"`
 
+	// Flag to deploy a PgBouncer connection pooler between the orchestrator and its workers and the database (default: false)
+	// +optional
+	DeployPgbouncer *bool `json:"deployPgbouncer,omitempty"`
+
+	// What the operator does with manual changes to the objects it manages (default: Correct)
+	// Options: Correct, Report
+	// Note: Both options record the changed fields in the status and as Events, Report leaves the changes in place until the next change of the CR or upgrade of the operator, except on the Secrets which the operator also updates on its own, e.g. when the internal certificates are rotated
+	// +optional
+	// +kubebuilder:validation:Enum=Correct;Report
+	DriftDetection string `json:"driftDetection,omitempty"`
+
 	// Flag to allow logging into the application without SSO (default: true)
 	// +optional
 	EnableApplicationLocalLogin *bool `json:"enableApplicationLocalLogin,omitempty"`
@@ -80,6 +165,31 @@ type ManageIQSpec struct {
 	// +optional
 	EnforceWorkerResourceConstraints *bool `json:"enforceWorkerResourceConstraints,omitempty"`
 
+	// Secret containing the CA certificate, in its ca.crt key, which signed the certificate of the external database
+	// +optional
+	ExternalDatabaseCASecret string `json:"externalDatabaseCASecret,omitempty"`
+
+	// kubernetes.io/tls Secret containing the client certificate presented to the external database
+	// +optional
+	ExternalDatabaseClientCertSecret string `json:"externalDatabaseClientCertSecret,omitempty"`
+
+	// Hostname of an external PostgreSQL database, the in-cluster database is not deployed when set
+	// Note: the DatabaseSecret must exist with the dbname, username and password of the external database, the operator sets its hostname, port and sslmode
+	// +optional
+	ExternalDatabaseHost string `json:"externalDatabaseHost,omitempty"`
+
+	// Port of the external database (default: 5432)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	ExternalDatabasePort *int32 `json:"externalDatabasePort,omitempty"`
+
+	// SSL mode used to connect to the external database (default: verify-full)
+	// Options: disable, require, verify-ca, verify-full
+	// +optional
+	// +kubebuilder:validation:Enum=disable;require;verify-ca;verify-full
+	ExternalDatabaseSSLMode string `json:"externalDatabaseSSLMode,omitempty"`
+
 	// Secret containing the httpd configuration files
 	// Mutually exclusive with the OIDCClientSecret and OIDCProviderURL if using openid-connect
 	// +optional
@@ -92,6 +202,18 @@ type ManageIQSpec struct {
 	// +kubebuilder:validation:Pattern=\A(active-directory|external|internal|openid-connect|saml)\z
 	HttpdAuthenticationType string `json:"httpdAuthenticationType,omitempty"`
 
+	// Maximum number of httpd pods the HorizontalPodAutoscaler scales the httpd deployment to,
+	// autoscaling is disabled when not set. HttpdReplicas is the minimum number of pods.
+	// Note: requires HttpdCpuRequest
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	HttpdAutoscaleMaxReplicas *int32 `json:"httpdAutoscaleMaxReplicas,omitempty"`
+
+	// Average CPU utilization of the httpd pods targeted by the HorizontalPodAutoscaler, as a percentage of HttpdCpuRequest (default: 75)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	HttpdAutoscaleTargetCPUUtilization *int32 `json:"httpdAutoscaleTargetCPUUtilization,omitempty"`
+
 	// Httpd deployment CPU limit (default: no limit)
 	// +optional
 	HttpdCpuLimit string `json:"httpdCpuLimit,omitempty"`
@@ -122,6 +244,16 @@ type ManageIQSpec struct {
 	// +optional
 	HttpdMemoryRequest string `json:"httpdMemoryRequest,omitempty"`
 
+	// Number of httpd pods (default: 1)
+	// Note: the pods of the external, active-directory and saml authentication types are replaced all at once on updates
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	HttpdReplicas *int32 `json:"httpdReplicas,omitempty"`
+
+	// Nodes, tolerations and priority of the httpd pods, in addition to the architecture of the operator
+	// +optional
+	HttpdScheduling *PodScheduling `json:"httpdScheduling,omitempty"`
+
 	// Secret containing the image registry authentication information needed for the manageiq images
 	// +optional
 	ImagePullSecret string `json:"imagePullSecret,omitempty"`
@@ -164,6 +296,10 @@ type ManageIQSpec struct {
 	// +optional
 	KafkaMemoryRequest string `json:"kafkaMemoryRequest,omitempty"`
 
+	// Nodes, tolerations and priority of the kafka, zookeeper and entity operator pods, in addition to the architecture of the operator
+	// +optional
+	KafkaScheduling *PodScheduling `json:"kafkaScheduling,omitempty"`
+
 	// Secret containing the kafka access information, content generated if not provided (default: kafka-secrets)
 	// +optional
 	KafkaSecret string `json:"kafkaSecret,omitempty"`
@@ -172,6 +308,10 @@ type ManageIQSpec struct {
 	// +optional
 	KafkaVolumeCapacity string `json:"kafkaVolumeCapacity,omitempty"`
 
+	// Flag to scale the orchestrator, httpd and memcached deployments down to zero while keeping the database running (default: false)
+	// +optional
+	MaintenanceMode *bool `json:"maintenanceMode,omitempty"`
+
 	// Memcached deployment CPU limit (default: no limit)
 	// +optional
 	MemcachedCpuLimit string `json:"memcachedCpuLimit,omitempty"`
@@ -209,6 +349,10 @@ type ManageIQSpec struct {
 	// +optional
 	MemcachedMemoryRequest string `json:"memcachedMemoryRequest,omitempty"`
 
+	// Nodes, tolerations and priority of the memcached pods, in addition to the architecture of the operator
+	// +optional
+	MemcachedScheduling *PodScheduling `json:"memcachedScheduling,omitempty"`
+
 	// Memcached max item size (default: 1m, min: 1k, max: 1024m)
 	// +optional
 	MemcachedSlabPageSize string `json:"memcachedSlabPageSize,omitempty"`
@@ -288,6 +432,79 @@ type ManageIQSpec struct {
 	// +optional
 	OrchestratorMemoryRequest string `json:"orchestratorMemoryRequest,omitempty"`
 
+	// Nodes, tolerations and priority of the orchestrator pods, in addition to the architecture of the operator
+	// +optional
+	OrchestratorScheduling *PodScheduling `json:"orchestratorScheduling,omitempty"`
+
+	// PgBouncer deployment CPU limit (default: no limit)
+	// +optional
+	PgbouncerCpuLimit string `json:"pgbouncerCpuLimit,omitempty"`
+
+	// PgBouncer deployment CPU request (default: no request)
+	// +optional
+	PgbouncerCpuRequest string `json:"pgbouncerCpuRequest,omitempty"`
+
+	// Number of database connections PgBouncer opens per user and database (default: 20)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	PgbouncerDefaultPoolSize *int32 `json:"pgbouncerDefaultPoolSize,omitempty"`
+
+	// Image string used for the pgbouncer deployment (default: ghcr.io/cloudnative-pg/pgbouncer:1.23.0)
+	// +optional
+	PgbouncerImage string `json:"pgbouncerImage,omitempty"`
+
+	// Number of client connections PgBouncer accepts from the orchestrator and its workers (default: 1000)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	PgbouncerMaxClientConnections *int32 `json:"pgbouncerMaxClientConnections,omitempty"`
+
+	// Number of connections PgBouncer opens to the database across all of its pools (default: 0, no limit)
+	// Note: keep it below PostgresqlMaxConnections to leave connections to the backups and maintenance Jobs
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	PgbouncerMaxDatabaseConnections *int32 `json:"pgbouncerMaxDatabaseConnections,omitempty"`
+
+	// PgBouncer deployment memory limit (default: no limit)
+	// +optional
+	PgbouncerMemoryLimit string `json:"pgbouncerMemoryLimit,omitempty"`
+
+	// PgBouncer deployment memory request (default: no limit)
+	// +optional
+	PgbouncerMemoryRequest string `json:"pgbouncerMemoryRequest,omitempty"`
+
+	// When a database connection is returned to the pool of PgBouncer (default: session)
+	// Options: session, transaction
+	// Note: transaction shares the database connections between the clients between transactions, session keeps them for the lifetime of the client connection
+	// +optional
+	// +kubebuilder:validation:Enum=session;transaction
+	PgbouncerPoolMode string `json:"pgbouncerPoolMode,omitempty"`
+
+	// Nodes, tolerations and priority of the pgbouncer pods, in addition to the architecture of the operator
+	// +optional
+	PgbouncerScheduling *PodScheduling `json:"pgbouncerScheduling,omitempty"`
+
+	// Keeps the pods of a deployment on different nodes (default: none)
+	// Options: preferred, required
+	// Note: required leaves the pods which do not fit on the nodes pending, a rolling update of httpd needs one more node than its replicas
+	// +optional
+	// +kubebuilder:validation:Enum=preferred;required
+	PodAntiAffinity string `json:"podAntiAffinity,omitempty"`
+
+	// Flag to let a node drain evict the database pod of the standalone mode (default: false)
+	// Note: by default its PodDisruptionBudget blocks the drain until the pod is deleted
+	// +optional
+	PostgresqlAllowEviction *bool `json:"postgresqlAllowEviction,omitempty"`
+
+	// Derive shared_buffers, effective_cache_size, work_mem and maintenance_work_mem from PostgresqlMemoryLimit and the CPU limit or request (default: false)
+	// Note: PostgresqlSharedBuffers is ignored when enabled, the derived parameters are reported in the status and can be overridden by PostgresqlParameters
+	// +optional
+	PostgresqlAutoTune *bool `json:"postgresqlAutoTune,omitempty"`
+
+	// Name of a ConfigMap with additional postgresql.conf parameters in its postgresql.conf key and pg_hba.conf rules in its pg_hba.conf key
+	// Note: the pg_hba.conf rules are matched before the default ones, PostgresqlParameters take precedence over the parameters of the ConfigMap
+	// +optional
+	PostgresqlConfigMap string `json:"postgresqlConfigMap,omitempty"`
+
 	// PostgreSQL deployment CPU limit (default: no limit)
 	// +optional
 	PostgresqlCpuLimit string `json:"postgresqlCpuLimit,omitempty"`
@@ -321,10 +538,42 @@ type ManageIQSpec struct {
 	// +optional
 	PostgresqlMemoryRequest string `json:"postgresqlMemoryRequest,omitempty"`
 
+	// PostgreSQL deployment mode (default: standalone)
+	// Options: standalone, replicated
+	// Note: replicated runs a StatefulSet with a primary and hot standbys fed by streaming replication, the standbys are served by the <AppName>-postgresql-readonly Service
+	// +optional
+	// +kubebuilder:validation:Enum=standalone;replicated
+	PostgresqlMode string `json:"postgresqlMode,omitempty"`
+
+	// Additional postgresql.conf parameters, merged over the defaults of the operator
+	// Note: changing a parameter which requires a restart restarts the database, the other parameters are reloaded
+	// +optional
+	PostgresqlParameters map[string]string `json:"postgresqlParameters,omitempty"`
+
+	// Ordinal of the StatefulSet pod running the primary in replicated mode (default: 0)
+	// Note: changing it promotes that standby once it is ready, the former primary is rewound as a standby; it can not be changed together with PostgresqlReplicas
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	PostgresqlPrimary *int32 `json:"postgresqlPrimary,omitempty"`
+
+	// Number of database pods in replicated mode, including the primary (default: 2)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	PostgresqlReplicas *int32 `json:"postgresqlReplicas,omitempty"`
+
+	// Nodes, tolerations and priority of the postgresql pods and of the Jobs on its volume, in addition to the architecture of the operator
+	// +optional
+	PostgresqlScheduling *PodScheduling `json:"postgresqlScheduling,omitempty"`
+
 	// PostgreSQL shared buffers setting (default: 1GB)
 	// +optional
 	PostgresqlSharedBuffers string `json:"postgresqlSharedBuffers,omitempty"`
 
+	// Name of a ManageIQBackup with the BaseBackup method whose storage also receives the WAL of the database, to restore it to a point in time
+	// Note: only supported for the standalone database deployed by the operator
+	// +optional
+	PostgresqlWalArchiveBackup string `json:"postgresqlWalArchiveBackup,omitempty"`
+
 	// Server GUID (default: auto-generated)
 	// +optional
 	ServerGuid string `json:"serverGuid,omitempty"`
@@ -333,10 +582,15 @@ type ManageIQSpec struct {
 	// +optional
 	StorageClassName string `json:"storageClassName,omitempty"`
 
-	// Secret containing the tls cert and key for the ingress, content generated if not provided (default: tls-secret)
+	// Secret containing the tls cert and key for the ingress, content generated if not provided (default: <AppName>-tls-secret)
 	// +optional
 	TLSSecret string `json:"tlsSecret,omitempty"`
 
+	// Topology spread constraints of the pods of the httpd, memcached, orchestrator, pgbouncer and postgresql deployments
+	// Note: a constraint without a labelSelector spreads the pods of each deployment
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
 	// Image string used for the UI worker deployments
 	// By default this is determined by the orchestrator pod
 	// +optional
@@ -381,6 +635,29 @@ type ManageIQSpec struct {
 	ZookeeperVolumeCapacity string `json:"zookeeperVolumeCapacity,omitempty"`
 }
 
+// PodScheduling defines where the pods of a component are scheduled
+type PodScheduling struct {
+	// Node affinity, pod affinity and pod anti-affinity of the pods
+	// Note: a node has to match one of the required node selector terms and the architectures of the operator, the schema is left out of the CRD for its size
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// Labels of the nodes the pods are restricted to
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// PriorityClass of the pods
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// Tolerations of the pods, e.g. of the taints of dedicated nodes
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
 // SecretSource is a reference to a secret containing a hidden value
 type SecretSource struct {
 	// The name of the secret containing the value
@@ -402,17 +679,107 @@ type Version struct {
 	Version string `json:"version,omitempty"`
 }
 
+// DriftedObject records the fields of a managed object which no longer matched the desired state
+type DriftedObject struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+
+	// The paths of the fields which differed from the desired state
+	Fields []string `json:"fields,omitempty"`
+
+	// Whether the operator reverted the object to the desired state
+	Corrected bool `json:"corrected"`
+
+	LastDetectedTime metav1.Time `json:"lastDetectedTime,omitempty"`
+}
+
+// DatabaseMaintenanceRun is the outcome of the last run of a database maintenance CronJob
+type DatabaseMaintenanceRun struct {
+	// Maintenance task: reindex, report or vacuum
+	Task string `json:"task"`
+
+	// Last time a Job of the task was scheduled
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// Last time a Job of the task succeeded
+	// +optional
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+
+	// Result of the most recent Job: Running, Succeeded or Failed
+	// +optional
+	LastResult string `json:"lastResult,omitempty"`
+
+	// Report of the most recent Job, or its error
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// DatabaseSnapshot is a VolumeSnapshot of the database PVC taken on the DatabaseSnapshotSchedule
+type DatabaseSnapshot struct {
+	Name string `json:"name"`
+
+	// Time at which the snapshot was cut by the storage provider
+	// +optional
+	CreationTime *metav1.Time `json:"creationTime,omitempty"`
+
+	// Whether the snapshot can be restored
+	ReadyToUse bool `json:"readyToUse"`
+
+	// Minimum size of a PVC restored from the snapshot
+	// +optional
+	RestoreSize string `json:"restoreSize,omitempty"`
+}
+
 // ManageIQStatus defines the observed state of ManageIQ
 type ManageIQStatus struct {
 	Versions  []Version  `json:"versions,omitempty"`
 	Endpoints []Endpoint `json:"endpoints,omitempty"`
 
+	// The most recent generation of the ManageIQ spec reconciled successfully by the operator
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Optional components deployed by the operator, their resources are pruned when they get disabled.
+	// An empty list when none is deployed, all the disabled components are pruned while it is unset.
+	// +optional
+	Components []string `json:"components"`
+
+	// Managed objects which were changed outside of the operator
+	// +optional
+	Drift []DriftedObject `json:"drift,omitempty"`
+
+	// PVC holding the data of the standalone database, e.g. the one restored from DatabaseSnapshotRestore
+	// +optional
+	PostgresqlClaimName string `json:"postgresqlClaimName,omitempty"`
+
+	// PostgreSQL parameters derived from the resource limits when PostgresqlAutoTune is enabled
+	// +optional
+	PostgresqlTuning map[string]string `json:"postgresqlTuning,omitempty"`
+
+	// VolumeSnapshots of the database PVC, the most recent first
+	// +optional
+	DatabaseSnapshots []DatabaseSnapshot `json:"databaseSnapshots,omitempty"`
+
+	// Last runs of the database maintenance CronJobs
+	// +optional
+	DatabaseMaintenance []DatabaseMaintenanceRun `json:"databaseMaintenance,omitempty"`
+
+	// Time of the last rotation of the database password
+	// +optional
+	DatabaseCredentialsRotationTime *metav1.Time `json:"databaseCredentialsRotationTime,omitempty"`
+
+	// Value of the rotate-database-credentials annotation of the last rotation
+	// +optional
+	DatabaseCredentialsRotationRequest string `json:"databaseCredentialsRotationRequest,omitempty"`
+
 	// +operator-sdk:csv:customresourcedefinitions:type=status
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:storageversion
 
 // ManageIQ is the Schema for the manageiqs API
 type ManageIQ struct {
@@ -435,41 +802,3 @@ type ManageIQList struct {
 func init() {
 	SchemeBuilder.Register(&ManageIQ{}, &ManageIQList{})
 }
-
-func (m *ManageIQ) Validate() error {
-	spec := m.Spec
-	errs := []string{}
-
-	if spec.HttpdAuthenticationType == "openid-connect" {
-		if spec.HttpdAuthConfig != "" && (spec.OIDCProviderURL != "" || spec.OIDCOAuthIntrospectionURL != "" || spec.OIDCClientSecret != "") {
-			// Invalid if config and any other info is also provided
-			errs = append(errs, "OIDCProviderURL, OIDCOAuthIntrospectionURL, and OIDCClientSecret are invalid when HttpdAuthConfig is specified")
-		} else if spec.HttpdAuthConfig == "" && (spec.OIDCProviderURL == "" || spec.OIDCClientSecret == "") {
-			// Need to provide either the entire config or a secret and provider url
-			errs = append(errs, "HttpdAuthConfig or both OIDCProviderURL and OIDCClientSecret must be provided for openid-connect authentication")
-		}
-	} else {
-		if spec.OIDCProviderURL != "" {
-			errs = append(errs, fmt.Sprintf("OIDCProviderURL is not allowed for authentication type %s", spec.HttpdAuthenticationType))
-		}
-
-		if spec.OIDCOAuthIntrospectionURL != "" {
-			errs = append(errs, fmt.Sprintf("OIDCOAuthIntrospectionURL is not allowed for authentication type %s", spec.HttpdAuthenticationType))
-		}
-
-		if spec.OIDCClientSecret != "" {
-			errs = append(errs, fmt.Sprintf("OIDCClientSecret is not allowed for authentication type %s", spec.HttpdAuthenticationType))
-		}
-
-		if spec.OIDCCACertSecret != "" {
-			errs = append(errs, fmt.Sprintf("OIDCCACertSecret is not allowed for authentication type %s", spec.HttpdAuthenticationType))
-		}
-	}
-
-	if len(errs) > 0 {
-		err := fmt.Sprintf("validation failed for ManageIQ object: %s", strings.Join(errs, ", "))
-		return errors.New(err)
-	} else {
-		return nil
-	}
-}
@@ -0,0 +1,369 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	miqutilsv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/miqutils"
+)
+
+// Simplified form of the distribution reference grammar: [registry[:port]/]path[:tag][@digest]
+var imageReferenceRegexp = regexp.MustCompile(`^(?:[a-zA-Z0-9.-]+(?::[0-9]+)?/)?[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*(?::[\w][\w.-]{0,127})?(?:@[a-z0-9]+:[a-fA-F0-9]{32,})?$`)
+
+var imageTagRegexp = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+
+// PostgreSQL parameter names, including the custom ones of the extensions (e.g. pg_stat_statements.max)
+var postgresqlParameterRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]*(\.[a-z][a-z0-9_]*)?$`)
+
+// Unquoted table names, optionally qualified with the schema, which are inlined in the maintenance commands
+var tableNameRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)?$`)
+
+// postgresqlManagedParameters are set by the postgresql image or the operator and can not be overridden
+var postgresqlManagedParameters = map[string]string{
+	"config_file":             "is set by the postgresql image",
+	"data_directory":          "is set by the postgresql image",
+	"external_pid_file":       "is set by the postgresql image",
+	"hba_file":                "is set by the operator, use the pg_hba.conf key of the PostgresqlConfigMap",
+	"hot_standby":             "is set by the operator",
+	"ident_file":              "is set by the postgresql image",
+	"include":                 "is not supported",
+	"include_dir":             "is not supported",
+	"include_if_exists":       "is not supported",
+	"listen_addresses":        "is set by the postgresql image",
+	"max_connections":         "is set by the operator, use PostgresqlMaxConnections",
+	"port":                    "is set by the postgresql image",
+	"shared_buffers":          "is set by the operator, use PostgresqlSharedBuffers",
+	"ssl":                     "is set by the operator from the internal certificates",
+	"ssl_cert_file":           "is set by the operator from the internal certificates",
+	"ssl_key_file":            "is set by the operator from the internal certificates",
+	"unix_socket_directories": "is set by the postgresql image",
+	"wal_level":               "is set by the operator",
+}
+
+func (m *ManageIQ) Validate() error {
+	spec := m.Spec
+	errs := []string{}
+
+	if spec.HttpdAuthenticationType == "openid-connect" {
+		if spec.HttpdAuthConfig != "" && (spec.OIDCProviderURL != "" || spec.OIDCOAuthIntrospectionURL != "" || spec.OIDCClientSecret != "") {
+			// Invalid if config and any other info is also provided
+			errs = append(errs, "OIDCProviderURL, OIDCOAuthIntrospectionURL, and OIDCClientSecret are invalid when HttpdAuthConfig is specified")
+		} else if spec.HttpdAuthConfig == "" && (spec.OIDCProviderURL == "" || spec.OIDCClientSecret == "") {
+			// Need to provide either the entire config or a secret and provider url
+			errs = append(errs, "HttpdAuthConfig or both OIDCProviderURL and OIDCClientSecret must be provided for openid-connect authentication")
+		}
+	} else {
+		if spec.OIDCProviderURL != "" {
+			errs = append(errs, fmt.Sprintf("OIDCProviderURL is not allowed for authentication type %s", spec.HttpdAuthenticationType))
+		}
+
+		if spec.OIDCOAuthIntrospectionURL != "" {
+			errs = append(errs, fmt.Sprintf("OIDCOAuthIntrospectionURL is not allowed for authentication type %s", spec.HttpdAuthenticationType))
+		}
+
+		if spec.OIDCClientSecret != "" {
+			errs = append(errs, fmt.Sprintf("OIDCClientSecret is not allowed for authentication type %s", spec.HttpdAuthenticationType))
+		}
+
+		if spec.OIDCCACertSecret != "" {
+			errs = append(errs, fmt.Sprintf("OIDCCACertSecret is not allowed for authentication type %s", spec.HttpdAuthenticationType))
+		}
+	}
+
+	errs = append(errs, validateResources("Httpd", spec.HttpdCpuLimit, spec.HttpdCpuRequest, spec.HttpdMemoryLimit, spec.HttpdMemoryRequest)...)
+
+	// The CPU utilization of the HorizontalPodAutoscaler is relative to the CPU request of the pods
+	if spec.HttpdAutoscaleMaxReplicas != nil {
+		if spec.HttpdCpuRequest == "" {
+			errs = append(errs, "HttpdCpuRequest is required when HttpdAutoscaleMaxReplicas is set")
+		}
+		if spec.HttpdReplicas != nil && *spec.HttpdReplicas > *spec.HttpdAutoscaleMaxReplicas {
+			errs = append(errs, fmt.Sprintf("HttpdReplicas %d must not be greater than HttpdAutoscaleMaxReplicas %d", *spec.HttpdReplicas, *spec.HttpdAutoscaleMaxReplicas))
+		}
+	} else if spec.HttpdAutoscaleTargetCPUUtilization != nil {
+		errs = append(errs, "HttpdAutoscaleTargetCPUUtilization requires HttpdAutoscaleMaxReplicas")
+	}
+
+	errs = append(errs, validateResources("Kafka", spec.KafkaCpuLimit, spec.KafkaCpuRequest, spec.KafkaMemoryLimit, spec.KafkaMemoryRequest)...)
+	errs = append(errs, validateResources("Memcached", spec.MemcachedCpuLimit, spec.MemcachedCpuRequest, spec.MemcachedMemoryLimit, spec.MemcachedMemoryRequest)...)
+	errs = append(errs, validateResources("Orchestrator", spec.OrchestratorCpuLimit, spec.OrchestratorCpuRequest, spec.OrchestratorMemoryLimit, spec.OrchestratorMemoryRequest)...)
+	errs = append(errs, validateResources("Pgbouncer", spec.PgbouncerCpuLimit, spec.PgbouncerCpuRequest, spec.PgbouncerMemoryLimit, spec.PgbouncerMemoryRequest)...)
+	errs = append(errs, validateResources("Postgresql", spec.PostgresqlCpuLimit, spec.PostgresqlCpuRequest, spec.PostgresqlMemoryLimit, spec.PostgresqlMemoryRequest)...)
+	errs = append(errs, validateResources("Zookeeper", spec.ZookeeperCpuLimit, spec.ZookeeperCpuRequest, spec.ZookeeperMemoryLimit, spec.ZookeeperMemoryRequest)...)
+
+	errs = append(errs, validatePostgresqlParameters("PostgresqlParameters", spec.PostgresqlParameters)...)
+
+	if spec.PostgresqlAutoTune != nil && *spec.PostgresqlAutoTune && spec.PostgresqlMemoryLimit == "" {
+		errs = append(errs, "PostgresqlMemoryLimit is required when PostgresqlAutoTune is enabled")
+	}
+
+	if spec.ExternalDatabaseHost == "" {
+		for _, f := range []struct {
+			name string
+			set  bool
+		}{
+			{"ExternalDatabaseCASecret", spec.ExternalDatabaseCASecret != ""},
+			{"ExternalDatabaseClientCertSecret", spec.ExternalDatabaseClientCertSecret != ""},
+			{"ExternalDatabasePort", spec.ExternalDatabasePort != nil},
+			{"ExternalDatabaseSSLMode", spec.ExternalDatabaseSSLMode != ""},
+		} {
+			if f.set {
+				errs = append(errs, fmt.Sprintf("%s requires ExternalDatabaseHost", f.name))
+			}
+		}
+	} else if sslMode := spec.ExternalDatabaseSSLMode; (sslMode == "" || strings.HasPrefix(sslMode, "verify-")) && spec.ExternalDatabaseCASecret == "" {
+		errs = append(errs, "ExternalDatabaseCASecret is required to verify the certificate of the external database, unless ExternalDatabaseSSLMode is disable or require")
+	}
+
+	for _, f := range []struct{ name, schedule string }{
+		{"DatabaseCredentialsRotationSchedule", spec.DatabaseCredentialsRotationSchedule},
+		{"DatabaseMaintenanceReindexSchedule", spec.DatabaseMaintenanceReindexSchedule},
+		{"DatabaseMaintenanceReportSchedule", spec.DatabaseMaintenanceReportSchedule},
+		{"DatabaseMaintenanceVacuumSchedule", spec.DatabaseMaintenanceVacuumSchedule},
+		{"DatabaseSnapshotSchedule", spec.DatabaseSnapshotSchedule},
+	} {
+		if f.schedule == "" {
+			continue
+		}
+		if schedule, err := miqutilsv1alpha1.ParseSchedule(f.schedule); err != nil {
+			errs = append(errs, fmt.Sprintf("%s %s", f.name, err))
+		} else if schedule.Next(time.Now().UTC()).IsZero() {
+			errs = append(errs, fmt.Sprintf("%s %q never matches", f.name, f.schedule))
+		}
+	}
+
+	if spec.DatabaseMaintenanceReindexSchedule != "" && len(spec.DatabaseMaintenanceReindexTables) == 0 {
+		errs = append(errs, "DatabaseMaintenanceReindexSchedule requires DatabaseMaintenanceReindexTables")
+	}
+	for _, f := range []struct {
+		name   string
+		tables []string
+	}{
+		{"DatabaseMaintenanceReindexTables", spec.DatabaseMaintenanceReindexTables},
+		{"DatabaseMaintenanceVacuumTables", spec.DatabaseMaintenanceVacuumTables},
+	} {
+		for _, table := range f.tables {
+			if !tableNameRegexp.MatchString(table) {
+				errs = append(errs, fmt.Sprintf("%s %q is not a table name, e.g. metrics or public.metrics", f.name, table))
+			}
+		}
+	}
+
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{"DatabaseSnapshotRestore", spec.DatabaseSnapshotRestore != ""},
+		{"DatabaseSnapshotSchedule", spec.DatabaseSnapshotSchedule != ""},
+		{"PostgresqlWalArchiveBackup", spec.PostgresqlWalArchiveBackup != ""},
+	} {
+		if f.set && spec.ExternalDatabaseHost != "" {
+			errs = append(errs, fmt.Sprintf("%s is not supported with an external database", f.name))
+		}
+	}
+
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{"DatabaseSnapshotRestore", spec.DatabaseSnapshotRestore != ""},
+		{"PostgresqlWalArchiveBackup", spec.PostgresqlWalArchiveBackup != ""},
+	} {
+		if f.set && spec.PostgresqlMode == PostgresqlModeReplicated {
+			errs = append(errs, fmt.Sprintf("%s is only supported in the standalone PostgresqlMode", f.name))
+		}
+	}
+
+	// The WAL archive settings are written by the operator, they would be overridden by the parameters
+	if spec.PostgresqlWalArchiveBackup != "" {
+		for _, name := range []string{"archive_command", "archive_library", "archive_mode"} {
+			if _, ok := spec.PostgresqlParameters[name]; ok {
+				errs = append(errs, fmt.Sprintf("PostgresqlParameters %q is set by the operator when PostgresqlWalArchiveBackup is set", name))
+			}
+		}
+	}
+
+	if spec.PostgresqlMode == PostgresqlModeReplicated && spec.PostgresqlPrimary != nil && spec.PostgresqlReplicas != nil && *spec.PostgresqlPrimary >= *spec.PostgresqlReplicas {
+		errs = append(errs, fmt.Sprintf("PostgresqlPrimary %d must be lower than PostgresqlReplicas %d", *spec.PostgresqlPrimary, *spec.PostgresqlReplicas))
+	}
+
+	for _, f := range []struct{ name, value string }{
+		{"DatabaseVolumeCapacity", spec.DatabaseVolumeCapacity},
+		{"KafkaVolumeCapacity", spec.KafkaVolumeCapacity},
+		{"ZookeeperVolumeCapacity", spec.ZookeeperVolumeCapacity},
+	} {
+		if _, err := parseQuantity(f.name, f.value); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	for _, f := range []struct{ name, value string }{
+		{"BaseWorkerImage", spec.BaseWorkerImage},
+		{"HttpdImage", spec.HttpdImage},
+		{"KafkaImage", spec.KafkaImage},
+		{"MemcachedImage", spec.MemcachedImage},
+		{"OpentofuRunnerImage", spec.OpentofuRunnerImage},
+		{"OrchestratorImage", spec.OrchestratorImage},
+		{"PgbouncerImage", spec.PgbouncerImage},
+		{"PostgresqlImage", spec.PostgresqlImage},
+		{"UIWorkerImage", spec.UIWorkerImage},
+		{"WebserverWorkerImage", spec.WebserverWorkerImage},
+		{"ZookeeperImage", spec.ZookeeperImage},
+	} {
+		if f.value != "" && !imageReferenceRegexp.MatchString(f.value) {
+			errs = append(errs, fmt.Sprintf("%s %q is not a valid image reference", f.name, f.value))
+		}
+	}
+
+	for _, f := range []struct{ name, value string }{
+		{"HttpdImageTag", spec.HttpdImageTag},
+		{"KafkaImageTag", spec.KafkaImageTag},
+		{"MemcachedImageTag", spec.MemcachedImageTag},
+		{"OrchestratorImageTag", spec.OrchestratorImageTag},
+		{"PostgresqlImageTag", spec.PostgresqlImageTag},
+		{"ZookeeperImageTag", spec.ZookeeperImageTag},
+	} {
+		if f.value != "" && !imageTagRegexp.MatchString(f.value) {
+			errs = append(errs, fmt.Sprintf("%s %q is not a valid image tag", f.name, f.value))
+		}
+	}
+
+	return validationError(errs)
+}
+
+// ValidateUpdate checks the fields which can not be changed once they have been set
+func (m *ManageIQ) ValidateUpdate(old *ManageIQ) error {
+	errs := []string{}
+
+	if old.Spec.DatabaseRegion != "" && m.Spec.DatabaseRegion != old.Spec.DatabaseRegion {
+		errs = append(errs, fmt.Sprintf("DatabaseRegion is immutable (current value: %s)", old.Spec.DatabaseRegion))
+	}
+
+	if old.Spec.ServerGuid != "" && m.Spec.ServerGuid != old.Spec.ServerGuid {
+		errs = append(errs, fmt.Sprintf("ServerGuid is immutable (current value: %s)", old.Spec.ServerGuid))
+	}
+
+	// A standby added by the same change would be promoted with an empty data directory
+	if old.Spec.PostgresqlMode == PostgresqlModeReplicated && m.Spec.PostgresqlMode == PostgresqlModeReplicated &&
+		!equalInt32(old.Spec.PostgresqlPrimary, m.Spec.PostgresqlPrimary) && !equalInt32(old.Spec.PostgresqlReplicas, m.Spec.PostgresqlReplicas) {
+		errs = append(errs, "PostgresqlPrimary and PostgresqlReplicas can not be changed together, add the standby first and promote it once it is ready")
+	}
+
+	// Volumes can be grown when their StorageClass allows it, but never shrunk
+	for _, v := range []struct{ name, current, requested string }{
+		{"DatabaseVolumeCapacity", old.Spec.DatabaseVolumeCapacity, m.Spec.DatabaseVolumeCapacity},
+		{"KafkaVolumeCapacity", old.Spec.KafkaVolumeCapacity, m.Spec.KafkaVolumeCapacity},
+		{"ZookeeperVolumeCapacity", old.Spec.ZookeeperVolumeCapacity, m.Spec.ZookeeperVolumeCapacity},
+	} {
+		current, _ := parseQuantity(v.name, v.current)
+		requested, _ := parseQuantity(v.name, v.requested)
+		if current != nil && requested != nil && requested.Cmp(*current) < 0 {
+			errs = append(errs, fmt.Sprintf("%s can not be lowered, volumes can not be shrunk (current value: %s)", v.name, v.current))
+		}
+	}
+
+	return validationError(errs)
+}
+
+func equalInt32(a, b *int32) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func validateResources(component, cpuLimit, cpuRequest, memLimit, memRequest string) []string {
+	errs := []string{}
+
+	for _, r := range []struct{ name, limit, request string }{
+		{"Cpu", cpuLimit, cpuRequest},
+		{"Memory", memLimit, memRequest},
+	} {
+		limit, err := parseQuantity(component+r.name+"Limit", r.limit)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+
+		request, err := parseQuantity(component+r.name+"Request", r.request)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+
+		if limit != nil && request != nil && request.Cmp(*limit) > 0 {
+			errs = append(errs, fmt.Sprintf("%s%sRequest %s must be less than or equal to %s%sLimit %s", component, r.name, r.request, component, r.name, r.limit))
+		}
+	}
+
+	return errs
+}
+
+// ValidatePostgresqlParameters checks the postgresql.conf parameters read from the source, e.g. the
+// PostgresqlConfigMap which can not be checked by the webhook
+func ValidatePostgresqlParameters(source string, parameters map[string]string) error {
+	if errs := validatePostgresqlParameters(source, parameters); len(errs) > 0 {
+		return fmt.Errorf("invalid PostgreSQL parameters: %s", strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func validatePostgresqlParameters(source string, parameters map[string]string) []string {
+	errs := []string{}
+
+	for _, name := range slices.Sorted(maps.Keys(parameters)) {
+		if !postgresqlParameterRegexp.MatchString(name) {
+			errs = append(errs, fmt.Sprintf("%s %q is not a valid parameter name", source, name))
+		} else if reason, ok := postgresqlManagedParameters[name]; ok {
+			errs = append(errs, fmt.Sprintf("%s %q %s", source, name, reason))
+		} else if strings.ContainsAny(parameters[name], "\n\r") {
+			errs = append(errs, fmt.Sprintf("%s %q must be a single line", source, name))
+		}
+	}
+
+	return errs
+}
+
+func parseQuantity(field, value string) (*resource.Quantity, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s %q is not a valid quantity", field, value)
+	}
+
+	return &q, nil
+}
+
+func validationError(errs []string) error {
+	if len(errs) > 0 {
+		err := fmt.Sprintf("validation failed for ManageIQ object: %s", strings.Join(errs, ", "))
+		return errors.New(err)
+	} else {
+		return nil
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	BackupMethodDump       = "Dump"
+	BackupMethodBaseBackup = "BaseBackup"
+
+	// TakeBackupAnnotation is set on a ManageIQBackup without a schedule to take another backup, a
+	// new value of the annotation takes another one
+	TakeBackupAnnotation = "manageiq.org/take-backup"
+)
+
+// ManageIQBackupSpec defines the desired state of ManageIQBackup
+type ManageIQBackupSpec struct {
+	// Name of the ManageIQ CR in the same namespace whose database is backed up
+	ManageIQName string `json:"manageiqName"`
+
+	// How the database is backed up (default: Dump)
+	// Options: Dump, BaseBackup
+	// Note: Dump uses pg_dump, BaseBackup uses pg_basebackup which requires a database user allowed to open replication connections
+	// +optional
+	// +kubebuilder:validation:Enum=Dump;BaseBackup
+	Method string `json:"method,omitempty"`
+
+	// Number of backups kept in the storage, the oldest ones are removed after each successful backup (default: 7)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Retention *int32 `json:"retention,omitempty"`
+
+	// Cron schedule of the backups, a single backup is taken if not provided
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// Where the backups are written
+	Storage BackupStorage `json:"storage"`
+
+	// Flag to suspend the scheduled backups (default: false)
+	// +optional
+	Suspend *bool `json:"suspend,omitempty"`
+}
+
+// BackupStorage is the target of the backups, exactly one of VolumeClaim or S3 must be set
+// +kubebuilder:validation:XValidation:rule="has(self.volumeClaim) != has(self.s3)",message="exactly one of volumeClaim or s3 must be set"
+type BackupStorage struct {
+	// PersistentVolumeClaim holding the backups
+	// +optional
+	VolumeClaim *BackupVolumeClaim `json:"volumeClaim,omitempty"`
+
+	// S3 compatible object storage holding the backups
+	// +optional
+	S3 *BackupS3 `json:"s3,omitempty"`
+}
+
+type BackupVolumeClaim struct {
+	// Name of the PersistentVolumeClaim, created if it does not exist (default: <ManageIQBackup name>-backups)
+	// +optional
+	ClaimName string `json:"claimName,omitempty"`
+
+	// Size of the created PersistentVolumeClaim (default: the database volume capacity of the ManageIQ CR)
+	// +optional
+	Size string `json:"size,omitempty"`
+
+	// StorageClass name of the created PersistentVolumeClaim (default: the StorageClass of the ManageIQ CR)
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+}
+
+type BackupS3 struct {
+	// Bucket holding the backups
+	Bucket string `json:"bucket"`
+
+	// Secret containing the access-key-id and secret-access-key of the bucket
+	CredentialsSecret string `json:"credentialsSecret"`
+
+	// URL of the S3 endpoint, e.g. https://s3.us-east-1.amazonaws.com or http://minio:9000
+	Endpoint string `json:"endpoint"`
+
+	// Image of the MinIO client used to transfer the backups (default: quay.io/minio/mc:RELEASE.2024-11-21T17-21-54Z)
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Flag to skip the verification of the endpoint certificate (default: false)
+	// +optional
+	InsecureSkipTLSVerify bool `json:"insecureSkipTLSVerify,omitempty"`
+
+	// Path in the bucket under which the backups are written
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// ManageIQBackupStatus defines the observed state of ManageIQBackup
+type ManageIQBackupStatus struct {
+	// Job of the most recent backup
+	// +optional
+	LastBackupJob string `json:"lastBackupJob,omitempty"`
+
+	// Completion time of the most recent successful backup
+	// +optional
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="ManageIQ",type=string,JSONPath=`.spec.manageiqName`
+//+kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`
+//+kubebuilder:printcolumn:name="Last Success",type=date,JSONPath=`.status.lastSuccessfulTime`
+
+// ManageIQBackup is the Schema for the manageiqbackups API
+type ManageIQBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ManageIQBackupSpec   `json:"spec,omitempty"`
+	Status ManageIQBackupStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ManageIQBackupList contains a list of ManageIQBackup
+type ManageIQBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ManageIQBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ManageIQBackup{}, &ManageIQBackupList{})
+}
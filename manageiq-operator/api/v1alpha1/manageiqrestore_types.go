@@ -0,0 +1,91 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	RestorePhasePending   = "Pending"
+	RestorePhaseQuiescing = "Quiescing"
+	RestorePhaseRestoring = "Restoring"
+	RestorePhaseCompleted = "Completed"
+	RestorePhaseFailed    = "Failed"
+)
+
+// ManageIQRestoreSpec defines the desired state of ManageIQRestore
+type ManageIQRestoreSpec struct {
+	// Name of the ManageIQBackup in the same namespace whose storage holds the backup
+	BackupName string `json:"backupName"`
+
+	// The backup to restore, the name of its timestamped directory in the storage (default: the most recent backup)
+	// +optional
+	Backup string `json:"backup,omitempty"`
+
+	// Time up to which the WAL archived for the ManageIQ CR is replayed over the base backup, which defaults to the most recent one taken before it
+	// Note: requires the ManageIQBackup to be the PostgresqlWalArchiveBackup of the ManageIQ CR
+	// +optional
+	TargetTime *metav1.Time `json:"targetTime,omitempty"`
+}
+
+// ManageIQRestoreStatus defines the observed state of ManageIQRestore
+type ManageIQRestoreStatus struct {
+	// Progress of the restore
+	// Options: Pending, Quiescing, Restoring, Completed, Failed
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// Time the application was quiesced for the restore
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Time the restore finished
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Backup",type=string,JSONPath=`.spec.backupName`
+//+kubebuilder:printcolumn:name="Target Time",type=date,JSONPath=`.spec.targetTime`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+
+// ManageIQRestore is the Schema for the manageiqrestores API
+type ManageIQRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ManageIQRestoreSpec   `json:"spec,omitempty"`
+	Status ManageIQRestoreStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ManageIQRestoreList contains a list of ManageIQRestore
+type ManageIQRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ManageIQRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ManageIQRestore{}, &ManageIQRestoreList{})
+}
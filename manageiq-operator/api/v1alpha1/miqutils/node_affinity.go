@@ -1,15 +1,23 @@
 package miqutils
 
 import (
+	"maps"
+	"os"
+	"slices"
+
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	"os"
+	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func OperatorNodeAffinityArchValues(deployment *appsv1.Deployment, client client.Client) []string {
+	return operatorNodeAffinityArchValues(deployment.ObjectMeta.Namespace, client)
+}
+
+func operatorNodeAffinityArchValues(namespace string, client client.Client) []string {
 	podName := os.Getenv("POD_NAME")
-	pod := FindPodByName(client, deployment.ObjectMeta.Namespace, podName)
+	pod := FindPodByName(client, namespace, podName)
 	values := []string{"amd64"}
 
 	if pod.Spec.Affinity == nil {
@@ -31,16 +39,25 @@ func OperatorNodeAffinityArchValues(deployment *appsv1.Deployment, client client
 }
 
 func SetDeploymentNodeAffinity(deployment *appsv1.Deployment, client client.Client) {
-	operatorNodeAffinityArchValues := OperatorNodeAffinityArchValues(deployment, client)
-	if len(operatorNodeAffinityArchValues) == 0 {
+	SetPodTemplateNodeAffinity(&deployment.Spec.Template, deployment.ObjectMeta.Namespace, client)
+}
+
+func SetPodTemplateNodeAffinity(template *corev1.PodTemplateSpec, namespace string, client client.Client) {
+	archValues := operatorNodeAffinityArchValues(namespace, client)
+	if len(archValues) == 0 {
 		// We're running local, can't find the operator pod, or it doesn't have any affinities to use as a template.  Skip it.
 		return
 	}
 
+	template.Spec.Affinity = NodeArchAffinity(archValues)
+}
+
+// NodeArchAffinity requires the nodes of one of the architectures
+func NodeArchAffinity(archValues []string) *corev1.Affinity {
 	matchExpression := corev1.NodeSelectorRequirement{
 		Key:      "kubernetes.io/arch",
 		Operator: corev1.NodeSelectorOpIn,
-		Values:   operatorNodeAffinityArchValues,
+		Values:   archValues,
 	}
 
 	matchExpressions := []corev1.NodeSelectorRequirement{matchExpression}
@@ -51,7 +68,7 @@ func SetDeploymentNodeAffinity(deployment *appsv1.Deployment, client client.Clie
 
 	nodeSelectionTerms := []corev1.NodeSelectorTerm{nodeSelectorTerm}
 
-	deployment.Spec.Template.Spec.Affinity = &corev1.Affinity{
+	return &corev1.Affinity{
 		NodeAffinity: &corev1.NodeAffinity{
 			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
 				NodeSelectorTerms: nodeSelectionTerms,
@@ -60,24 +77,100 @@ func SetDeploymentNodeAffinity(deployment *appsv1.Deployment, client client.Clie
 	}
 }
 
-func SetKafkaNodeAffinity(kafkaCRSpec map[string]interface{}, archs []string) map[string]interface{} {
-	nodeAffinity := map[string]interface{}{
-		"nodeAffinity": map[string]interface{}{
-			"requiredDuringSchedulingIgnoredDuringExecution": map[string]interface{}{
-				"nodeSelectorTerms": []map[string]interface{}{
-					map[string]interface{}{
-						"matchExpressions": []map[string]interface{}{
-							map[string]interface{}{
-								"key":      "kubernetes.io/arch",
-								"operator": "In",
-								"values":   archs,
-							},
-						},
-					},
-				},
+// NodeSelectorAffinity turns a node selector into the equivalent node affinity, for the pod
+// templates which do not take a node selector
+func NodeSelectorAffinity(nodeSelector map[string]string) *corev1.Affinity {
+	if len(nodeSelector) == 0 {
+		return nil
+	}
+
+	nodeSelectorTerm := corev1.NodeSelectorTerm{}
+	for _, key := range slices.Sorted(maps.Keys(nodeSelector)) {
+		nodeSelectorTerm.MatchExpressions = append(nodeSelectorTerm.MatchExpressions, corev1.NodeSelectorRequirement{
+			Key:      key,
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   []string{nodeSelector[key]},
+		})
+	}
+
+	return &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{nodeSelectorTerm},
 			},
 		},
 	}
+}
+
+// MergeAffinity returns an affinity satisfying both affinities, neither of which is modified. A
+// node has to match one of the required node selector terms of each, the other terms add up.
+func MergeAffinity(a *corev1.Affinity, b *corev1.Affinity) *corev1.Affinity {
+	if a == nil {
+		return b.DeepCopy()
+	}
+	merged := a.DeepCopy()
+	if b == nil {
+		return merged
+	}
+	b = b.DeepCopy()
+
+	if b.NodeAffinity != nil {
+		if merged.NodeAffinity == nil {
+			merged.NodeAffinity = &corev1.NodeAffinity{}
+		}
+		merged.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = mergeNodeSelectors(merged.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution, b.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+		merged.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(merged.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution, b.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution...)
+	}
+
+	if b.PodAffinity != nil {
+		if merged.PodAffinity == nil {
+			merged.PodAffinity = &corev1.PodAffinity{}
+		}
+		merged.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(merged.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution, b.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution...)
+		merged.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(merged.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution, b.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution...)
+	}
+
+	if b.PodAntiAffinity != nil {
+		if merged.PodAntiAffinity == nil {
+			merged.PodAntiAffinity = &corev1.PodAntiAffinity{}
+		}
+		merged.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(merged.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, b.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution...)
+		merged.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(merged.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, b.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution...)
+	}
+
+	return merged
+}
+
+// mergeNodeSelectors combines each term of a node selector with each term of the other, the terms
+// of a node selector are ORed while the requirements of a term are ANDed
+func mergeNodeSelectors(a *corev1.NodeSelector, b *corev1.NodeSelector) *corev1.NodeSelector {
+	if a == nil || len(a.NodeSelectorTerms) == 0 {
+		return b
+	}
+	if b == nil || len(b.NodeSelectorTerms) == 0 {
+		return a
+	}
+
+	nodeSelectorTerms := []corev1.NodeSelectorTerm{}
+	for _, termA := range a.NodeSelectorTerms {
+		for _, termB := range b.NodeSelectorTerms {
+			nodeSelectorTerms = append(nodeSelectorTerms, corev1.NodeSelectorTerm{
+				MatchExpressions: slices.Concat(termA.MatchExpressions, termB.MatchExpressions),
+				MatchFields:      slices.Concat(termA.MatchFields, termB.MatchFields),
+			})
+		}
+	}
+
+	return &corev1.NodeSelector{NodeSelectorTerms: nodeSelectorTerms}
+}
+
+// SetKafkaNodeAffinity sets the affinity of the kafka, zookeeper and entity operator pods of the
+// Kafka CR to the architectures merged with the affinity of the CR
+func SetKafkaNodeAffinity(kafkaCRSpec map[string]interface{}, archs []string, affinity *corev1.Affinity) (map[string]interface{}, error) {
+	nodeAffinity, err := runtime.DefaultUnstructuredConverter.ToUnstructured(MergeAffinity(NodeArchAffinity(archs), affinity))
+	if err != nil {
+		return nil, err
+	}
 
 	kafkaPod := kafkaCRSpec["kafka"].(map[string]interface{})["template"].(map[string]interface{})["pod"].(map[string]interface{})
 	kafkaPod["affinity"] = nodeAffinity
@@ -86,5 +179,5 @@ func SetKafkaNodeAffinity(kafkaCRSpec map[string]interface{}, archs []string) ma
 	operatorEntityPod := kafkaCRSpec["entityOperator"].(map[string]interface{})["template"].(map[string]interface{})["pod"].(map[string]interface{})
 	operatorEntityPod["affinity"] = nodeAffinity
 
-	return kafkaCRSpec
+	return kafkaCRSpec, nil
 }
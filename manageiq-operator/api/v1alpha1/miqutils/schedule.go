@@ -0,0 +1,181 @@
+package miqutils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron schedule in the format of the CronJobs: minute, hour, day of month,
+// month and day of week, or one of the @yearly, @annually, @monthly, @weekly, @daily, @midnight,
+// @hourly and @every <duration> macros
+type Schedule struct {
+	minutes, hours, days, months, weekdays []bool
+
+	// A day matches either the day of month or the day of week when both are restricted, i.e.
+	// neither starts with * or ?
+	daysRestricted, weekdaysRestricted bool
+
+	// Interval of the @every macro
+	every time.Duration
+}
+
+var scheduleMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var weekdayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// ParseSchedule parses a cron schedule with the grammar of the CronJobs, the times it matches are
+// in UTC
+func ParseSchedule(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+		return nil, fmt.Errorf("schedule %q can not set a time zone, the times are in UTC", spec)
+	}
+
+	if duration, ok := strings.CutPrefix(spec, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(duration))
+		if err != nil || every <= 0 {
+			return nil, fmt.Errorf("invalid duration in schedule %q", spec)
+		}
+		return &Schedule{every: max(every.Round(time.Second), time.Second)}, nil
+	}
+
+	if macro, ok := scheduleMacros[spec]; ok {
+		spec = macro
+	} else if strings.HasPrefix(spec, "@") {
+		return nil, fmt.Errorf("unknown macro in schedule %q", spec)
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q must have 5 fields: minute, hour, day of month, month and day of week", spec)
+	}
+
+	schedule := &Schedule{}
+	var err error
+	var star bool
+	if schedule.minutes, _, err = parseScheduleField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("minute of schedule %q: %v", spec, err)
+	}
+	if schedule.hours, _, err = parseScheduleField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("hour of schedule %q: %v", spec, err)
+	}
+	if schedule.days, star, err = parseScheduleField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("day of month of schedule %q: %v", spec, err)
+	}
+	schedule.daysRestricted = !star
+	if schedule.months, _, err = parseScheduleField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("month of schedule %q: %v", spec, err)
+	}
+	if schedule.weekdays, star, err = parseScheduleField(fields[4], 0, 6, weekdayNames); err != nil {
+		return nil, fmt.Errorf("day of week of schedule %q: %v", spec, err)
+	}
+	schedule.weekdaysRestricted = !star
+
+	return schedule, nil
+}
+
+// parseScheduleField parses a comma separated list of *, ?, values, names and ranges, each with an
+// optional step, into the values between min and max it matches. It also returns whether a part
+// starts with * or ?.
+func parseScheduleField(field string, min, max int, names map[string]int) ([]bool, bool, error) {
+	values := make([]bool, max+1)
+	star := false
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step, stepped := part, 1, false
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s < 1 {
+				return nil, false, fmt.Errorf("invalid step in %q", part)
+			}
+			rangePart, step, stepped = part[:i], s, true
+		}
+
+		low, high := min, max
+		if rangePart == "*" || rangePart == "?" {
+			star = true
+		} else {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if low, err = parseScheduleValue(bounds[0], names); err != nil {
+				return nil, false, fmt.Errorf("invalid value in %q", part)
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = parseScheduleValue(bounds[1], names); err != nil {
+					return nil, false, fmt.Errorf("invalid value in %q", part)
+				}
+			} else if stepped {
+				high = max
+			}
+		}
+		if low < min || high > max || low > high {
+			return nil, false, fmt.Errorf("%q is out of the range %d-%d", part, min, max)
+		}
+
+		for value := low; value <= high; value += step {
+			values[value] = true
+		}
+	}
+
+	return values, star, nil
+}
+
+func parseScheduleValue(value string, names map[string]int) (int, error) {
+	if n, ok := names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// Next returns the first time matching the schedule after t, or the zero time when none matches
+// within the next five years, e.g. for the 31st of February
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Truncate(time.Second).Add(s.every)
+	}
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case !s.months[t.Month()]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !s.hours[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !s.minutes[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	day, weekday := s.days[t.Day()], s.weekdays[t.Weekday()]
+	if s.daysRestricted && s.weekdaysRestricted {
+		return day || weekday
+	}
+	return day && weekday
+}
@@ -0,0 +1,113 @@
+package miqutils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		spec  string
+		valid bool
+	}{
+		{"0 2 * * *", true},
+		{"*/15 * * * *", true},
+		{"0 0 1,15 * *", true},
+		{"0 8-18/2 * * 1-5", true},
+		{"0 0 * * MON", true},
+		{"0 0 * * mon-fri", true},
+		{"0 0 1 JAN *", true},
+		{"0 0 1 jan-jun/2 *", true},
+		{"0 0 ? * SUN", true},
+		{"0 0 1 * ?", true},
+		{"5/10 * * * *", true},
+		{"@daily", true},
+		{" @weekly ", true},
+		{"@every 90m", true},
+		{"", false},
+		{"0 0 * *", false},
+		{"0 0 * * * *", false},
+		{"60 * * * *", false},
+		{"0 24 * * *", false},
+		{"0 0 0 * *", false},
+		{"0 0 32 * *", false},
+		{"0 0 * 13 *", false},
+		{"0 0 * * 7", false},
+		{"0 0 * * MONDAY", false},
+		{"0 0 * FOO *", false},
+		{"0 0 * * 5-1", false},
+		{"*/0 * * * *", false},
+		{"a * * * *", false},
+		{"@fortnightly", false},
+		{"@every 0s", false},
+		{"@every soon", false},
+		{"TZ=UTC 0 0 * * *", false},
+		{"CRON_TZ=UTC 0 0 * * *", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			_, err := ParseSchedule(test.spec)
+			if test.valid && err != nil {
+				t.Errorf("ParseSchedule(%q) failed: %v", test.spec, err)
+			} else if !test.valid && err == nil {
+				t.Errorf("ParseSchedule(%q) succeeded, want an error", test.spec)
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	date := func(value string) time.Time {
+		t, err := time.Parse("2006-01-02 15:04:05", value)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+
+	tests := []struct {
+		name string
+		spec string
+		from string
+		next string
+	}{
+		{"next minute", "* * * * *", "2026-10-18 10:40:30", "2026-10-18 10:41:00"},
+		{"later today", "0 2 * * *", "2026-10-18 01:59:00", "2026-10-18 02:00:00"},
+		{"matching time is excluded", "0 2 * * *", "2026-10-18 02:00:00", "2026-10-19 02:00:00"},
+		{"step", "*/15 * * * *", "2026-10-18 10:31:00", "2026-10-18 10:45:00"},
+		{"step from a value", "5/20 * * * *", "2026-10-18 10:26:00", "2026-10-18 10:45:00"},
+		{"end of the month", "0 0 1 * *", "2026-10-18 10:00:00", "2026-11-01 00:00:00"},
+		{"end of the year", "@yearly", "2026-10-18 10:00:00", "2027-01-01 00:00:00"},
+		{"weekday name", "0 0 * * MON", "2026-10-18 10:00:00", "2026-10-19 00:00:00"},
+		{"sunday", "@weekly", "2026-10-19 10:00:00", "2026-10-25 00:00:00"},
+		{"month name", "0 0 1 FEB *", "2026-10-18 10:00:00", "2027-02-01 00:00:00"},
+		{"day of month or day of week", "0 0 13 * FRI", "2026-10-18 10:00:00", "2026-10-23 00:00:00"},
+		{"day of month or day of week, day of month first", "0 0 20 * FRI", "2026-10-18 10:00:00", "2026-10-20 00:00:00"},
+		{"day of week with any day of month", "0 0 * * FRI", "2026-10-18 10:00:00", "2026-10-23 00:00:00"},
+		{"day of week with a stepped day of month", "0 0 */2 * SAT", "2026-10-18 10:00:00", "2026-10-31 00:00:00"},
+		{"day of week with ?", "0 0 ? * FRI", "2026-10-18 10:00:00", "2026-10-23 00:00:00"},
+		{"31st skips the short months", "0 0 31 * *", "2026-11-01 00:00:00", "2026-12-31 00:00:00"},
+		{"29th of February", "0 0 29 2 *", "2026-10-18 10:00:00", "2028-02-29 00:00:00"},
+		{"31st of February never matches", "0 0 31 2 *", "2026-10-18 10:00:00", ""},
+		{"30th of February or a monday", "0 0 30 2 MON", "2026-10-18 10:00:00", "2027-02-01 00:00:00"},
+		{"every", "@every 90m", "2026-10-18 10:40:30", "2026-10-18 12:10:30"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(test.spec)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q) failed: %v", test.spec, err)
+			}
+
+			want := time.Time{}
+			if test.next != "" {
+				want = date(test.next)
+			}
+			if next := schedule.Next(date(test.from)); !next.Equal(want) {
+				t.Errorf("Next(%s) of %q = %s, want %s", test.from, test.spec, next, want)
+			}
+		})
+	}
+}
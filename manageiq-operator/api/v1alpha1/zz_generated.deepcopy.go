@@ -21,10 +21,129 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupS3) DeepCopyInto(out *BackupS3) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupS3.
+func (in *BackupS3) DeepCopy() *BackupS3 {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupS3)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupStorage) DeepCopyInto(out *BackupStorage) {
+	*out = *in
+	if in.VolumeClaim != nil {
+		in, out := &in.VolumeClaim, &out.VolumeClaim
+		*out = new(BackupVolumeClaim)
+		**out = **in
+	}
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(BackupS3)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStorage.
+func (in *BackupStorage) DeepCopy() *BackupStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupVolumeClaim) DeepCopyInto(out *BackupVolumeClaim) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupVolumeClaim.
+func (in *BackupVolumeClaim) DeepCopy() *BackupVolumeClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupVolumeClaim)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseMaintenanceRun) DeepCopyInto(out *DatabaseMaintenanceRun) {
+	*out = *in
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulTime != nil {
+		in, out := &in.LastSuccessfulTime, &out.LastSuccessfulTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseMaintenanceRun.
+func (in *DatabaseMaintenanceRun) DeepCopy() *DatabaseMaintenanceRun {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseMaintenanceRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseSnapshot) DeepCopyInto(out *DatabaseSnapshot) {
+	*out = *in
+	if in.CreationTime != nil {
+		in, out := &in.CreationTime, &out.CreationTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseSnapshot.
+func (in *DatabaseSnapshot) DeepCopy() *DatabaseSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriftedObject) DeepCopyInto(out *DriftedObject) {
+	*out = *in
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.LastDetectedTime.DeepCopyInto(&out.LastDetectedTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriftedObject.
+func (in *DriftedObject) DeepCopy() *DriftedObject {
+	if in == nil {
+		return nil
+	}
+	out := new(DriftedObject)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Endpoint) DeepCopyInto(out *Endpoint) {
 	*out = *in
@@ -68,6 +187,117 @@ func (in *ManageIQ) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManageIQBackup) DeepCopyInto(out *ManageIQBackup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageIQBackup.
+func (in *ManageIQBackup) DeepCopy() *ManageIQBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(ManageIQBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ManageIQBackup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManageIQBackupList) DeepCopyInto(out *ManageIQBackupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ManageIQBackup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageIQBackupList.
+func (in *ManageIQBackupList) DeepCopy() *ManageIQBackupList {
+	if in == nil {
+		return nil
+	}
+	out := new(ManageIQBackupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ManageIQBackupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManageIQBackupSpec) DeepCopyInto(out *ManageIQBackupSpec) {
+	*out = *in
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = new(int32)
+		**out = **in
+	}
+	in.Storage.DeepCopyInto(&out.Storage)
+	if in.Suspend != nil {
+		in, out := &in.Suspend, &out.Suspend
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageIQBackupSpec.
+func (in *ManageIQBackupSpec) DeepCopy() *ManageIQBackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ManageIQBackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManageIQBackupStatus) DeepCopyInto(out *ManageIQBackupStatus) {
+	*out = *in
+	if in.LastSuccessfulTime != nil {
+		in, out := &in.LastSuccessfulTime, &out.LastSuccessfulTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageIQBackupStatus.
+func (in *ManageIQBackupStatus) DeepCopy() *ManageIQBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ManageIQBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ManageIQList) DeepCopyInto(out *ManageIQList) {
 	*out = *in
@@ -100,6 +330,114 @@ func (in *ManageIQList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManageIQRestore) DeepCopyInto(out *ManageIQRestore) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageIQRestore.
+func (in *ManageIQRestore) DeepCopy() *ManageIQRestore {
+	if in == nil {
+		return nil
+	}
+	out := new(ManageIQRestore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ManageIQRestore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManageIQRestoreList) DeepCopyInto(out *ManageIQRestoreList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ManageIQRestore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageIQRestoreList.
+func (in *ManageIQRestoreList) DeepCopy() *ManageIQRestoreList {
+	if in == nil {
+		return nil
+	}
+	out := new(ManageIQRestoreList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ManageIQRestoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManageIQRestoreSpec) DeepCopyInto(out *ManageIQRestoreSpec) {
+	*out = *in
+	if in.TargetTime != nil {
+		in, out := &in.TargetTime, &out.TargetTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageIQRestoreSpec.
+func (in *ManageIQRestoreSpec) DeepCopy() *ManageIQRestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ManageIQRestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManageIQRestoreStatus) DeepCopyInto(out *ManageIQRestoreStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageIQRestoreStatus.
+func (in *ManageIQRestoreStatus) DeepCopy() *ManageIQRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ManageIQRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ManageIQSpec) DeepCopyInto(out *ManageIQSpec) {
 	*out = *in
@@ -110,11 +448,36 @@ func (in *ManageIQSpec) DeepCopyInto(out *ManageIQSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.DatabaseMaintenanceReindexTables != nil {
+		in, out := &in.DatabaseMaintenanceReindexTables, &out.DatabaseMaintenanceReindexTables
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DatabaseMaintenanceVacuumTables != nil {
+		in, out := &in.DatabaseMaintenanceVacuumTables, &out.DatabaseMaintenanceVacuumTables
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DatabaseSnapshotPauseOrchestrator != nil {
+		in, out := &in.DatabaseSnapshotPauseOrchestrator, &out.DatabaseSnapshotPauseOrchestrator
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DatabaseSnapshotRetention != nil {
+		in, out := &in.DatabaseSnapshotRetention, &out.DatabaseSnapshotRetention
+		*out = new(int32)
+		**out = **in
+	}
 	if in.DeployMessagingService != nil {
 		in, out := &in.DeployMessagingService, &out.DeployMessagingService
 		*out = new(bool)
 		**out = **in
 	}
+	if in.DeployPgbouncer != nil {
+		in, out := &in.DeployPgbouncer, &out.DeployPgbouncer
+		*out = new(bool)
+		**out = **in
+	}
 	if in.EnableApplicationLocalLogin != nil {
 		in, out := &in.EnableApplicationLocalLogin, &out.EnableApplicationLocalLogin
 		*out = new(bool)
@@ -130,6 +493,46 @@ func (in *ManageIQSpec) DeepCopyInto(out *ManageIQSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.ExternalDatabasePort != nil {
+		in, out := &in.ExternalDatabasePort, &out.ExternalDatabasePort
+		*out = new(int32)
+		**out = **in
+	}
+	if in.HttpdAutoscaleMaxReplicas != nil {
+		in, out := &in.HttpdAutoscaleMaxReplicas, &out.HttpdAutoscaleMaxReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.HttpdAutoscaleTargetCPUUtilization != nil {
+		in, out := &in.HttpdAutoscaleTargetCPUUtilization, &out.HttpdAutoscaleTargetCPUUtilization
+		*out = new(int32)
+		**out = **in
+	}
+	if in.HttpdReplicas != nil {
+		in, out := &in.HttpdReplicas, &out.HttpdReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.HttpdScheduling != nil {
+		in, out := &in.HttpdScheduling, &out.HttpdScheduling
+		*out = new(PodScheduling)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.KafkaScheduling != nil {
+		in, out := &in.KafkaScheduling, &out.KafkaScheduling
+		*out = new(PodScheduling)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaintenanceMode != nil {
+		in, out := &in.MaintenanceMode, &out.MaintenanceMode
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MemcachedScheduling != nil {
+		in, out := &in.MemcachedScheduling, &out.MemcachedScheduling
+		*out = new(PodScheduling)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.MigrationsRan != nil {
 		in, out := &in.MigrationsRan, &out.MigrationsRan
 		*out = make([]string, len(*in))
@@ -140,6 +543,70 @@ func (in *ManageIQSpec) DeepCopyInto(out *ManageIQSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.OrchestratorScheduling != nil {
+		in, out := &in.OrchestratorScheduling, &out.OrchestratorScheduling
+		*out = new(PodScheduling)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PgbouncerDefaultPoolSize != nil {
+		in, out := &in.PgbouncerDefaultPoolSize, &out.PgbouncerDefaultPoolSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PgbouncerMaxClientConnections != nil {
+		in, out := &in.PgbouncerMaxClientConnections, &out.PgbouncerMaxClientConnections
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PgbouncerMaxDatabaseConnections != nil {
+		in, out := &in.PgbouncerMaxDatabaseConnections, &out.PgbouncerMaxDatabaseConnections
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PgbouncerScheduling != nil {
+		in, out := &in.PgbouncerScheduling, &out.PgbouncerScheduling
+		*out = new(PodScheduling)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PostgresqlAllowEviction != nil {
+		in, out := &in.PostgresqlAllowEviction, &out.PostgresqlAllowEviction
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PostgresqlAutoTune != nil {
+		in, out := &in.PostgresqlAutoTune, &out.PostgresqlAutoTune
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PostgresqlParameters != nil {
+		in, out := &in.PostgresqlParameters, &out.PostgresqlParameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PostgresqlPrimary != nil {
+		in, out := &in.PostgresqlPrimary, &out.PostgresqlPrimary
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PostgresqlReplicas != nil {
+		in, out := &in.PostgresqlReplicas, &out.PostgresqlReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PostgresqlScheduling != nil {
+		in, out := &in.PostgresqlScheduling, &out.PostgresqlScheduling
+		*out = new(PodScheduling)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageIQSpec.
@@ -165,9 +632,46 @@ func (in *ManageIQStatus) DeepCopyInto(out *ManageIQStatus) {
 		*out = make([]Endpoint, len(*in))
 		copy(*out, *in)
 	}
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = make([]DriftedObject, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PostgresqlTuning != nil {
+		in, out := &in.PostgresqlTuning, &out.PostgresqlTuning
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.DatabaseSnapshots != nil {
+		in, out := &in.DatabaseSnapshots, &out.DatabaseSnapshots
+		*out = make([]DatabaseSnapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DatabaseMaintenance != nil {
+		in, out := &in.DatabaseMaintenance, &out.DatabaseMaintenance
+		*out = make([]DatabaseMaintenanceRun, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DatabaseCredentialsRotationTime != nil {
+		in, out := &in.DatabaseCredentialsRotationTime, &out.DatabaseCredentialsRotationTime
+		*out = (*in).DeepCopy()
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]v1.Condition, len(*in))
+		*out = make([]metav1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -184,6 +688,40 @@ func (in *ManageIQStatus) DeepCopy() *ManageIQStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodScheduling) DeepCopyInto(out *PodScheduling) {
+	*out = *in
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodScheduling.
+func (in *PodScheduling) DeepCopy() *PodScheduling {
+	if in == nil {
+		return nil
+	}
+	out := new(PodScheduling)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretSource) DeepCopyInto(out *SecretSource) {
 	*out = *in
@@ -0,0 +1,587 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+)
+
+// hubSpecAnnotation keeps the v1alpha1 strings which v1beta1 parses and would otherwise render
+// differently on the way back, e.g. a memory limit of 1024Mi or a repository with a registry port
+const hubSpecAnnotation = "manageiq.org/v1alpha1-spec"
+
+// hubString is a v1alpha1 field kept in the hubSpecAnnotation, along with the value the conversion
+// renders for it. The original is only restored while the v1beta1 field still converts to that value.
+type hubString struct {
+	Value     string `json:"value"`
+	Converted string `json:"converted"`
+}
+
+// ConvertTo converts this ManageIQ to the Hub version (v1alpha1)
+func (src *ManageIQ) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*miqv1alpha1.ManageIQ)
+	if err := src.convertTo(dst); err != nil {
+		return err
+	}
+
+	return restoreHubStrings(dst)
+}
+
+func (src *ManageIQ) convertTo(dst *miqv1alpha1.ManageIQ) error {
+	dst.ObjectMeta = src.ObjectMeta
+
+	s := src.Spec
+	d := &dst.Spec
+
+	d.AppAnnotations = s.AppAnnotations
+	d.ApplicationDomain = s.ApplicationDomain
+	d.AppName = s.AppName
+	d.BackupLabelName = s.BackupLabelName
+	d.DatabaseRegion = s.DatabaseRegion
+	d.DatabaseSecret = s.DatabaseSecret
+	d.DeletionPolicy = s.DeletionPolicy
+	d.DriftDetection = s.DriftDetection
+	d.EnableApplicationLocalLogin = s.EnableApplicationLocalLogin
+	d.EnableSSO = s.EnableSSO
+	d.EnforceWorkerResourceConstraints = s.EnforceWorkerResourceConstraints
+	d.ImagePullSecret = s.ImagePullSecret
+	d.InitialAdminGroupName = s.InitialAdminGroupName
+	d.InternalCertificatesSecret = s.InternalCertificatesSecret
+	d.MaintenanceMode = s.MaintenanceMode
+	d.MigrationsRan = s.MigrationsRan
+	d.ServerGuid = s.ServerGuid
+	d.StorageClassName = s.StorageClassName
+	d.TLSSecret = s.TLSSecret
+
+	d.DatabaseCredentialsRotationSchedule = s.Database.CredentialsRotation.Schedule
+	d.DatabaseMaintenanceReindexSchedule = s.Database.Maintenance.ReindexSchedule
+	d.DatabaseMaintenanceReindexTables = s.Database.Maintenance.ReindexTables
+	d.DatabaseMaintenanceReportSchedule = s.Database.Maintenance.ReportSchedule
+	d.DatabaseMaintenanceVacuumSchedule = s.Database.Maintenance.VacuumSchedule
+	d.DatabaseMaintenanceVacuumTables = s.Database.Maintenance.VacuumTables
+	d.ExternalDatabaseCASecret = s.Database.External.CASecret
+	d.ExternalDatabaseClientCertSecret = s.Database.External.ClientCertSecret
+	d.ExternalDatabaseHost = s.Database.External.Host
+	d.ExternalDatabasePort = s.Database.External.Port
+	d.ExternalDatabaseSSLMode = s.Database.External.SSLMode
+	d.DatabaseSnapshotClassName = s.Database.Snapshots.ClassName
+	d.DatabaseSnapshotPauseOrchestrator = s.Database.Snapshots.PauseOrchestrator
+	d.DatabaseSnapshotRestore = s.Database.Snapshots.Restore
+	d.DatabaseSnapshotRetention = s.Database.Snapshots.Retention
+	d.DatabaseSnapshotSchedule = s.Database.Snapshots.Schedule
+
+	d.HttpdAuthConfig = s.Httpd.AuthConfig
+	d.HttpdAuthenticationType = s.Httpd.AuthenticationType
+	d.HttpdAutoscaleMaxReplicas = s.Httpd.Autoscaling.MaxReplicas
+	d.HttpdAutoscaleTargetCPUUtilization = s.Httpd.Autoscaling.TargetCPUUtilization
+	d.HttpdImage = s.Httpd.Image.Image
+	d.HttpdImageNamespace = s.Httpd.Image.Repository
+	d.HttpdImageTag = s.Httpd.Image.Tag
+	d.HttpdCpuLimit, d.HttpdCpuRequest, d.HttpdMemoryLimit, d.HttpdMemoryRequest = resourcesToStrings(s.Httpd.Resources)
+	d.HttpdReplicas = s.Httpd.Replicas
+	d.HttpdScheduling = podSchedulingToHub(s.Httpd.Scheduling)
+	d.OIDCCACertSecret = s.Httpd.OIDC.CACertSecret
+	d.OIDCClientSecret = s.Httpd.OIDC.ClientSecret
+	d.OIDCOAuthIntrospectionURL = s.Httpd.OIDC.IntrospectionURL
+	d.OIDCOAuthIntrospectionSSLVerify = s.Httpd.OIDC.IntrospectionSSLVerify
+	d.OIDCProviderURL = s.Httpd.OIDC.ProviderURL
+
+	d.DeployMessagingService = s.Kafka.Enabled
+	d.KafkaImage = s.Kafka.Image.Image
+	d.KafkaImageName = s.Kafka.Image.Repository
+	d.KafkaImageTag = s.Kafka.Image.Tag
+	d.KafkaCpuLimit, d.KafkaCpuRequest, d.KafkaMemoryLimit, d.KafkaMemoryRequest = resourcesToStrings(s.Kafka.Resources)
+	d.KafkaScheduling = podSchedulingToHub(s.Kafka.Scheduling)
+	d.KafkaSecret = s.Kafka.Secret
+	d.KafkaVolumeCapacity = quantityToString(s.Kafka.VolumeCapacity)
+
+	d.MemcachedImage = s.Memcached.Image.Image
+	d.MemcachedImageName = s.Memcached.Image.Repository
+	d.MemcachedImageTag = s.Memcached.Image.Tag
+	d.MemcachedMaxConnection = s.Memcached.MaxConnection
+	d.MemcachedMaxMemory = s.Memcached.MaxMemory
+	d.MemcachedCpuLimit, d.MemcachedCpuRequest, d.MemcachedMemoryLimit, d.MemcachedMemoryRequest = resourcesToStrings(s.Memcached.Resources)
+	d.MemcachedScheduling = podSchedulingToHub(s.Memcached.Scheduling)
+	d.MemcachedSlabPageSize = s.Memcached.SlabPageSize
+
+	d.BaseWorkerImage = s.Orchestrator.BaseWorkerImage
+	d.OrchestratorImage = s.Orchestrator.Image.Image
+	d.OrchestratorImageNamespace, d.OrchestratorImageName = splitRepository(s.Orchestrator.Image.Repository)
+	d.OrchestratorImageTag = s.Orchestrator.Image.Tag
+	d.OrchestratorInitialDelay = s.Orchestrator.InitialDelay
+	d.OpentofuRunnerImage = s.Orchestrator.OpentofuRunnerImage
+	d.OrchestratorCpuLimit, d.OrchestratorCpuRequest, d.OrchestratorMemoryLimit, d.OrchestratorMemoryRequest = resourcesToStrings(s.Orchestrator.Resources)
+	d.OrchestratorScheduling = podSchedulingToHub(s.Orchestrator.Scheduling)
+	d.UIWorkerImage = s.Orchestrator.UIWorkerImage
+	d.WebserverWorkerImage = s.Orchestrator.WebserverWorkerImage
+
+	d.DeployPgbouncer = s.Pgbouncer.Enabled
+	d.PgbouncerDefaultPoolSize = s.Pgbouncer.DefaultPoolSize
+	d.PgbouncerImage = s.Pgbouncer.Image
+	d.PgbouncerMaxClientConnections = s.Pgbouncer.MaxClientConnections
+	d.PgbouncerMaxDatabaseConnections = s.Pgbouncer.MaxDatabaseConnections
+	d.PgbouncerPoolMode = s.Pgbouncer.PoolMode
+	d.PgbouncerCpuLimit, d.PgbouncerCpuRequest, d.PgbouncerMemoryLimit, d.PgbouncerMemoryRequest = resourcesToStrings(s.Pgbouncer.Resources)
+	d.PgbouncerScheduling = podSchedulingToHub(s.Pgbouncer.Scheduling)
+
+	d.PostgresqlAllowEviction = s.Postgresql.AllowEviction
+	d.PostgresqlAutoTune = s.Postgresql.AutoTune
+	d.PostgresqlConfigMap = s.Postgresql.ConfigMapName
+	d.PostgresqlImage = s.Postgresql.Image.Image
+	d.PostgresqlImageName = s.Postgresql.Image.Repository
+	d.PostgresqlImageTag = s.Postgresql.Image.Tag
+	d.PostgresqlMaxConnections = s.Postgresql.MaxConnections
+	d.PostgresqlMode = s.Postgresql.Mode
+	d.PostgresqlParameters = s.Postgresql.Parameters
+	d.PostgresqlPrimary = s.Postgresql.Primary
+	d.PostgresqlReplicas = s.Postgresql.Replicas
+	d.PostgresqlCpuLimit, d.PostgresqlCpuRequest, d.PostgresqlMemoryLimit, d.PostgresqlMemoryRequest = resourcesToStrings(s.Postgresql.Resources)
+	d.PostgresqlScheduling = podSchedulingToHub(s.Postgresql.Scheduling)
+	d.PostgresqlSharedBuffers = s.Postgresql.SharedBuffers
+	d.DatabaseVolumeCapacity = quantityToString(s.Postgresql.VolumeCapacity)
+	d.PostgresqlWalArchiveBackup = s.Postgresql.WalArchiveBackup
+
+	d.PodAntiAffinity = s.Scheduling.PodAntiAffinity
+	d.TopologySpreadConstraints = s.Scheduling.TopologySpreadConstraints
+
+	d.ZookeeperImage = s.Zookeeper.Image.Image
+	d.ZookeeperImageName = s.Zookeeper.Image.Repository
+	d.ZookeeperImageTag = s.Zookeeper.Image.Tag
+	d.ZookeeperCpuLimit, d.ZookeeperCpuRequest, d.ZookeeperMemoryLimit, d.ZookeeperMemoryRequest = resourcesToStrings(s.Zookeeper.Resources)
+	d.ZookeeperVolumeCapacity = quantityToString(s.Zookeeper.VolumeCapacity)
+
+	dst.Status.Versions = nil
+	for _, v := range src.Status.Versions {
+		dst.Status.Versions = append(dst.Status.Versions, miqv1alpha1.Version{Name: v.Name, Version: v.Version})
+	}
+	dst.Status.Endpoints = nil
+	for _, e := range src.Status.Endpoints {
+		dst.Status.Endpoints = append(dst.Status.Endpoints, miqv1alpha1.Endpoint{
+			Name:     e.Name,
+			Type:     e.Type,
+			Scope:    e.Scope,
+			URI:      e.URI,
+			CASecret: miqv1alpha1.SecretSource{SecretName: e.CASecret.SecretName, Key: e.CASecret.Key},
+		})
+	}
+	dst.Status.ObservedGeneration = src.Status.ObservedGeneration
+	dst.Status.Components = src.Status.Components
+	dst.Status.Drift = nil
+	for _, drifted := range src.Status.Drift {
+		dst.Status.Drift = append(dst.Status.Drift, miqv1alpha1.DriftedObject{
+			Kind:             drifted.Kind,
+			Name:             drifted.Name,
+			Fields:           drifted.Fields,
+			Corrected:        drifted.Corrected,
+			LastDetectedTime: drifted.LastDetectedTime,
+		})
+	}
+	dst.Status.PostgresqlClaimName = src.Status.PostgresqlClaimName
+	dst.Status.PostgresqlTuning = src.Status.PostgresqlTuning
+	dst.Status.DatabaseSnapshots = nil
+	for _, snapshot := range src.Status.DatabaseSnapshots {
+		dst.Status.DatabaseSnapshots = append(dst.Status.DatabaseSnapshots, miqv1alpha1.DatabaseSnapshot{
+			Name:         snapshot.Name,
+			CreationTime: snapshot.CreationTime,
+			ReadyToUse:   snapshot.ReadyToUse,
+			RestoreSize:  snapshot.RestoreSize,
+		})
+	}
+	dst.Status.DatabaseMaintenance = nil
+	for _, run := range src.Status.DatabaseMaintenance {
+		dst.Status.DatabaseMaintenance = append(dst.Status.DatabaseMaintenance, miqv1alpha1.DatabaseMaintenanceRun{
+			Task:               run.Task,
+			LastScheduleTime:   run.LastScheduleTime,
+			LastSuccessfulTime: run.LastSuccessfulTime,
+			LastResult:         run.LastResult,
+			Message:            run.Message,
+		})
+	}
+	dst.Status.DatabaseCredentialsRotationTime = src.Status.DatabaseCredentialsRotationTime
+	dst.Status.DatabaseCredentialsRotationRequest = src.Status.DatabaseCredentialsRotationRequest
+	dst.Status.Conditions = src.Status.Conditions
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1alpha1) to this version
+func (dst *ManageIQ) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*miqv1alpha1.ManageIQ)
+	if err := dst.convertFrom(src); err != nil {
+		return err
+	}
+
+	return dst.keepHubStrings(src)
+}
+
+func (dst *ManageIQ) convertFrom(src *miqv1alpha1.ManageIQ) error {
+	dst.ObjectMeta = src.ObjectMeta
+
+	s := src.Spec
+	d := &dst.Spec
+	var err error
+
+	d.AppAnnotations = s.AppAnnotations
+	d.ApplicationDomain = s.ApplicationDomain
+	d.AppName = s.AppName
+	d.BackupLabelName = s.BackupLabelName
+	d.DatabaseRegion = s.DatabaseRegion
+	d.DatabaseSecret = s.DatabaseSecret
+	d.DeletionPolicy = s.DeletionPolicy
+	d.DriftDetection = s.DriftDetection
+	d.EnableApplicationLocalLogin = s.EnableApplicationLocalLogin
+	d.EnableSSO = s.EnableSSO
+	d.EnforceWorkerResourceConstraints = s.EnforceWorkerResourceConstraints
+	d.ImagePullSecret = s.ImagePullSecret
+	d.InitialAdminGroupName = s.InitialAdminGroupName
+	d.InternalCertificatesSecret = s.InternalCertificatesSecret
+	d.MaintenanceMode = s.MaintenanceMode
+	d.MigrationsRan = s.MigrationsRan
+	d.ServerGuid = s.ServerGuid
+	d.StorageClassName = s.StorageClassName
+	d.TLSSecret = s.TLSSecret
+
+	d.Database.CredentialsRotation.Schedule = s.DatabaseCredentialsRotationSchedule
+	d.Database.Maintenance.ReindexSchedule = s.DatabaseMaintenanceReindexSchedule
+	d.Database.Maintenance.ReindexTables = s.DatabaseMaintenanceReindexTables
+	d.Database.Maintenance.ReportSchedule = s.DatabaseMaintenanceReportSchedule
+	d.Database.Maintenance.VacuumSchedule = s.DatabaseMaintenanceVacuumSchedule
+	d.Database.Maintenance.VacuumTables = s.DatabaseMaintenanceVacuumTables
+	d.Database.External.CASecret = s.ExternalDatabaseCASecret
+	d.Database.External.ClientCertSecret = s.ExternalDatabaseClientCertSecret
+	d.Database.External.Host = s.ExternalDatabaseHost
+	d.Database.External.Port = s.ExternalDatabasePort
+	d.Database.External.SSLMode = s.ExternalDatabaseSSLMode
+	d.Database.Snapshots.ClassName = s.DatabaseSnapshotClassName
+	d.Database.Snapshots.PauseOrchestrator = s.DatabaseSnapshotPauseOrchestrator
+	d.Database.Snapshots.Restore = s.DatabaseSnapshotRestore
+	d.Database.Snapshots.Retention = s.DatabaseSnapshotRetention
+	d.Database.Snapshots.Schedule = s.DatabaseSnapshotSchedule
+
+	d.Httpd.AuthConfig = s.HttpdAuthConfig
+	d.Httpd.AuthenticationType = s.HttpdAuthenticationType
+	d.Httpd.Autoscaling = HttpdAutoscalingSpec{MaxReplicas: s.HttpdAutoscaleMaxReplicas, TargetCPUUtilization: s.HttpdAutoscaleTargetCPUUtilization}
+	d.Httpd.Image = ImageSpec{Image: s.HttpdImage, Repository: s.HttpdImageNamespace, Tag: s.HttpdImageTag}
+	if d.Httpd.Resources, err = resourcesFromStrings("httpd", s.HttpdCpuLimit, s.HttpdCpuRequest, s.HttpdMemoryLimit, s.HttpdMemoryRequest); err != nil {
+		return err
+	}
+	d.Httpd.OIDC = OIDCSpec{
+		CACertSecret:           s.OIDCCACertSecret,
+		ClientSecret:           s.OIDCClientSecret,
+		IntrospectionURL:       s.OIDCOAuthIntrospectionURL,
+		IntrospectionSSLVerify: s.OIDCOAuthIntrospectionSSLVerify,
+		ProviderURL:            s.OIDCProviderURL,
+	}
+	d.Httpd.Replicas = s.HttpdReplicas
+	d.Httpd.Scheduling = podSchedulingFromHub(s.HttpdScheduling)
+
+	d.Kafka.Enabled = s.DeployMessagingService
+	d.Kafka.Image = ImageSpec{Image: s.KafkaImage, Repository: s.KafkaImageName, Tag: s.KafkaImageTag}
+	if d.Kafka.Resources, err = resourcesFromStrings("kafka", s.KafkaCpuLimit, s.KafkaCpuRequest, s.KafkaMemoryLimit, s.KafkaMemoryRequest); err != nil {
+		return err
+	}
+	d.Kafka.Scheduling = podSchedulingFromHub(s.KafkaScheduling)
+	d.Kafka.Secret = s.KafkaSecret
+	if d.Kafka.VolumeCapacity, err = quantityFromString("kafkaVolumeCapacity", s.KafkaVolumeCapacity); err != nil {
+		return err
+	}
+
+	d.Memcached.Image = ImageSpec{Image: s.MemcachedImage, Repository: s.MemcachedImageName, Tag: s.MemcachedImageTag}
+	d.Memcached.MaxConnection = s.MemcachedMaxConnection
+	d.Memcached.MaxMemory = s.MemcachedMaxMemory
+	if d.Memcached.Resources, err = resourcesFromStrings("memcached", s.MemcachedCpuLimit, s.MemcachedCpuRequest, s.MemcachedMemoryLimit, s.MemcachedMemoryRequest); err != nil {
+		return err
+	}
+	d.Memcached.Scheduling = podSchedulingFromHub(s.MemcachedScheduling)
+	d.Memcached.SlabPageSize = s.MemcachedSlabPageSize
+
+	d.Orchestrator.BaseWorkerImage = s.BaseWorkerImage
+	d.Orchestrator.Image = ImageSpec{Image: s.OrchestratorImage, Repository: joinRepository(s.OrchestratorImageNamespace, s.OrchestratorImageName), Tag: s.OrchestratorImageTag}
+	d.Orchestrator.InitialDelay = s.OrchestratorInitialDelay
+	d.Orchestrator.OpentofuRunnerImage = s.OpentofuRunnerImage
+	if d.Orchestrator.Resources, err = resourcesFromStrings("orchestrator", s.OrchestratorCpuLimit, s.OrchestratorCpuRequest, s.OrchestratorMemoryLimit, s.OrchestratorMemoryRequest); err != nil {
+		return err
+	}
+	d.Orchestrator.Scheduling = podSchedulingFromHub(s.OrchestratorScheduling)
+	d.Orchestrator.UIWorkerImage = s.UIWorkerImage
+	d.Orchestrator.WebserverWorkerImage = s.WebserverWorkerImage
+
+	d.Pgbouncer.DefaultPoolSize = s.PgbouncerDefaultPoolSize
+	d.Pgbouncer.Enabled = s.DeployPgbouncer
+	d.Pgbouncer.Image = s.PgbouncerImage
+	d.Pgbouncer.MaxClientConnections = s.PgbouncerMaxClientConnections
+	d.Pgbouncer.MaxDatabaseConnections = s.PgbouncerMaxDatabaseConnections
+	d.Pgbouncer.PoolMode = s.PgbouncerPoolMode
+	if d.Pgbouncer.Resources, err = resourcesFromStrings("pgbouncer", s.PgbouncerCpuLimit, s.PgbouncerCpuRequest, s.PgbouncerMemoryLimit, s.PgbouncerMemoryRequest); err != nil {
+		return err
+	}
+	d.Pgbouncer.Scheduling = podSchedulingFromHub(s.PgbouncerScheduling)
+
+	d.Postgresql.AllowEviction = s.PostgresqlAllowEviction
+	d.Postgresql.AutoTune = s.PostgresqlAutoTune
+	d.Postgresql.ConfigMapName = s.PostgresqlConfigMap
+	d.Postgresql.Image = ImageSpec{Image: s.PostgresqlImage, Repository: s.PostgresqlImageName, Tag: s.PostgresqlImageTag}
+	d.Postgresql.MaxConnections = s.PostgresqlMaxConnections
+	d.Postgresql.Mode = s.PostgresqlMode
+	d.Postgresql.Parameters = s.PostgresqlParameters
+	d.Postgresql.Primary = s.PostgresqlPrimary
+	d.Postgresql.Replicas = s.PostgresqlReplicas
+	if d.Postgresql.Resources, err = resourcesFromStrings("postgresql", s.PostgresqlCpuLimit, s.PostgresqlCpuRequest, s.PostgresqlMemoryLimit, s.PostgresqlMemoryRequest); err != nil {
+		return err
+	}
+	d.Postgresql.Scheduling = podSchedulingFromHub(s.PostgresqlScheduling)
+	d.Postgresql.SharedBuffers = s.PostgresqlSharedBuffers
+	if d.Postgresql.VolumeCapacity, err = quantityFromString("databaseVolumeCapacity", s.DatabaseVolumeCapacity); err != nil {
+		return err
+	}
+	d.Postgresql.WalArchiveBackup = s.PostgresqlWalArchiveBackup
+
+	d.Scheduling = SchedulingSpec{PodAntiAffinity: s.PodAntiAffinity, TopologySpreadConstraints: s.TopologySpreadConstraints}
+
+	d.Zookeeper.Image = ImageSpec{Image: s.ZookeeperImage, Repository: s.ZookeeperImageName, Tag: s.ZookeeperImageTag}
+	if d.Zookeeper.Resources, err = resourcesFromStrings("zookeeper", s.ZookeeperCpuLimit, s.ZookeeperCpuRequest, s.ZookeeperMemoryLimit, s.ZookeeperMemoryRequest); err != nil {
+		return err
+	}
+	if d.Zookeeper.VolumeCapacity, err = quantityFromString("zookeeperVolumeCapacity", s.ZookeeperVolumeCapacity); err != nil {
+		return err
+	}
+
+	dst.Status.Versions = nil
+	for _, v := range src.Status.Versions {
+		dst.Status.Versions = append(dst.Status.Versions, Version{Name: v.Name, Version: v.Version})
+	}
+	dst.Status.Endpoints = nil
+	for _, e := range src.Status.Endpoints {
+		dst.Status.Endpoints = append(dst.Status.Endpoints, Endpoint{
+			Name:     e.Name,
+			Type:     e.Type,
+			Scope:    e.Scope,
+			URI:      e.URI,
+			CASecret: SecretSource{SecretName: e.CASecret.SecretName, Key: e.CASecret.Key},
+		})
+	}
+	dst.Status.ObservedGeneration = src.Status.ObservedGeneration
+	dst.Status.Components = src.Status.Components
+	dst.Status.Drift = nil
+	for _, drifted := range src.Status.Drift {
+		dst.Status.Drift = append(dst.Status.Drift, DriftedObject{
+			Kind:             drifted.Kind,
+			Name:             drifted.Name,
+			Fields:           drifted.Fields,
+			Corrected:        drifted.Corrected,
+			LastDetectedTime: drifted.LastDetectedTime,
+		})
+	}
+	dst.Status.PostgresqlClaimName = src.Status.PostgresqlClaimName
+	dst.Status.PostgresqlTuning = src.Status.PostgresqlTuning
+	dst.Status.DatabaseSnapshots = nil
+	for _, snapshot := range src.Status.DatabaseSnapshots {
+		dst.Status.DatabaseSnapshots = append(dst.Status.DatabaseSnapshots, DatabaseSnapshot{
+			Name:         snapshot.Name,
+			CreationTime: snapshot.CreationTime,
+			ReadyToUse:   snapshot.ReadyToUse,
+			RestoreSize:  snapshot.RestoreSize,
+		})
+	}
+	dst.Status.DatabaseMaintenance = nil
+	for _, run := range src.Status.DatabaseMaintenance {
+		dst.Status.DatabaseMaintenance = append(dst.Status.DatabaseMaintenance, DatabaseMaintenanceRun{
+			Task:               run.Task,
+			LastScheduleTime:   run.LastScheduleTime,
+			LastSuccessfulTime: run.LastSuccessfulTime,
+			LastResult:         run.LastResult,
+			Message:            run.Message,
+		})
+	}
+	dst.Status.DatabaseCredentialsRotationTime = src.Status.DatabaseCredentialsRotationTime
+	dst.Status.DatabaseCredentialsRotationRequest = src.Status.DatabaseCredentialsRotationRequest
+	dst.Status.Conditions = src.Status.Conditions
+
+	return nil
+}
+
+func resourcesFromStrings(component, cpuLimit, cpuRequest, memLimit, memRequest string) (corev1.ResourceRequirements, error) {
+	r := corev1.ResourceRequirements{}
+
+	add := func(list *corev1.ResourceList, name corev1.ResourceName, value, field string) error {
+		if value == "" {
+			return nil
+		}
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %s %q: %v", component, field, value, err)
+		}
+		if *list == nil {
+			*list = corev1.ResourceList{}
+		}
+		(*list)[name] = q
+		return nil
+	}
+
+	if err := add(&r.Limits, corev1.ResourceCPU, cpuLimit, "cpu limit"); err != nil {
+		return r, err
+	}
+	if err := add(&r.Requests, corev1.ResourceCPU, cpuRequest, "cpu request"); err != nil {
+		return r, err
+	}
+	if err := add(&r.Limits, corev1.ResourceMemory, memLimit, "memory limit"); err != nil {
+		return r, err
+	}
+	if err := add(&r.Requests, corev1.ResourceMemory, memRequest, "memory request"); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+func resourcesToStrings(r corev1.ResourceRequirements) (cpuLimit, cpuRequest, memLimit, memRequest string) {
+	get := func(list corev1.ResourceList, name corev1.ResourceName) string {
+		if q, ok := list[name]; ok {
+			return q.String()
+		}
+		return ""
+	}
+
+	return get(r.Limits, corev1.ResourceCPU), get(r.Requests, corev1.ResourceCPU), get(r.Limits, corev1.ResourceMemory), get(r.Requests, corev1.ResourceMemory)
+}
+
+func podSchedulingToHub(s PodSchedulingSpec) *miqv1alpha1.PodScheduling {
+	if s.Affinity == nil && len(s.NodeSelector) == 0 && s.PriorityClassName == "" && len(s.Tolerations) == 0 {
+		return nil
+	}
+	return &miqv1alpha1.PodScheduling{Affinity: s.Affinity, NodeSelector: s.NodeSelector, PriorityClassName: s.PriorityClassName, Tolerations: s.Tolerations}
+}
+
+func podSchedulingFromHub(s *miqv1alpha1.PodScheduling) PodSchedulingSpec {
+	if s == nil {
+		return PodSchedulingSpec{}
+	}
+	return PodSchedulingSpec{Affinity: s.Affinity, NodeSelector: s.NodeSelector, PriorityClassName: s.PriorityClassName, Tolerations: s.Tolerations}
+}
+
+func quantityFromString(field, value string) (*resource.Quantity, error) {
+	if value == "" {
+		return nil, nil
+	}
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %v", field, value, err)
+	}
+	return &q, nil
+}
+
+func quantityToString(q *resource.Quantity) string {
+	if q == nil {
+		return ""
+	}
+	return q.String()
+}
+
+// The orchestrator image is split into a namespace and a name in v1alpha1,
+// joinRepository and splitRepository map that pair to and from a single repository
+func joinRepository(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+func splitRepository(repository string) (namespace, name string) {
+	i := strings.LastIndex(repository, "/")
+	if i < 0 {
+		return "", repository
+	}
+	return repository[:i], repository[i+1:]
+}
+
+// keepHubStrings records the string fields of the hub spec which the conversion back would change
+// in the hubSpecAnnotation
+func (dst *ManageIQ) keepHubStrings(src *miqv1alpha1.ManageIQ) error {
+	converted := &miqv1alpha1.ManageIQ{}
+	if err := dst.convertTo(converted); err != nil {
+		return err
+	}
+
+	kept := map[string]hubString{}
+	original, roundTrip := reflect.ValueOf(src.Spec), reflect.ValueOf(converted.Spec)
+	for i := 0; i < original.NumField(); i++ {
+		if original.Field(i).Kind() != reflect.String {
+			continue
+		}
+		if value, convertedValue := original.Field(i).String(), roundTrip.Field(i).String(); value != convertedValue {
+			kept[original.Type().Field(i).Name] = hubString{Value: value, Converted: convertedValue}
+		}
+	}
+
+	// The annotations are shared with the hub object, they are copied before being changed
+	dst.Annotations = maps.Clone(dst.Annotations)
+	delete(dst.Annotations, hubSpecAnnotation)
+	if len(kept) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(kept)
+	if err != nil {
+		return err
+	}
+	if dst.Annotations == nil {
+		dst.Annotations = map[string]string{}
+	}
+	dst.Annotations[hubSpecAnnotation] = string(data)
+
+	return nil
+}
+
+// restoreHubStrings puts the string fields kept in the hubSpecAnnotation back on the hub spec,
+// unless they have been changed in v1beta1 since
+func restoreHubStrings(dst *miqv1alpha1.ManageIQ) error {
+	data, ok := dst.Annotations[hubSpecAnnotation]
+	if !ok {
+		return nil
+	}
+
+	dst.Annotations = maps.Clone(dst.Annotations)
+	delete(dst.Annotations, hubSpecAnnotation)
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
+	}
+
+	kept := map[string]hubString{}
+	if err := json.Unmarshal([]byte(data), &kept); err != nil {
+		return fmt.Errorf("invalid %s annotation: %v", hubSpecAnnotation, err)
+	}
+
+	spec := reflect.ValueOf(&dst.Spec).Elem()
+	for name, field := range kept {
+		value := spec.FieldByName(name)
+		if value.Kind() == reflect.String && value.String() == field.Converted {
+			value.SetString(field.Value)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package v1beta1
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"sigs.k8s.io/randfill"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+)
+
+// TestManageIQRoundTrip converts randomly filled v1alpha1 CRs to v1beta1 and back, every field of
+// the hub has to survive the conversion
+func TestManageIQRoundTrip(t *testing.T) {
+	filler := randfill.NewWithSeed(1).NilChance(0).NumElements(1, 2)
+	convertible := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 50; i++ {
+		hub := &miqv1alpha1.ManageIQ{}
+		filler.Fill(hub)
+		// The TypeMeta is set by the conversion webhook
+		hub.TypeMeta = metav1.TypeMeta{}
+		fillConvertibleSpec(&hub.Spec, convertible)
+
+		roundTrip := convertRoundTrip(t, hub)
+		if !apiequality.Semantic.DeepEqual(hub, roundTrip) {
+			t.Fatalf("the round trip through v1beta1 changed the CR:\n%s", diff.Diff(hub, roundTrip))
+		}
+	}
+}
+
+// fillConvertibleSpec replaces the random strings of the fields which v1beta1 parses with random
+// values in any of their formats. The resources and volume capacities are quantities, the
+// orchestrator repository is joined from its namespace and name which may contain slashes as well.
+func fillConvertibleSpec(spec *miqv1alpha1.ManageIQSpec, r *rand.Rand) {
+	quantity := func() string {
+		n := r.Intn(8) + 1
+		switch r.Intn(6) {
+		case 0:
+			return fmt.Sprintf("%d000m", n)
+		case 1:
+			return fmt.Sprintf("0.%d", n)
+		case 2:
+			return fmt.Sprintf("%dMi", n*1024)
+		case 3:
+			return fmt.Sprintf("%de3", n)
+		case 4:
+			return fmt.Sprintf("%dGi", n)
+		default:
+			return fmt.Sprintf("%dm", n*100)
+		}
+	}
+
+	value := reflect.ValueOf(spec).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		name := value.Type().Field(i).Name
+		for _, suffix := range []string{"CpuLimit", "CpuRequest", "MemoryLimit", "MemoryRequest", "VolumeCapacity"} {
+			if strings.HasSuffix(name, suffix) {
+				value.Field(i).SetString(quantity())
+			}
+		}
+	}
+
+	repositories := [][2]string{{"", "ns/img"}, {"registry:5000/ns", "img"}, {"", "registry:5000/ns/img"}, {"ns/", "img"}}
+	if repository := repositories[r.Intn(len(repositories))]; r.Intn(2) == 0 {
+		spec.OrchestratorImageNamespace, spec.OrchestratorImageName = repository[0], repository[1]
+	}
+}
+
+func TestManageIQRoundTripStrings(t *testing.T) {
+	tests := []struct {
+		name string
+		spec func(spec *miqv1alpha1.ManageIQSpec)
+	}{
+		{"registry with a port", func(spec *miqv1alpha1.ManageIQSpec) {
+			spec.OrchestratorImageNamespace = "registry:5000/ns"
+			spec.OrchestratorImageName = "img"
+		}},
+		{"repository in the image name", func(spec *miqv1alpha1.ManageIQSpec) {
+			spec.OrchestratorImageName = "registry:5000/ns/img"
+		}},
+		{"memory in a smaller unit", func(spec *miqv1alpha1.ManageIQSpec) {
+			spec.HttpdMemoryLimit = "1024Mi"
+			spec.DatabaseVolumeCapacity = "1048576Ki"
+		}},
+		{"fractional cpu", func(spec *miqv1alpha1.ManageIQSpec) {
+			spec.OrchestratorCpuRequest = "0.5"
+			spec.PostgresqlCpuLimit = "1000m"
+		}},
+		{"exponent", func(spec *miqv1alpha1.ManageIQSpec) {
+			spec.KafkaVolumeCapacity = "1e9"
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hub := &miqv1alpha1.ManageIQ{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"example.com/kept": "true"}}}
+			test.spec(&hub.Spec)
+			original := hub.DeepCopy()
+
+			roundTrip := convertRoundTrip(t, hub)
+			if !apiequality.Semantic.DeepEqual(hub, original) {
+				t.Errorf("the conversion changed the hub CR:\n%s", diff.Diff(original, hub))
+			}
+			if !apiequality.Semantic.DeepEqual(hub, roundTrip) {
+				t.Errorf("the round trip through v1beta1 changed the CR:\n%s", diff.Diff(hub, roundTrip))
+			}
+		})
+	}
+}
+
+// TestManageIQRoundTripChanged checks that a field changed in v1beta1 wins over the string kept for it
+func TestManageIQRoundTripChanged(t *testing.T) {
+	hub := &miqv1alpha1.ManageIQ{}
+	hub.Spec.HttpdMemoryLimit = "1024Mi"
+	hub.Spec.HttpdMemoryRequest = "512Mi"
+
+	spoke := &ManageIQ{}
+	if err := spoke.ConvertFrom(hub); err != nil {
+		t.Fatalf("ConvertFrom() failed: %v", err)
+	}
+	spoke.Spec.Httpd.Resources.Limits[corev1.ResourceMemory] = resource.MustParse("2Gi")
+
+	roundTrip := &miqv1alpha1.ManageIQ{}
+	if err := spoke.ConvertTo(roundTrip); err != nil {
+		t.Fatalf("ConvertTo() failed: %v", err)
+	}
+	if roundTrip.Spec.HttpdMemoryLimit != "2Gi" || roundTrip.Spec.HttpdMemoryRequest != "512Mi" {
+		t.Errorf("httpd memory limit and request = %q and %q, want %q and %q", roundTrip.Spec.HttpdMemoryLimit, roundTrip.Spec.HttpdMemoryRequest, "2Gi", "512Mi")
+	}
+	if _, ok := roundTrip.Annotations[hubSpecAnnotation]; ok {
+		t.Errorf("the %s annotation has been left on the hub CR", hubSpecAnnotation)
+	}
+}
+
+func convertRoundTrip(t *testing.T, hub *miqv1alpha1.ManageIQ) *miqv1alpha1.ManageIQ {
+	spoke := &ManageIQ{}
+	if err := spoke.ConvertFrom(hub); err != nil {
+		t.Fatalf("ConvertFrom() failed: %v", err)
+	}
+	roundTrip := &miqv1alpha1.ManageIQ{}
+	if err := spoke.ConvertTo(roundTrip); err != nil {
+		t.Fatalf("ConvertTo() failed: %v", err)
+	}
+
+	return roundTrip
+}
+
+func TestManageIQRoundTripEmpty(t *testing.T) {
+	hub := &miqv1alpha1.ManageIQ{}
+
+	roundTrip := convertRoundTrip(t, hub)
+	if !apiequality.Semantic.DeepEqual(hub, roundTrip) {
+		t.Errorf("the round trip through v1beta1 changed the empty CR:\n%s", diff.Diff(hub, roundTrip))
+	}
+}
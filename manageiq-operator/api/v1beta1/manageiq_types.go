@@ -0,0 +1,746 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ManageIQSpec defines the desired state of ManageIQ
+type ManageIQSpec struct {
+	// Optional Annotations to apply to the Httpd, Kafka, Memcached, Orchestrator and PostgresQL Pods
+	// +optional
+	AppAnnotations map[string]string `json:"appAnnotations,omitempty"`
+
+	// Domain name for the external route. Used for external authentication configuration
+	ApplicationDomain string `json:"applicationDomain"`
+
+	// Application name used for deployed objects (default: manageiq)
+	// +optional
+	AppName string `json:"appName,omitempty"`
+
+	// This label will be applied to essential resources that need to be backed up (default: manageiq.org/backup)
+	// +optional
+	BackupLabelName string `json:"backupLabelName,omitempty"`
+
+	// Database region number (default: 0)
+	// +optional
+	DatabaseRegion string `json:"databaseRegion,omitempty"`
+
+	// Secret containing the database access information, content generated if not provided (default: <appName>-postgresql-secrets)
+	// +optional
+	DatabaseSecret string `json:"databaseSecret,omitempty"`
+
+	// What happens to the database PVC and the encryption key when the CR is deleted (default: Delete)
+	// Options: Delete, Retain, Snapshot
+	// Note: Snapshot takes a final pg_dump of the database to the <appName>-final-backup PVC before the teardown
+	// +optional
+	// +kubebuilder:validation:Enum=Delete;Retain;Snapshot
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+
+	// What the operator does with manual changes to the objects it manages (default: Correct)
+	// Options: Correct, Report
+	// Note: Both options record the changed fields in the status and as Events, Report leaves the changes in place until the next change of the CR or upgrade of the operator, except on the Secrets which the operator also updates on its own, e.g. when the internal certificates are rotated
+	// +optional
+	// +kubebuilder:validation:Enum=Correct;Report
+	DriftDetection string `json:"driftDetection,omitempty"`
+
+	// Flag to allow logging into the application without SSO (default: true)
+	// +optional
+	EnableApplicationLocalLogin *bool `json:"enableApplicationLocalLogin,omitempty"`
+
+	// Flag to enable SSO in the application (default: false)
+	// +optional
+	EnableSSO *bool `json:"enableSSO,omitempty"`
+
+	// Flag to trigger worker resource constraint enforcement (default: false)
+	// +optional
+	EnforceWorkerResourceConstraints *bool `json:"enforceWorkerResourceConstraints,omitempty"`
+
+	// Secret containing the image registry authentication information needed for the manageiq images
+	// +optional
+	ImagePullSecret string `json:"imagePullSecret,omitempty"`
+
+	// Group name to create with the super admin role.
+	// This can be used to seed a group when using external authentication
+	// +optional
+	InitialAdminGroupName string `json:"initialAdminGroupName,omitempty"`
+
+	// Secret containing all of the necessary certificates to secure communication between pods (default: internal-certificates-secret)
+	// +optional
+	InternalCertificatesSecret string `json:"internalCertificatesSecret,omitempty"`
+
+	// Flag to scale the orchestrator, httpd and memcached deployments down to zero while keeping the database running (default: false)
+	// +optional
+	MaintenanceMode *bool `json:"maintenanceMode,omitempty"`
+
+	// A list of CR data migrations that have been run
+	// +optional
+	MigrationsRan []string `json:"migrationsRan,omitempty"`
+
+	// Server GUID (default: auto-generated)
+	// +optional
+	ServerGuid string `json:"serverGuid,omitempty"`
+
+	// StorageClass name that will be used by manageiq data stores
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+
+	// Secret containing the tls cert and key for the ingress, content generated if not provided (default: <appName>-tls-secret)
+	// +optional
+	TLSSecret string `json:"tlsSecret,omitempty"`
+
+	// Database connection settings
+	// +optional
+	Database DatabaseSpec `json:"database,omitempty"`
+
+	// Httpd component settings
+	// +optional
+	Httpd HttpdSpec `json:"httpd,omitempty"`
+
+	// Kafka component settings
+	// +optional
+	Kafka KafkaSpec `json:"kafka,omitempty"`
+
+	// Memcached component settings
+	// +optional
+	Memcached MemcachedSpec `json:"memcached,omitempty"`
+
+	// Orchestrator and worker settings
+	// +optional
+	Orchestrator OrchestratorSpec `json:"orchestrator,omitempty"`
+
+	// PgBouncer connection pooler settings
+	// +optional
+	Pgbouncer PgbouncerSpec `json:"pgbouncer,omitempty"`
+
+	// PostgreSQL component settings
+	// +optional
+	Postgresql PostgresqlSpec `json:"postgresql,omitempty"`
+
+	// Placement of the pods of the deployments
+	// +optional
+	Scheduling SchedulingSpec `json:"scheduling,omitempty"`
+
+	// Zookeeper component settings
+	// +optional
+	Zookeeper ZookeeperSpec `json:"zookeeper,omitempty"`
+}
+
+// ImageSpec describes the container image used for a component
+type ImageSpec struct {
+	// Full image reference, takes precedence over Repository and Tag
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Image repository, combined with Tag when Image is not set.
+	// For httpd this is the image namespace, the image name is determined by the authentication type.
+	// +optional
+	Repository string `json:"repository,omitempty"`
+
+	// Image tag, combined with Repository when Image is not set
+	// +optional
+	Tag string `json:"tag,omitempty"`
+}
+
+// HttpdSpec defines the settings for the httpd deployment
+type DatabaseSpec struct {
+	// Rotation of the database password
+	// +optional
+	CredentialsRotation DatabaseCredentialsRotationSpec `json:"credentialsRotation,omitempty"`
+
+	// External PostgreSQL database used instead of the in-cluster database
+	// +optional
+	External ExternalDatabaseSpec `json:"external,omitempty"`
+
+	// Scheduled maintenance Jobs run against the database
+	// +optional
+	Maintenance DatabaseMaintenanceSpec `json:"maintenance,omitempty"`
+
+	// VolumeSnapshots of the database PVC
+	// +optional
+	Snapshots DatabaseSnapshotsSpec `json:"snapshots,omitempty"`
+}
+
+type DatabaseMaintenanceSpec struct {
+	// Cron schedule, in UTC, of the REINDEX CONCURRENTLY of the reindexTables, no reindex is run if not provided
+	// +optional
+	ReindexSchedule string `json:"reindexSchedule,omitempty"`
+
+	// Tables reindexed on the reindexSchedule, e.g. metrics or public.vim_performance_states
+	// +optional
+	ReindexTables []string `json:"reindexTables,omitempty"`
+
+	// Cron schedule, in UTC, of the report of the size and the dead tuples of the largest tables, no report is made if not provided
+	// +optional
+	ReportSchedule string `json:"reportSchedule,omitempty"`
+
+	// Cron schedule, in UTC, of the VACUUM ANALYZE of the vacuumTables, no vacuum is run if not provided
+	// +optional
+	VacuumSchedule string `json:"vacuumSchedule,omitempty"`
+
+	// Tables vacuumed on the vacuumSchedule (default: the whole database)
+	// +optional
+	VacuumTables []string `json:"vacuumTables,omitempty"`
+}
+
+type DatabaseCredentialsRotationSpec struct {
+	// Cron schedule, in UTC, of the rotation, the password is only rotated on demand if not provided
+	// Note: the password of an external database is only changed in the databaseSecret, it must then be set on the database
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+}
+
+type DatabaseSnapshotsSpec struct {
+	// VolumeSnapshotClass of the snapshots (default: the default VolumeSnapshotClass of the cluster)
+	// +optional
+	ClassName string `json:"className,omitempty"`
+
+	// Flag to scale down the orchestrator and its workers while a snapshot is taken (default: false)
+	// +optional
+	PauseOrchestrator *bool `json:"pauseOrchestrator,omitempty"`
+
+	// Name of a VolumeSnapshot to restore, the database is moved to a new PVC created from the snapshot
+	// Note: the database is restarted on the restored PVC, the previous PVC is kept; clearing the field keeps the database on the restored PVC, see status.postgresqlClaimName
+	// +optional
+	Restore string `json:"restore,omitempty"`
+
+	// Number of snapshots kept, the oldest ones are removed after each snapshot (default: 7)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Retention *int32 `json:"retention,omitempty"`
+
+	// Cron schedule, in UTC, of the snapshots, no snapshots are taken if not provided
+	// Note: a CHECKPOINT is issued before each snapshot, the snapshots are crash consistent
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+}
+
+type ExternalDatabaseSpec struct {
+	// Secret containing the CA certificate, in its ca.crt key, which signed the certificate of the database
+	// +optional
+	CASecret string `json:"caSecret,omitempty"`
+
+	// kubernetes.io/tls Secret containing the client certificate presented to the database
+	// +optional
+	ClientCertSecret string `json:"clientCertSecret,omitempty"`
+
+	// Hostname of the database, the in-cluster database is not deployed when set
+	// Note: the databaseSecret must exist with the dbname, username and password of the database, the operator sets its hostname, port and sslmode
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// Port of the database (default: 5432)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port *int32 `json:"port,omitempty"`
+
+	// SSL mode used to connect to the database (default: verify-full)
+	// Options: disable, require, verify-ca, verify-full
+	// +optional
+	// +kubebuilder:validation:Enum=disable;require;verify-ca;verify-full
+	SSLMode string `json:"sslMode,omitempty"`
+}
+
+type HttpdSpec struct {
+	// Secret containing the httpd configuration files
+	// Mutually exclusive with the OIDC ClientSecret and ProviderURL if using openid-connect
+	// +optional
+	AuthConfig string `json:"authConfig,omitempty"`
+
+	// Type of httpd authentication (default: internal)
+	// Options: internal, external, active-directory, saml, openid-connect
+	// Note: external, active-directory, and saml require an httpd container with elevated privileges
+	// +optional
+	// +kubebuilder:validation:Pattern=\A(active-directory|external|internal|openid-connect|saml)\z
+	AuthenticationType string `json:"authenticationType,omitempty"`
+
+	// HorizontalPodAutoscaler settings of the httpd deployment, autoscaling is disabled when MaxReplicas is not set
+	// +optional
+	Autoscaling HttpdAutoscalingSpec `json:"autoscaling,omitempty"`
+
+	// Image used for the httpd deployment
+	// (default: <Repository>/httpd[-init]:<Tag>)
+	// +optional
+	Image ImageSpec `json:"image,omitempty"`
+
+	// OpenID Connect settings, only used with the openid-connect authentication type
+	// +optional
+	OIDC OIDCSpec `json:"oidc,omitempty"`
+
+	// Number of httpd pods, the minimum number of pods with autoscaling (default: 1)
+	// Note: the pods of the external, active-directory and saml authentication types are replaced all at once on updates
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Httpd deployment resource requests and limits (default: none)
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Nodes, tolerations and priority of the httpd pods, in addition to the architecture of the operator
+	// +optional
+	Scheduling PodSchedulingSpec `json:"scheduling,omitempty"`
+}
+
+// HttpdAutoscalingSpec defines the HorizontalPodAutoscaler of the httpd deployment
+type HttpdAutoscalingSpec struct {
+	// Maximum number of httpd pods
+	// Note: requires a CPU request in the httpd resources
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+
+	// Average CPU utilization of the httpd pods, as a percentage of their CPU request (default: 75)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TargetCPUUtilization *int32 `json:"targetCPUUtilization,omitempty"`
+}
+
+// OIDCSpec defines the OpenID Connect settings for httpd
+type OIDCSpec struct {
+	// Secret containing the trusted CA certificate file(s) for the OIDC server
+	// +optional
+	CACertSecret string `json:"caCertSecret,omitempty"`
+
+	// Secret name containing the OIDC client id and secret
+	// +optional
+	ClientSecret string `json:"clientSecret,omitempty"`
+
+	// URL for OIDC authentication introspection
+	// If not specified, the operator will attempt to fetch its value from the
+	// "introspection_endpoint" field in the Provider metadata at the
+	// ProviderURL provided.
+	// +optional
+	IntrospectionURL string `json:"introspectionURL,omitempty"`
+
+	// Enable or disable SSL verification for OIDC authentication introspection (default: true)
+	// +optional
+	IntrospectionSSLVerify *bool `json:"introspectionSSLVerify,omitempty"`
+
+	// URL for the OIDC provider
+	// +optional
+	ProviderURL string `json:"providerURL,omitempty"`
+}
+
+// KafkaSpec defines the settings for the kafka cluster
+type KafkaSpec struct {
+	// Deprecated: Flag to indicate if Kafka and Zookeeper should be deployed (default: true)
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Deprecated: Image used for the kafka deployment
+	// +optional
+	Image ImageSpec `json:"image,omitempty"`
+
+	// Kafka resource requests and limits (default: none)
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Nodes, tolerations and priority of the kafka, zookeeper and entity operator pods, in addition to the architecture of the operator
+	// +optional
+	Scheduling PodSchedulingSpec `json:"scheduling,omitempty"`
+
+	// Secret containing the kafka access information, content generated if not provided (default: kafka-secrets)
+	// +optional
+	Secret string `json:"secret,omitempty"`
+
+	// Kafka volume size (default: 2Gi)
+	// +optional
+	VolumeCapacity *resource.Quantity `json:"volumeCapacity,omitempty"`
+}
+
+// MemcachedSpec defines the settings for the memcached deployment
+type MemcachedSpec struct {
+	// Image used for the memcached deployment
+	// (default: manageiq/memcached:<tag>)
+	// +optional
+	Image ImageSpec `json:"image,omitempty"`
+
+	// Memcached max simultaneous connections (default: 1024)
+	// +optional
+	MaxConnection string `json:"maxConnection,omitempty"`
+
+	// Memcached item memory in megabytes (default: 64)
+	// +optional
+	MaxMemory string `json:"maxMemory,omitempty"`
+
+	// Memcached deployment resource requests and limits (default: none)
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Nodes, tolerations and priority of the memcached pods, in addition to the architecture of the operator
+	// +optional
+	Scheduling PodSchedulingSpec `json:"scheduling,omitempty"`
+
+	// Memcached max item size (default: 1m, min: 1k, max: 1024m)
+	// +optional
+	SlabPageSize string `json:"slabPageSize,omitempty"`
+}
+
+// OrchestratorSpec defines the settings for the orchestrator deployment and the workers it manages
+type OrchestratorSpec struct {
+	// Image string used for the base worker deployments
+	// By default this is determined by the orchestrator pod
+	// +optional
+	BaseWorkerImage string `json:"baseWorkerImage,omitempty"`
+
+	// Image used for the orchestrator deployment
+	// (default: manageiq/manageiq-orchestrator:<tag>)
+	// +optional
+	Image ImageSpec `json:"image,omitempty"`
+
+	// Number of seconds to wait before starting the orchestrator liveness check (default: 480)
+	// +optional
+	InitialDelay string `json:"initialDelay,omitempty"`
+
+	// Image string used for the Opentofu runner worker deployments
+	// By default this is determined by the orchestrator pod
+	// +optional
+	OpentofuRunnerImage string `json:"opentofuRunnerImage,omitempty"`
+
+	// Orchestrator deployment resource requests and limits (default: none)
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Nodes, tolerations and priority of the orchestrator pods, in addition to the architecture of the operator
+	// +optional
+	Scheduling PodSchedulingSpec `json:"scheduling,omitempty"`
+
+	// Image string used for the UI worker deployments
+	// By default this is determined by the orchestrator pod
+	// +optional
+	UIWorkerImage string `json:"uiWorkerImage,omitempty"`
+
+	// Image string used for the webserver worker deployments
+	// By default this is determined by the orchestrator pod
+	// +optional
+	WebserverWorkerImage string `json:"webserverWorkerImage,omitempty"`
+}
+
+// PgbouncerSpec defines the settings for the pgbouncer deployment
+type PgbouncerSpec struct {
+	// Number of database connections opened per user and database (default: 20)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	DefaultPoolSize *int32 `json:"defaultPoolSize,omitempty"`
+
+	// Flag to deploy a PgBouncer connection pooler between the orchestrator and its workers and the database (default: false)
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Image string used for the pgbouncer deployment (default: ghcr.io/cloudnative-pg/pgbouncer:1.23.0)
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Number of client connections accepted from the orchestrator and its workers (default: 1000)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxClientConnections *int32 `json:"maxClientConnections,omitempty"`
+
+	// Number of connections opened to the database across all of the pools (default: 0, no limit)
+	// Note: keep it below the maxConnections of the database to leave connections to the backups and maintenance Jobs
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxDatabaseConnections *int32 `json:"maxDatabaseConnections,omitempty"`
+
+	// When a database connection is returned to the pool (default: session)
+	// Options: session, transaction
+	// Note: transaction shares the database connections between the clients between transactions, session keeps them for the lifetime of the client connection
+	// +optional
+	// +kubebuilder:validation:Enum=session;transaction
+	PoolMode string `json:"poolMode,omitempty"`
+
+	// PgBouncer deployment resource requests and limits (default: none)
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Nodes, tolerations and priority of the pgbouncer pods, in addition to the architecture of the operator
+	// +optional
+	Scheduling PodSchedulingSpec `json:"scheduling,omitempty"`
+}
+
+// PostgresqlSpec defines the settings for the postgresql deployment
+type PostgresqlSpec struct {
+	// Flag to let a node drain evict the database pod of the standalone mode (default: false)
+	// Note: by default its PodDisruptionBudget blocks the drain until the pod is deleted
+	// +optional
+	AllowEviction *bool `json:"allowEviction,omitempty"`
+
+	// Derive shared_buffers, effective_cache_size, work_mem and maintenance_work_mem from the memory limit and the CPU limit or request (default: false)
+	// Note: sharedBuffers is ignored when enabled, the derived parameters are reported in the status and can be overridden by parameters
+	// +optional
+	AutoTune *bool `json:"autoTune,omitempty"`
+
+	// Name of a ConfigMap with additional postgresql.conf parameters in its postgresql.conf key and pg_hba.conf rules in its pg_hba.conf key
+	// Note: the pg_hba.conf rules are matched before the default ones, parameters takes precedence over the parameters of the ConfigMap
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// Image used for the postgresql deployment
+	// (default: docker.io/manageiq/postgresql:<tag>)
+	// +optional
+	Image ImageSpec `json:"image,omitempty"`
+
+	// PostgreSQL maximum connection setting (default: 1000)
+	// +optional
+	MaxConnections string `json:"maxConnections,omitempty"`
+
+	// Deployment mode (default: standalone)
+	// Options: standalone, replicated
+	// Note: replicated runs a StatefulSet with a primary and hot standbys fed by streaming replication, the standbys are served by the <appName>-postgresql-readonly Service
+	// +optional
+	// +kubebuilder:validation:Enum=standalone;replicated
+	Mode string `json:"mode,omitempty"`
+
+	// Additional postgresql.conf parameters, merged over the defaults of the operator
+	// Note: changing a parameter which requires a restart restarts the database, the other parameters are reloaded
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// Ordinal of the StatefulSet pod running the primary in replicated mode (default: 0)
+	// Note: changing it promotes that standby, the database pods are restarted and the former primary is cloned again as a standby
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Primary *int32 `json:"primary,omitempty"`
+
+	// Number of database pods in replicated mode, including the primary (default: 2)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// PostgreSQL deployment resource requests and limits (default: none)
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Nodes, tolerations and priority of the postgresql pods and of the Jobs on its volume, in addition to the architecture of the operator
+	// +optional
+	Scheduling PodSchedulingSpec `json:"scheduling,omitempty"`
+
+	// PostgreSQL shared buffers setting (default: 1GB)
+	// +optional
+	SharedBuffers string `json:"sharedBuffers,omitempty"`
+
+	// Database volume size (default: 15Gi)
+	// +optional
+	VolumeCapacity *resource.Quantity `json:"volumeCapacity,omitempty"`
+
+	// Name of a ManageIQBackup with the BaseBackup method whose storage also receives the WAL of the database, to restore it to a point in time
+	// Note: only supported in the standalone mode
+	// +optional
+	WalArchiveBackup string `json:"walArchiveBackup,omitempty"`
+}
+
+// PodSchedulingSpec defines where the pods of a component are scheduled
+type PodSchedulingSpec struct {
+	// Node affinity, pod affinity and pod anti-affinity of the pods
+	// Note: a node has to match one of the required node selector terms and the architectures of the operator, the schema is left out of the CRD for its size
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// Labels of the nodes the pods are restricted to
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// PriorityClass of the pods
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// Tolerations of the pods, e.g. of the taints of dedicated nodes
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// SchedulingSpec defines how the pods of the httpd, memcached, orchestrator, pgbouncer and postgresql deployments are spread
+type SchedulingSpec struct {
+	// Keeps the pods of a deployment on different nodes (default: none)
+	// Options: preferred, required
+	// Note: required leaves the pods which do not fit on the nodes pending, a rolling update of httpd needs one more node than its replicas
+	// +optional
+	// +kubebuilder:validation:Enum=preferred;required
+	PodAntiAffinity string `json:"podAntiAffinity,omitempty"`
+
+	// Topology spread constraints of the pods
+	// Note: a constraint without a labelSelector spreads the pods of each deployment
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+}
+
+// ZookeeperSpec defines the settings for the zookeeper nodes of the kafka cluster
+type ZookeeperSpec struct {
+	// Deprecated: Image used for the zookeeper deployment
+	// +optional
+	Image ImageSpec `json:"image,omitempty"`
+
+	// Zookeeper resource requests and limits (default: none)
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Zookeeper volume size (default: 1Gi)
+	// +optional
+	VolumeCapacity *resource.Quantity `json:"volumeCapacity,omitempty"`
+}
+
+// SecretSource is a reference to a secret containing a hidden value
+type SecretSource struct {
+	// The name of the secret containing the value
+	SecretName string `json:"secretName,omitempty"`
+	// The key for the value in the secret
+	Key string `json:"key,omitempty"`
+}
+
+type Endpoint struct {
+	Name     string       `json:"name,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Scope    string       `json:"scope,omitempty"`
+	URI      string       `json:"uri,omitempty"`
+	CASecret SecretSource `json:"caSecret,omitempty"`
+}
+
+type Version struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// DatabaseMaintenanceRun is the outcome of the last run of a database maintenance CronJob
+type DatabaseMaintenanceRun struct {
+	// Maintenance task: reindex, report or vacuum
+	Task string `json:"task"`
+
+	// Last time a Job of the task was scheduled
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// Last time a Job of the task succeeded
+	// +optional
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+
+	// Result of the most recent Job: Running, Succeeded or Failed
+	// +optional
+	LastResult string `json:"lastResult,omitempty"`
+
+	// Report of the most recent Job, or its error
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// DatabaseSnapshot is a VolumeSnapshot of the database PVC taken on the database.snapshots.schedule
+type DatabaseSnapshot struct {
+	Name string `json:"name"`
+
+	// Time at which the snapshot was cut by the storage provider
+	// +optional
+	CreationTime *metav1.Time `json:"creationTime,omitempty"`
+
+	// Whether the snapshot can be restored
+	ReadyToUse bool `json:"readyToUse"`
+
+	// Minimum size of a PVC restored from the snapshot
+	// +optional
+	RestoreSize string `json:"restoreSize,omitempty"`
+}
+
+// DriftedObject records the fields of a managed object which no longer matched the desired state
+type DriftedObject struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+
+	// The paths of the fields which differed from the desired state
+	Fields []string `json:"fields,omitempty"`
+
+	// Whether the operator reverted the object to the desired state
+	Corrected bool `json:"corrected"`
+
+	LastDetectedTime metav1.Time `json:"lastDetectedTime,omitempty"`
+}
+
+// ManageIQStatus defines the observed state of ManageIQ
+type ManageIQStatus struct {
+	Versions  []Version  `json:"versions,omitempty"`
+	Endpoints []Endpoint `json:"endpoints,omitempty"`
+
+	// The most recent generation of the ManageIQ spec reconciled successfully by the operator
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Optional components deployed by the operator, their resources are pruned when they get disabled.
+	// An empty list when none is deployed, all the disabled components are pruned while it is unset.
+	// +optional
+	Components []string `json:"components"`
+
+	// Managed objects which were changed outside of the operator
+	// +optional
+	Drift []DriftedObject `json:"drift,omitempty"`
+
+	// PVC holding the data of the standalone database, e.g. the one restored from DatabaseSnapshotRestore
+	// +optional
+	PostgresqlClaimName string `json:"postgresqlClaimName,omitempty"`
+
+	// PostgreSQL parameters derived from the resource limits when postgresql.autoTune is enabled
+	// +optional
+	PostgresqlTuning map[string]string `json:"postgresqlTuning,omitempty"`
+
+	// VolumeSnapshots of the database PVC, the most recent first
+	// +optional
+	DatabaseSnapshots []DatabaseSnapshot `json:"databaseSnapshots,omitempty"`
+
+	// Last runs of the database maintenance CronJobs
+	// +optional
+	DatabaseMaintenance []DatabaseMaintenanceRun `json:"databaseMaintenance,omitempty"`
+
+	// Time of the last rotation of the database password
+	// +optional
+	DatabaseCredentialsRotationTime *metav1.Time `json:"databaseCredentialsRotationTime,omitempty"`
+
+	// Value of the rotate-database-credentials annotation of the last rotation
+	// +optional
+	DatabaseCredentialsRotationRequest string `json:"databaseCredentialsRotationRequest,omitempty"`
+
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// ManageIQ is the Schema for the manageiqs API
+type ManageIQ struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ManageIQSpec   `json:"spec,omitempty"`
+	Status ManageIQStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ManageIQList contains a list of ManageIQ
+type ManageIQList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ManageIQ `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ManageIQ{}, &ManageIQList{})
+}
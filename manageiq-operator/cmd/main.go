@@ -22,18 +22,21 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	routev1 "github.com/openshift/api/route/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	ctrlcache "sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -42,12 +45,17 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	manageiqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	manageiqv1beta1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1beta1"
 	"github.com/ManageIQ/manageiq-pods/manageiq-operator/internal/controller"
+	webhookv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/internal/webhook/v1alpha1"
 	olmv1 "github.com/operator-framework/api/pkg/operators/v1"
 	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
 
+// operatorBuildManifestPath is written by the Dockerfile with the build time and the commit of the operator
+const operatorBuildManifestPath = "/opt/manageiq/manifest"
+
 var (
 	log      = logf.Log.WithName("cmd")
 	scheme   = runtime.NewScheme()
@@ -58,6 +66,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(manageiqv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(manageiqv1beta1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 
 	utilruntime.Must(olmv1alpha1.SchemeBuilder.AddToScheme(scheme))
@@ -81,6 +90,14 @@ func getWatchNamespace() (string, error) {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		if err := render(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var metricsAddr string
 	var metricsCertPath, metricsCertName, metricsCertKey string
 	var webhookCertPath, webhookCertName, webhookCertKey string
@@ -215,6 +232,10 @@ func main() {
 		Cache: ctrlcache.Options{
 			DefaultNamespaces: namespaces,
 		},
+		// StorageClasses are cluster scoped, they are only read when a volume is grown
+		Client: client.Options{
+			Cache: &client.CacheOptions{DisableFor: []client.Object{&storagev1.StorageClass{}}},
+		},
 		Scheme:                 scheme,
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
@@ -246,16 +267,47 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The build manifest of the operator image, it is missing when running outside of the image
+	operatorBuild, err := os.ReadFile(operatorBuildManifestPath)
+	if err != nil && !os.IsNotExist(err) {
+		setupLog.Error(err, "unable to read the operator build manifest")
+		os.Exit(1)
+	}
+
 	if err = (&controllers.ManageIQReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 		// Add a Recorder to the reconciler.
 		// This allows the operator author to emit events during reconcilliation.
-		Recorder: mgr.GetEventRecorderFor("manageiq-controller"),
+		Recorder:      mgr.GetEventRecorderFor("manageiq-controller"),
+		OperatorBuild: strings.TrimSpace(string(operatorBuild)),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ManageIQ")
 		os.Exit(1)
 	}
+	if err = (&controllers.ManageIQBackupReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("manageiqbackup-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ManageIQBackup")
+		os.Exit(1)
+	}
+	if err = (&controllers.ManageIQRestoreReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("manageiqrestore-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ManageIQRestore")
+		os.Exit(1)
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhookv1alpha1.SetupManageIQWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ManageIQ")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	log.Info("Registering Components.")
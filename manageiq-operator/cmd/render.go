@@ -0,0 +1,370 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/yaml"
+
+	manageiqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	manageiqv1beta1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1beta1"
+	"github.com/ManageIQ/manageiq-pods/manageiq-operator/internal/controller"
+)
+
+const renderUsage = `Usage: manageiq-operator render [flags] <manageiq.yaml>
+
+Prints the manifests the operator would create for the ManageIQ CR in the given file
+as a multi-document YAML stream, without contacting a cluster.
+
+Flags:
+`
+
+// render runs a single reconcile of the CR read from the arguments against a fake client and
+// writes every object the operator created or updated to stdout
+func render(args []string) error {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	var namespace, secretsFile string
+	var openshift bool
+	fs.StringVar(&namespace, "namespace", "", "The namespace to render the manifests for (default: the namespace of the CR, or \"default\")")
+	fs.StringVar(&secretsFile, "secrets", "", "A YAML file with existing Secrets, e.g. app-secrets or postgresql-secrets, used instead of generating new ones")
+	fs.BoolVar(&openshift, "openshift", true, "Render a Route for the application, use --openshift=false to render an Ingress instead")
+	opts := zap.Options{}
+	opts.BindFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), renderUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts), zap.WriteTo(os.Stderr)))
+
+	// The pods of the operator itself are not part of the rendered manifests
+	if err := os.Unsetenv("POD_NAME"); err != nil {
+		return err
+	}
+
+	cr, err := readManageIQ(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if namespace != "" {
+		cr.Namespace = namespace
+	}
+	if cr.Namespace == "" {
+		cr.Namespace = "default"
+	}
+	if cr.UID == "" {
+		cr.UID = types.UID(cr.Namespace + "-" + cr.Name)
+	}
+	if cr.Spec.ServerGuid == "" {
+		fmt.Fprintln(os.Stderr, "WARNING: spec.serverGuid is not set, the rendered orchestrator will get a placeholder GUID")
+	}
+	delete(cr.Annotations, "manageiq.org/pause-reconciliation")
+
+	objects := []client.Object{cr}
+	if secretsFile != "" {
+		secrets, err := readSecrets(secretsFile, cr.Namespace)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, secrets...)
+	}
+
+	// The seeded objects are managed outside of the operator and are left out of the output
+	rendered := &renderedObjects{}
+	for _, obj := range objects {
+		rendered.skip(obj)
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&manageiqv1alpha1.ManageIQ{}).
+		WithObjects(objects...).
+		WithInterceptorFuncs(rendered.interceptorFuncs(openshift)).
+		Build()
+
+	recorder := record.NewFakeRecorder(100)
+	go func() {
+		for event := range recorder.Events {
+			fmt.Fprintln(os.Stderr, "Event:", event)
+		}
+	}()
+
+	reconciler := &controllers.ManageIQReconciler{Client: c, Scheme: scheme, Recorder: recorder}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}}
+	if _, err := reconciler.Reconcile(context.TODO(), request); err != nil {
+		return fmt.Errorf("failed to render %s: %w", cr.Name, err)
+	}
+
+	return rendered.write(os.Stdout, c)
+}
+
+// readManageIQ decodes a ManageIQ CR of any served version and converts it to the storage version
+func readManageIQ(path string) (*manageiqv1alpha1.ManageIQ, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, _, err := serializer.NewCodecFactory(scheme).UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	switch cr := obj.(type) {
+	case *manageiqv1alpha1.ManageIQ:
+		return cr, nil
+	case *manageiqv1beta1.ManageIQ:
+		hub := &manageiqv1alpha1.ManageIQ{}
+		if err := cr.ConvertTo(hub); err != nil {
+			return nil, err
+		}
+		return hub, nil
+	default:
+		return nil, fmt.Errorf("%s does not contain a ManageIQ CR", path)
+	}
+}
+
+// readSecrets decodes the Secrets from a multi-document YAML file
+func readSecrets(path string, namespace string) ([]client.Object, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(f))
+	secrets := []client.Object{}
+	for {
+		data, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return secrets, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+
+		obj, _, err := decoder.Decode(data, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		secret, ok := obj.(*corev1.Secret)
+		if !ok {
+			return nil, fmt.Errorf("%s must only contain Secrets, found %s", path, obj.GetObjectKind().GroupVersionKind().Kind)
+		}
+		secret.Namespace = namespace
+		mergeStringData(secret)
+		secrets = append(secrets, secret)
+	}
+}
+
+// mergeStringData moves the stringData of a Secret into its data like the API server does on write
+func mergeStringData(secret *corev1.Secret) {
+	if len(secret.StringData) == 0 {
+		return
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for key, value := range secret.StringData {
+		secret.Data[key] = []byte(value)
+	}
+	secret.StringData = nil
+}
+
+// normalizeUnstructured round-trips the content of an unstructured object through JSON like the
+// API server does on write, the builders set typed slices the fake client cannot deep copy
+func normalizeUnstructured(obj client.Object) error {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+
+	data, err := json.Marshal(u.Object)
+	if err != nil {
+		return err
+	}
+	content := map[string]interface{}{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		return err
+	}
+	u.Object = content
+	return nil
+}
+
+// renderedObjects keeps track of the objects written during the reconcile in the order they were
+// first written
+type renderedObjects struct {
+	objects []client.Object
+	seen    map[renderedKey]bool
+}
+
+type renderedKey struct {
+	gvk schema.GroupVersionKind
+	key client.ObjectKey
+}
+
+func (r *renderedObjects) add(obj client.Object) {
+	if r.markSeen(obj) {
+		r.objects = append(r.objects, obj.DeepCopyObject().(client.Object))
+	}
+}
+
+func (r *renderedObjects) skip(obj client.Object) {
+	r.markSeen(obj)
+}
+
+// markSeen returns whether obj was written for the first time
+func (r *renderedObjects) markSeen(obj client.Object) bool {
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return false
+	}
+	key := renderedKey{gvk: gvk, key: client.ObjectKeyFromObject(obj)}
+	if r.seen == nil {
+		r.seen = map[renderedKey]bool{}
+	}
+	if r.seen[key] {
+		return false
+	}
+
+	r.seen[key] = true
+	return true
+}
+
+// interceptorFuncs records the objects written to the fake client. Without openshift the Route
+// API is reported as missing from the cluster so that the operator falls back to an Ingress.
+func (r *renderedObjects) interceptorFuncs(openshift bool) interceptor.Funcs {
+	noRoutes := func(obj runtime.Object) error {
+		if openshift {
+			return nil
+		}
+		switch obj.(type) {
+		case *routev1.Route, *routev1.RouteList:
+			return &apimeta.NoKindMatchError{GroupKind: routev1.GroupVersion.WithKind("Route").GroupKind(), SearchedVersions: []string{routev1.GroupVersion.Version}}
+		}
+		return nil
+	}
+
+	return interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if err := noRoutes(obj); err != nil {
+				return err
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if err := noRoutes(list); err != nil {
+				return err
+			}
+			return c.List(ctx, list, opts...)
+		},
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if err := noRoutes(obj); err != nil {
+				return err
+			}
+			if secret, ok := obj.(*corev1.Secret); ok {
+				mergeStringData(secret)
+			}
+			if err := normalizeUnstructured(obj); err != nil {
+				return err
+			}
+			if err := c.Create(ctx, obj, opts...); err != nil {
+				return err
+			}
+			r.add(obj)
+			return nil
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if secret, ok := obj.(*corev1.Secret); ok {
+				mergeStringData(secret)
+			}
+			if err := normalizeUnstructured(obj); err != nil {
+				return err
+			}
+			if err := c.Update(ctx, obj, opts...); err != nil {
+				return err
+			}
+			r.add(obj)
+			return nil
+		},
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if err := c.Patch(ctx, obj, patch, opts...); err != nil {
+				return err
+			}
+			r.add(obj)
+			return nil
+		},
+	}
+}
+
+// write prints the final state of the recorded objects, stripped of the fields set by the cluster
+func (r *renderedObjects) write(w io.Writer, c client.Client) error {
+	for _, obj := range r.objects {
+		// Objects pruned later in the reconcile are not part of the result
+		if err := c.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj); apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		gvk, err := apiutil.GVKForObject(obj, scheme)
+		if err != nil {
+			return err
+		}
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return err
+		}
+		delete(content, "status")
+		if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+			for _, field := range []string{"creationTimestamp", "generation", "managedFields", "ownerReferences", "resourceVersion", "uid"} {
+				delete(metadata, field)
+			}
+		}
+
+		data, err := yaml.Marshal(content)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	manageiqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	"github.com/ManageIQ/manageiq-pods/manageiq-operator/internal/controller"
+)
+
+// TestRenderSharedNamespace renders two CRs in one namespace, none of the objects written for the
+// second CR may have been written for the first one
+func TestRenderSharedNamespace(t *testing.T) {
+	t.Setenv("POD_NAME", "")
+
+	enabled := true
+	var replicas, maxReplicas int32 = 1, 3
+
+	tests := []struct {
+		name    string
+		spec    func(spec *manageiqv1alpha1.ManageIQSpec)
+		objects []client.Object
+	}{
+		{
+			name: "default components",
+			spec: func(spec *manageiqv1alpha1.ManageIQSpec) {},
+		},
+		{
+			name: "optional components",
+			spec: func(spec *manageiqv1alpha1.ManageIQSpec) {
+				spec.DatabaseMaintenanceVacuumSchedule = "0 3 * * *"
+				spec.DatabaseSnapshotSchedule = "0 2 * * *"
+				spec.DeployMessagingService = &enabled
+				spec.DeployPgbouncer = &enabled
+				spec.HttpdAuthenticationType = "saml"
+				spec.HttpdAutoscaleMaxReplicas = &maxReplicas
+				spec.HttpdCpuRequest = "250m"
+				spec.PostgresqlMode = manageiqv1alpha1.PostgresqlModeReplicated
+				spec.PostgresqlReplicas = &replicas
+			},
+			// The Strimzi operator is installed from the catalog on OpenShift
+			objects: []client.Object{&olmv1alpha1.CatalogSource{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-marketplace", Name: "community-operators"}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objects := test.objects
+			crs := []*manageiqv1alpha1.ManageIQ{}
+			for _, name := range []string{"alpha", "beta"} {
+				cr := &manageiqv1alpha1.ManageIQ{ObjectMeta: metav1.ObjectMeta{Namespace: "manageiq", Name: name, UID: types.UID(name)}}
+				cr.Spec.AppName = name
+				cr.Spec.ApplicationDomain = name + ".example.com"
+				cr.Spec.ServerGuid = name
+				test.spec(&cr.Spec)
+				crs = append(crs, cr)
+				objects = append(objects, cr)
+			}
+
+			rendered := &renderedObjects{}
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithStatusSubresource(&manageiqv1alpha1.ManageIQ{}).
+				WithObjects(objects...).
+				WithInterceptorFuncs(rendered.interceptorFuncs(true)).
+				Build()
+			reconciler := &controllers.ManageIQReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(1000)}
+
+			owners := map[renderedKey]string{}
+			for _, cr := range crs {
+				*rendered = renderedObjects{}
+
+				request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}}
+				if _, err := reconciler.Reconcile(context.TODO(), request); err != nil {
+					t.Fatalf("failed to render %s: %v", cr.Name, err)
+				}
+				if len(rendered.objects) == 0 {
+					t.Fatalf("no objects were rendered for %s", cr.Name)
+				}
+
+				for key := range rendered.seen {
+					if owner, ok := owners[key]; ok {
+						t.Errorf("%s %s is written for both %s and %s", key.gvk.Kind, key.key.Name, owner, cr.Name)
+					}
+					owners[key] = cr.Name
+				}
+			}
+		})
+	}
+}
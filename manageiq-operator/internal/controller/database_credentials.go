@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqtool "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/miq-components"
+)
+
+// rotateDatabaseCredentials rotates the database password on the DatabaseCredentialsRotationSchedule
+// or when the rotate-database-credentials annotation changes. The new password is set on the
+// database deployed by the operator before it is copied to the database secret, the orchestrator
+// is then rolled. The password of an external database is only changed in the database secret,
+// the orchestrator waits for a restart once the password has been set on the database.
+func (r *ManageIQReconciler) rotateDatabaseCredentials(cr *miqv1alpha1.ManageIQ) error {
+	condition := apimeta.FindStatusCondition(cr.Status.Conditions, miqtool.DatabaseCredentialsRotatingCondition)
+	if condition != nil && condition.Reason == "RestartRequired" {
+		return r.checkOrchestratorRestarted(cr)
+	}
+
+	if !miqtool.DatabaseCredentialsRotating(cr) {
+		if next := r.nextDatabaseCredentialsRotation(cr); !miqtool.DatabaseCredentialsRotationRequested(cr) && (next.IsZero() || time.Now().Before(next)) {
+			return nil
+		}
+		if miqtool.Quiesced(cr) || miqtool.PostgresqlUpgrading(cr) {
+			logger.Info("Waiting for the restore or the upgrade before rotating the database password", "component", "postgresql")
+			return nil
+		}
+
+		cr.Status.DatabaseCredentialsRotationRequest = cr.Annotations[miqv1alpha1.RotateDatabaseCredentialsAnnotation]
+		logger.Info("Database password rotation has started", "component", "postgresql")
+		r.reportStatusCondition(cr, "Rotating the database password", "Rotating", metav1.ConditionTrue, miqtool.DatabaseCredentialsRotatingCondition)
+	}
+
+	pending, mutateFunc := miqtool.DatabaseCredentialsRotationSecret(cr, r.Scheme)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, pending, mutateFunc); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("Secret has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, pending, result)
+	}
+
+	if miqtool.ExternalDatabase(cr) {
+		if err := r.updateDatabasePassword(cr, pending); err != nil {
+			return err
+		}
+		if err := r.Client.Delete(context.TODO(), pending); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+
+		username := string(FindSecret(cr, r.Client, cr.Spec.DatabaseSecret).Data["username"])
+		message := fmt.Sprintf("The password of Secret %s has been rotated, set it on role %s of the external database %s and restart the orchestrator", cr.Spec.DatabaseSecret, username, cr.Spec.ExternalDatabaseHost)
+		r.Recorder.Event(cr, corev1.EventTypeWarning, "DatabaseRestartRequired", message)
+		r.reportStatusCondition(cr, message, "RestartRequired", metav1.ConditionTrue, miqtool.DatabaseCredentialsRotatingCondition)
+		return nil
+	}
+
+	job, mutateFunc := miqtool.DatabaseCredentialsRotationJob(cr, r.Client, r.Scheme)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, job, mutateFunc); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("Job has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, job, result)
+	}
+
+	switch {
+	case job.Status.Succeeded > 0:
+	case jobFailed(job):
+		if err := r.deleteJobs(job); err != nil {
+			return err
+		}
+		if err := r.Client.Delete(context.TODO(), pending); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+
+		message := fmt.Sprintf("Job %s setting the new database password failed, the password has not been changed", job.Name)
+		r.Recorder.Event(cr, corev1.EventTypeWarning, "DatabaseCredentialsRotationFailed", message)
+		r.reportStatusCondition(cr, message, "Failed", metav1.ConditionFalse, miqtool.DatabaseCredentialsRotatingCondition)
+		return fmt.Errorf("%s", message)
+	default:
+		r.reportStatusCondition(cr, fmt.Sprintf("Waiting for Job %s setting the new database password", job.Name), "Rotating", metav1.ConditionTrue, miqtool.DatabaseCredentialsRotatingCondition)
+		return nil
+	}
+
+	// The Job is removed before the pending secret, a new Job would otherwise set another password
+	if err := r.updateDatabasePassword(cr, pending); err != nil {
+		return err
+	}
+	if err := r.deleteJobs(job); err != nil {
+		return err
+	}
+	if err := r.Client.Delete(context.TODO(), pending); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+
+	r.reportStatusCondition(cr, "The database password has been rotated, the orchestrator and its workers are restarted", "Rotated", metav1.ConditionFalse, miqtool.DatabaseCredentialsRotatingCondition)
+	return nil
+}
+
+// updateDatabasePassword copies the pending password to the database secret and records the time
+// of the rotation, which rolls the orchestrator of the database deployed by the operator
+func (r *ManageIQReconciler) updateDatabasePassword(cr *miqv1alpha1.ManageIQ, pending *corev1.Secret) error {
+	secret := FindSecret(cr, r.Client, cr.Spec.DatabaseSecret)
+	if secret == nil {
+		return fmt.Errorf("Secret %s does not exist, the database password can not be rotated", cr.Spec.DatabaseSecret)
+	}
+
+	if string(secret.Data["password"]) != string(pending.Data["password"]) {
+		patch := client.MergeFrom(secret.DeepCopy())
+		secret.Data["password"] = pending.Data["password"]
+		if err := r.Client.Patch(context.TODO(), secret, patch); err != nil {
+			return err
+		}
+
+		logger.Info("Database password has been rotated", "component", "postgresql", "secret", secret.Name)
+		r.Recorder.Eventf(cr, corev1.EventTypeNormal, "DatabaseCredentialsRotated", "The password of Secret %s has been rotated", secret.Name)
+	}
+
+	now := metav1.Now()
+	cr.Status.DatabaseCredentialsRotationTime = &now
+
+	return nil
+}
+
+// checkOrchestratorRestarted ends the rotation of the password of an external database once all
+// the orchestrator pods have been started after it
+func (r *ManageIQReconciler) checkOrchestratorRestarted(cr *miqv1alpha1.ManageIQ) error {
+	pods := &corev1.PodList{}
+	if err := r.Client.List(context.TODO(), pods, client.InNamespace(cr.Namespace), client.MatchingLabels{"app": cr.Spec.AppName, "name": "orchestrator"}); err != nil {
+		return err
+	}
+	if len(pods.Items) == 0 || cr.Status.DatabaseCredentialsRotationTime == nil {
+		return nil
+	}
+	for _, pod := range pods.Items {
+		if pod.CreationTimestamp.Before(cr.Status.DatabaseCredentialsRotationTime) {
+			return nil
+		}
+	}
+
+	logger.Info("Orchestrator has been restarted with the rotated database password", "component", "postgresql")
+	r.reportStatusCondition(cr, "The orchestrator has been restarted with the rotated database password", "Rotated", metav1.ConditionFalse, miqtool.DatabaseCredentialsRotatingCondition)
+	return nil
+}
+
+// nextDatabaseCredentialsRotation returns the time of the next scheduled rotation, counted from the
+// end of the previous one or from the creation of the CR, or the zero time when no rotation is
+// scheduled or one is in progress
+func (r *ManageIQReconciler) nextDatabaseCredentialsRotation(cr *miqv1alpha1.ManageIQ) time.Time {
+	if miqtool.DatabaseCredentialsRotating(cr) {
+		return time.Time{}
+	}
+
+	last := cr.CreationTimestamp.Time
+	if condition := apimeta.FindStatusCondition(cr.Status.Conditions, miqtool.DatabaseCredentialsRotatingCondition); condition != nil {
+		last = condition.LastTransitionTime.Time
+	}
+
+	return miqtool.NextDatabaseCredentialsRotation(cr, last)
+}
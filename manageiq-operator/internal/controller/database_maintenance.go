@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqtool "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/miq-components"
+)
+
+// generateDatabaseMaintenanceResources creates a CronJob for each scheduled maintenance task and
+// removes those of the tasks which are no longer scheduled, then reports their last run
+func (r *ManageIQReconciler) generateDatabaseMaintenanceResources(cr *miqv1alpha1.ManageIQ) error {
+	if !miqtool.DatabaseMaintenanceEnabled(cr) {
+		cr.Status.DatabaseMaintenance = nil
+		return nil
+	}
+
+	runs := []miqv1alpha1.DatabaseMaintenanceRun{}
+	for _, task := range miqtool.DatabaseMaintenanceTasks {
+		cronJob, mutateFunc := miqtool.DatabaseMaintenanceCronJob(cr, task, r.Client, r.Scheme)
+		if miqtool.DatabaseMaintenanceSchedule(cr, task) == "" {
+			if err := r.pruneObjects(cr, componentDatabaseMaintenance, cronJob); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, cronJob, r.detectDrift(cr, cronJob, mutateFunc)); err != nil {
+			return err
+		} else if result != controllerutil.OperationResultNone {
+			logger.Info("CronJob has been reconciled", "component", componentDatabaseMaintenance, "task", task, "result", result)
+			r.recordReconcileEvent(cr, cronJob, result)
+		}
+
+		run, err := r.databaseMaintenanceRun(cr, task, cronJob)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, run)
+	}
+
+	cr.Status.DatabaseMaintenance = runs
+	return nil
+}
+
+// databaseMaintenanceRun reports the schedule times of the CronJob of a maintenance task along with
+// the result of its most recent Job, the report of the bloat report task is its termination message
+func (r *ManageIQReconciler) databaseMaintenanceRun(cr *miqv1alpha1.ManageIQ, task string, cronJob *batchv1.CronJob) (miqv1alpha1.DatabaseMaintenanceRun, error) {
+	run := miqv1alpha1.DatabaseMaintenanceRun{
+		Task:               task,
+		LastScheduleTime:   cronJob.Status.LastScheduleTime,
+		LastSuccessfulTime: cronJob.Status.LastSuccessfulTime,
+	}
+
+	jobList := &batchv1.JobList{}
+	if err := r.Client.List(context.TODO(), jobList, client.InNamespace(cr.Namespace), client.MatchingLabels{"app": cr.Spec.AppName, miqtool.DatabaseMaintenanceTaskLabel: task}); err != nil {
+		return run, err
+	}
+
+	jobs := []batchv1.Job{}
+	for _, job := range jobList.Items {
+		if metav1.IsControlledBy(&job, cronJob) {
+			jobs = append(jobs, job)
+		}
+	}
+	if len(jobs) == 0 {
+		return run, nil
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreationTimestamp.After(jobs[j].CreationTimestamp.Time)
+	})
+
+	job := &jobs[0]
+	switch {
+	case job.Status.Succeeded > 0:
+		run.LastResult = "Succeeded"
+	case jobFailed(job):
+		run.LastResult = "Failed"
+	default:
+		run.LastResult = "Running"
+		return run, nil
+	}
+
+	message, err := r.jobTerminationMessage(job)
+	if err != nil {
+		return run, err
+	}
+	run.Message = message
+
+	return run, nil
+}
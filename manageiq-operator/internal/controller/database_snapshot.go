@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqtool "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/miq-components"
+)
+
+//+kubebuilder:rbac:namespace=changeme,groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;update;patch;delete
+
+// snapshotDatabase takes the scheduled VolumeSnapshots of the database PVC. The orchestrator is
+// scaled down first when DatabaseSnapshotPauseOrchestrator is set, then a CHECKPOINT is issued and
+// the VolumeSnapshot is created. The snapshot has completed once the storage provider has cut it.
+func (r *ManageIQReconciler) snapshotDatabase(cr *miqv1alpha1.ManageIQ) error {
+	if err := r.reportDatabaseSnapshots(cr); err != nil {
+		return err
+	}
+
+	job, _ := miqtool.DatabaseCheckpointJob(cr, "", r.Client, r.Scheme)
+	if !miqtool.DatabaseSnapshotsEnabled(cr) {
+		apimeta.RemoveStatusCondition(&cr.Status.Conditions, miqtool.DatabaseSnapshottingCondition)
+		return r.deleteJobs(job)
+	}
+
+	if apimeta.FindStatusCondition(cr.Status.Conditions, miqtool.DatabaseSnapshottingCondition) == nil {
+		r.reportStatusCondition(cr, fmt.Sprintf("Next snapshot at %s", r.nextDatabaseSnapshot(cr).Format(time.RFC3339)), "Scheduled", metav1.ConditionFalse, miqtool.DatabaseSnapshottingCondition)
+		return nil
+	}
+
+	if !miqtool.DatabaseSnapshotting(cr) {
+		if time.Now().Before(r.nextDatabaseSnapshot(cr)) {
+			return nil
+		}
+		if miqtool.Quiesced(cr) || miqtool.PostgresqlUpgrading(cr) {
+			logger.Info("Waiting for the restore or the upgrade before snapshotting the database", "component", "postgresql")
+			return nil
+		}
+
+		logger.Info("Database snapshot has started", "component", "postgresql")
+		r.reportStatusCondition(cr, "Starting the snapshot", "Starting", metav1.ConditionTrue, miqtool.DatabaseSnapshottingCondition)
+	}
+
+	if miqtool.DatabaseSnapshotPaused(cr) {
+		for _, selector := range []client.ListOption{
+			client.MatchingLabels{"app": cr.Spec.AppName, "name": "orchestrator"},
+			client.HasLabels{cr.Spec.AppName + "-orchestrated-by"},
+		} {
+			pods := &corev1.PodList{}
+			if err := r.Client.List(context.TODO(), pods, client.InNamespace(cr.Namespace), selector); err != nil {
+				return err
+			}
+			if len(pods.Items) > 0 {
+				r.reportStatusCondition(cr, "Waiting for the orchestrator and its workers to stop", "Pausing", metav1.ConditionTrue, miqtool.DatabaseSnapshottingCondition)
+				return nil
+			}
+		}
+	}
+
+	job, mutateFunc := miqtool.DatabaseCheckpointJob(cr, miqtool.DatabaseSnapshotName(cr, time.Now()), r.Client, r.Scheme)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, job, mutateFunc); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("Job has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, job, result)
+	}
+
+	switch {
+	case job.Status.Succeeded > 0:
+	case jobFailed(job):
+		return r.databaseSnapshotFailed(cr, fmt.Sprintf("Job %s issuing the CHECKPOINT failed", job.Name))
+	default:
+		r.reportStatusCondition(cr, fmt.Sprintf("Waiting for Job %s issuing the CHECKPOINT", job.Name), "Checkpointing", metav1.ConditionTrue, miqtool.DatabaseSnapshottingCondition)
+		return nil
+	}
+
+	snapshot, mutateFunc := miqtool.DatabaseVolumeSnapshot(cr, job.Annotations[miqtool.DatabaseSnapshotAnnotation], r.Scheme)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, snapshot, mutateFunc); apimeta.IsNoMatchError(err) {
+		return r.databaseSnapshotFailed(cr, "The VolumeSnapshot API is not available, the CSI snapshot controller must be installed to snapshot the database")
+	} else if err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("VolumeSnapshot has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, snapshot, result)
+	}
+
+	status, message := miqtool.VolumeSnapshotStatus(snapshot)
+	if message != "" {
+		return r.databaseSnapshotFailed(cr, fmt.Sprintf("VolumeSnapshot %s failed: %s", snapshot.GetName(), message))
+	}
+	if status.CreationTime == nil {
+		r.reportStatusCondition(cr, fmt.Sprintf("Waiting for the storage provider to cut VolumeSnapshot %s", snapshot.GetName()), "Snapshotting", metav1.ConditionTrue, miqtool.DatabaseSnapshottingCondition)
+		return nil
+	}
+
+	if err := r.deleteJobs(job); err != nil {
+		return err
+	}
+
+	logger.Info("Database snapshot has been taken", "component", "postgresql", "snapshot", snapshot.GetName())
+	r.Recorder.Eventf(cr, corev1.EventTypeNormal, "DatabaseSnapshotTaken", "VolumeSnapshot %s of the database has been taken", snapshot.GetName())
+	r.reportStatusCondition(cr, fmt.Sprintf("VolumeSnapshot %s has been taken", snapshot.GetName()), "Completed", metav1.ConditionFalse, miqtool.DatabaseSnapshottingCondition)
+
+	if err := r.pruneDatabaseSnapshots(cr); err != nil {
+		return err
+	}
+	return r.reportDatabaseSnapshots(cr)
+}
+
+// databaseSnapshotFailed ends the snapshot, the next one is taken on the schedule
+func (r *ManageIQReconciler) databaseSnapshotFailed(cr *miqv1alpha1.ManageIQ, message string) error {
+	job, _ := miqtool.DatabaseCheckpointJob(cr, "", r.Client, r.Scheme)
+	if err := r.deleteJobs(job); err != nil {
+		return err
+	}
+
+	r.Recorder.Event(cr, corev1.EventTypeWarning, "DatabaseSnapshotFailed", message)
+	r.reportStatusCondition(cr, message, "Failed", metav1.ConditionFalse, miqtool.DatabaseSnapshottingCondition)
+	return fmt.Errorf("%s", message)
+}
+
+// nextDatabaseSnapshot returns the time of the next scheduled snapshot, counted from the end of the
+// previous one, or the zero time when no snapshots are scheduled or one is in progress
+func (r *ManageIQReconciler) nextDatabaseSnapshot(cr *miqv1alpha1.ManageIQ) time.Time {
+	if !miqtool.DatabaseSnapshotsEnabled(cr) || miqtool.DatabaseSnapshotting(cr) {
+		return time.Time{}
+	}
+
+	last := time.Now()
+	if condition := apimeta.FindStatusCondition(cr.Status.Conditions, miqtool.DatabaseSnapshottingCondition); condition != nil {
+		last = condition.LastTransitionTime.Time
+	}
+
+	return miqtool.NextDatabaseSnapshot(cr, last)
+}
+
+// databaseSnapshots returns the VolumeSnapshots of the database taken by the operator, the most
+// recent first. No snapshots are returned when the VolumeSnapshot API is not available.
+func (r *ManageIQReconciler) databaseSnapshots(cr *miqv1alpha1.ManageIQ) ([]unstructured.Unstructured, error) {
+	list := miqtool.NewVolumeSnapshotList()
+	if err := r.Client.List(context.TODO(), list, client.InNamespace(cr.Namespace), client.MatchingLabels(miqtool.DatabaseSnapshotSelector(cr))); apimeta.IsNoMatchError(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	snapshots := []unstructured.Unstructured{}
+	for _, snapshot := range list.Items {
+		if metav1.IsControlledBy(&snapshot, cr) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].GetCreationTimestamp().After(snapshots[j].GetCreationTimestamp().Time)
+	})
+
+	return snapshots, nil
+}
+
+// reportDatabaseSnapshots lists the VolumeSnapshots of the database in the CR status
+func (r *ManageIQReconciler) reportDatabaseSnapshots(cr *miqv1alpha1.ManageIQ) error {
+	snapshots, err := r.databaseSnapshots(cr)
+	if err != nil {
+		return err
+	}
+
+	cr.Status.DatabaseSnapshots = nil
+	for i := range snapshots {
+		status, _ := miqtool.VolumeSnapshotStatus(&snapshots[i])
+		cr.Status.DatabaseSnapshots = append(cr.Status.DatabaseSnapshots, status)
+	}
+
+	return nil
+}
+
+// pruneDatabaseSnapshots removes the oldest snapshots beyond the retention, the snapshot being
+// restored is kept
+func (r *ManageIQReconciler) pruneDatabaseSnapshots(cr *miqv1alpha1.ManageIQ) error {
+	snapshots, err := r.databaseSnapshots(cr)
+	if err != nil {
+		return err
+	}
+
+	for i := miqtool.DatabaseSnapshotRetention(cr); i < len(snapshots); i++ {
+		if snapshots[i].GetName() == cr.Spec.DatabaseSnapshotRestore {
+			continue
+		}
+
+		if err := r.Client.Delete(context.TODO(), &snapshots[i]); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		logger.Info("VolumeSnapshot has been deleted", "component", "postgresql", "snapshot", snapshots[i].GetName())
+		r.Recorder.Eventf(cr, corev1.EventTypeNormal, "Deleted", "VolumeSnapshot %s has been deleted", snapshots[i].GetName())
+	}
+
+	return nil
+}
+
+// restoreDatabaseSnapshot checks the VolumeSnapshot of DatabaseSnapshotRestore before the PVC is
+// created from it, and reports whether the PVC can be reconciled
+func (r *ManageIQReconciler) restoreDatabaseSnapshot(cr *miqv1alpha1.ManageIQ) (bool, error) {
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.Client.Get(context.TODO(), client.ObjectKey{Namespace: cr.Namespace, Name: miqtool.PostgresqlClaimName(cr)}, pvc); err == nil {
+		if !metav1.IsControlledBy(pvc, cr) {
+			return false, fmt.Errorf("PVC %s already exists and is not managed by the CR, VolumeSnapshot %s can not be restored to it", pvc.Name, cr.Spec.DatabaseSnapshotRestore)
+		}
+		return true, nil
+	} else if !errors.IsNotFound(err) {
+		return false, err
+	}
+
+	snapshot := miqtool.NewVolumeSnapshot(cr.Namespace, cr.Spec.DatabaseSnapshotRestore)
+	if err := r.Client.Get(context.TODO(), client.ObjectKeyFromObject(snapshot), snapshot); apimeta.IsNoMatchError(err) {
+		return false, fmt.Errorf("The VolumeSnapshot API is not available, VolumeSnapshot %s can not be restored", cr.Spec.DatabaseSnapshotRestore)
+	} else if errors.IsNotFound(err) {
+		return false, fmt.Errorf("VolumeSnapshot %s does not exist", cr.Spec.DatabaseSnapshotRestore)
+	} else if err != nil {
+		return false, err
+	}
+
+	status, message := miqtool.VolumeSnapshotStatus(snapshot)
+	if message != "" {
+		return false, fmt.Errorf("VolumeSnapshot %s failed: %s", snapshot.GetName(), message)
+	}
+	if !status.ReadyToUse {
+		logger.Info("Waiting for the VolumeSnapshot to be ready to use", "component", "postgresql", "snapshot", snapshot.GetName())
+		return false, nil
+	}
+	if restoreSize, err := resource.ParseQuantity(status.RestoreSize); err == nil && restoreSize.Cmp(resource.MustParse(cr.Spec.DatabaseVolumeCapacity)) > 0 {
+		return false, fmt.Errorf("DatabaseVolumeCapacity %s is lower than the restore size %s of VolumeSnapshot %s", cr.Spec.DatabaseVolumeCapacity, status.RestoreSize, snapshot.GetName())
+	}
+
+	logger.Info("Database snapshot restore has started", "component", "postgresql", "snapshot", snapshot.GetName())
+	r.Recorder.Eventf(cr, corev1.EventTypeNormal, "DatabaseSnapshotRestoring", "Restoring VolumeSnapshot %s to PVC %s, the database is restarted on it", snapshot.GetName(), miqtool.PostgresqlClaimName(cr))
+
+	return true, nil
+}
@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqtool "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/miq-components"
+)
+
+// operatorBuildAnnotation records the build of the operator which last wrote an object
+const operatorBuildAnnotation = "manageiq.org/operator-build"
+
+// detectDrift wraps the mutate function of a builder to compare the live object with the desired
+// state before CreateOrUpdate corrects it. Differences found while the CR has changed since the
+// last reconcile, while a restore or a database upgrade scales down the application, or on objects
+// last written by another build of the operator, are expected and not reported.
+// With the Report drift detection the live object is left as is, except for the Secrets which the
+// operator also updates on its own, e.g. with the certificates of the internal certificates secret.
+// An operator upgrade overrides the Report drift detection: the changes of the new build, e.g. a
+// new default image, are rolled out once, along with any manual change of the same fields.
+func (r *ManageIQReconciler) detectDrift(cr *miqv1alpha1.ManageIQ, obj client.Object, mutateFunc controllerutil.MutateFn) controllerutil.MutateFn {
+	return func() error {
+		if obj.GetResourceVersion() == "" {
+			if err := mutateFunc(); err != nil {
+				return err
+			}
+			r.annotateOperatorBuild(obj)
+			return nil
+		}
+
+		live := obj.DeepCopyObject().(client.Object)
+		if err := mutateFunc(); err != nil {
+			return err
+		}
+		r.annotateOperatorBuild(obj)
+
+		if cr.Generation != cr.Status.ObservedGeneration || quiesceChanged(cr) || r.operatorUpgraded(live) {
+			r.clearDrift(cr, obj)
+			return nil
+		}
+
+		fields, err := driftedFields(live, obj)
+		if err != nil {
+			return err
+		}
+		if len(fields) == 0 {
+			r.clearDrift(cr, obj)
+			return nil
+		}
+
+		_, secret := obj.(*corev1.Secret)
+		corrected := cr.Spec.DriftDetection != miqv1alpha1.DriftDetectionReport || secret
+		r.recordDrift(cr, obj, fields, corrected)
+		if !corrected {
+			reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(live).Elem())
+		}
+
+		return nil
+	}
+}
+
+// annotateOperatorBuild records the build of the operator on the object it is about to write
+func (r *ManageIQReconciler) annotateOperatorBuild(obj client.Object) {
+	if r.OperatorBuild == "" {
+		return
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[operatorBuildAnnotation] = r.OperatorBuild
+	obj.SetAnnotations(annotations)
+}
+
+// operatorUpgraded returns whether the live object was last written by another build of the
+// operator, or by a build from before the objects were annotated. Outside of the operator image
+// the build is unknown and every object is taken as written by the running operator.
+func (r *ManageIQReconciler) operatorUpgraded(live client.Object) bool {
+	return r.OperatorBuild != "" && live.GetAnnotations()[operatorBuildAnnotation] != r.OperatorBuild
+}
+
+// quiesceChanged returns whether the CR is quiesced for a restore, a database upgrade or a snapshot
+// or was in the previous reconcile, the replicas change without a change of the CR spec
+func quiesceChanged(cr *miqv1alpha1.ManageIQ) bool {
+	if miqtool.Quiesced(cr) || miqtool.PostgresqlUpgrading(cr) || miqtool.DatabaseSnapshotPaused(cr) {
+		return true
+	}
+
+	condition := apimeta.FindStatusCondition(cr.Status.Conditions, conditionReady)
+	return condition != nil && (condition.Reason == reasonQuiesced || condition.Reason == reasonPostgresqlUpgrading || condition.Reason == reasonDatabaseSnapshotting)
+}
+
+// recordDrift reports the drifted fields of obj as an Event and in the CR status,
+// unless the same drift was already reported and left in place
+func (r *ManageIQReconciler) recordDrift(cr *miqv1alpha1.ManageIQ, obj client.Object, fields []string, corrected bool) {
+	kind := r.objectKind(obj)
+	for _, drifted := range cr.Status.Drift {
+		if drifted.Kind == kind && drifted.Name == obj.GetName() && !drifted.Corrected && !corrected && reflect.DeepEqual(drifted.Fields, fields) {
+			return
+		}
+	}
+
+	action := "corrected"
+	if !corrected {
+		action = "left in place"
+	}
+
+	logger.Info("Object has drifted from the desired state", "kind", kind, "name", obj.GetName(), "fields", fields, "corrected", corrected)
+	r.Recorder.Eventf(cr, corev1.EventTypeWarning, "DriftDetected", "%s %s has drifted from the desired state, %s: %s", kind, obj.GetName(), action, strings.Join(fields, ", "))
+
+	drifted := miqv1alpha1.DriftedObject{Kind: kind, Name: obj.GetName(), Fields: fields, Corrected: corrected, LastDetectedTime: metav1.Now()}
+	for i := range cr.Status.Drift {
+		if cr.Status.Drift[i].Kind == kind && cr.Status.Drift[i].Name == obj.GetName() {
+			cr.Status.Drift[i] = drifted
+			return
+		}
+	}
+	cr.Status.Drift = append(cr.Status.Drift, drifted)
+}
+
+// clearDrift drops the uncorrected drift of obj from the CR status once it matches the desired
+// state again, the corrected drift is kept as a record of the last manual change
+func (r *ManageIQReconciler) clearDrift(cr *miqv1alpha1.ManageIQ, obj client.Object) {
+	kind := r.objectKind(obj)
+	drift := []miqv1alpha1.DriftedObject{}
+	for _, drifted := range cr.Status.Drift {
+		if drifted.Kind == kind && drifted.Name == obj.GetName() && !drifted.Corrected {
+			continue
+		}
+		drift = append(drift, drifted)
+	}
+	cr.Status.Drift = drift
+}
+
+// driftedFields returns the paths of the fields set in desired which have a different value in
+// live. Fields only present in live, e.g. defaulted by the API server, are ignored, as is the
+// stringData of a Secret which the API server merges into its data.
+func driftedFields(live client.Object, desired client.Object) ([]string, error) {
+	liveContent, err := jsonContent(live)
+	if err != nil {
+		return nil, err
+	}
+	desiredContent, err := jsonContent(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range []string{"status", "stringData"} {
+		delete(liveContent, field)
+		delete(desiredContent, field)
+	}
+	for _, content := range []map[string]interface{}{liveContent, desiredContent} {
+		if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+			content["metadata"] = map[string]interface{}{"labels": metadata["labels"], "annotations": metadata["annotations"]}
+		}
+	}
+
+	fields := []string{}
+	compareFields("", liveContent, desiredContent, &fields)
+	sort.Strings(fields)
+
+	return fields, nil
+}
+
+func jsonContent(obj client.Object) (map[string]interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	content := map[string]interface{}{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+func compareFields(path string, live interface{}, desired interface{}, fields *[]string) {
+	switch desiredValue := desired.(type) {
+	case nil:
+		return
+	case map[string]interface{}:
+		liveValue, ok := live.(map[string]interface{})
+		if !ok {
+			if len(desiredValue) > 0 {
+				*fields = append(*fields, path)
+			}
+			return
+		}
+		for key, value := range desiredValue {
+			compareFields(fieldPath(path, key), liveValue[key], value, fields)
+		}
+	case []interface{}:
+		liveValue, ok := live.([]interface{})
+		if !ok || len(liveValue) != len(desiredValue) {
+			if len(desiredValue) > 0 || len(liveValue) > 0 {
+				*fields = append(*fields, path)
+			}
+			return
+		}
+		for i, value := range desiredValue {
+			compareFields(fmt.Sprintf("%s[%d]", path, i), liveValue[i], value, fields)
+		}
+	default:
+		if !reflect.DeepEqual(live, desired) && !(quantityField(path) && equalQuantities(live, desired)) {
+			*fields = append(*fields, path)
+		}
+	}
+}
+
+// quantityField returns whether the field holds a resource quantity, e.g. the resources of a
+// container or a PVC and the storage size of the Kafka CR, which the API server may reformat
+func quantityField(path string) bool {
+	return strings.Contains(path, ".resources.") || strings.HasSuffix(path, ".storage.size") || strings.HasSuffix(path, ".sizeLimit")
+}
+
+// equalQuantities compares quantities by value, e.g. 0.5 and 500m, numbers are read as quantities
+func equalQuantities(live interface{}, desired interface{}) bool {
+	quantity := func(value interface{}) (resource.Quantity, bool) {
+		switch v := value.(type) {
+		case string:
+			q, err := resource.ParseQuantity(v)
+			return q, err == nil
+		case float64:
+			q, err := resource.ParseQuantity(strconv.FormatFloat(v, 'f', -1, 64))
+			return q, err == nil
+		}
+		return resource.Quantity{}, false
+	}
+
+	liveQuantity, ok := quantity(live)
+	if !ok {
+		return false
+	}
+	desiredQuantity, ok := quantity(desired)
+	return ok && liveQuantity.Cmp(desiredQuantity) == 0
+}
+
+func fieldPath(path string, key string) string {
+	if strings.ContainsAny(key, "./") {
+		return fmt.Sprintf("%s[%s]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqtool "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/miq-components"
+)
+
+func TestCompareFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		live    interface{}
+		desired interface{}
+		fields  []string
+	}{
+		{
+			name:    "equal values",
+			live:    map[string]interface{}{"spec": map[string]interface{}{"replicas": 1.0, "name": "httpd"}},
+			desired: map[string]interface{}{"spec": map[string]interface{}{"replicas": 1.0, "name": "httpd"}},
+			fields:  []string{},
+		},
+		{
+			name:    "changed value",
+			live:    map[string]interface{}{"spec": map[string]interface{}{"replicas": 2.0}},
+			desired: map[string]interface{}{"spec": map[string]interface{}{"replicas": 1.0}},
+			fields:  []string{"spec.replicas"},
+		},
+		{
+			name:    "field only set in live",
+			live:    map[string]interface{}{"spec": map[string]interface{}{"replicas": 1.0, "revisionHistoryLimit": 10.0}},
+			desired: map[string]interface{}{"spec": map[string]interface{}{"replicas": 1.0}},
+			fields:  []string{},
+		},
+		{
+			name:    "field missing in live",
+			live:    map[string]interface{}{"spec": map[string]interface{}{}},
+			desired: map[string]interface{}{"spec": map[string]interface{}{"replicas": 1.0}},
+			fields:  []string{"spec.replicas"},
+		},
+		{
+			name:    "nil desired value",
+			live:    map[string]interface{}{"spec": map[string]interface{}{"selector": map[string]interface{}{"app": "manageiq"}}},
+			desired: map[string]interface{}{"spec": map[string]interface{}{"selector": nil}},
+			fields:  []string{},
+		},
+		{
+			name:    "empty desired map",
+			live:    map[string]interface{}{"metadata": map[string]interface{}{"labels": nil}},
+			desired: map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{}}},
+			fields:  []string{},
+		},
+		{
+			name:    "list of a different length",
+			live:    map[string]interface{}{"args": []interface{}{"a"}},
+			desired: map[string]interface{}{"args": []interface{}{"a", "b"}},
+			fields:  []string{"args"},
+		},
+		{
+			name:    "empty desired list",
+			live:    map[string]interface{}{},
+			desired: map[string]interface{}{"args": []interface{}{}},
+			fields:  []string{},
+		},
+		{
+			name:    "changed list item",
+			live:    map[string]interface{}{"containers": []interface{}{map[string]interface{}{"image": "a"}}},
+			desired: map[string]interface{}{"containers": []interface{}{map[string]interface{}{"image": "b"}}},
+			fields:  []string{"containers[0].image"},
+		},
+		{
+			name:    "key with a dot or a slash",
+			live:    map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"manageiq.org/backup": "t"}}},
+			desired: map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"manageiq.org/backup": "db"}}},
+			fields:  []string{"metadata.labels[manageiq.org/backup]"},
+		},
+		{
+			name:    "reformatted quantity",
+			live:    map[string]interface{}{"spec": map[string]interface{}{"resources": map[string]interface{}{"requests": map[string]interface{}{"cpu": "500m", "memory": "1Gi"}}}},
+			desired: map[string]interface{}{"spec": map[string]interface{}{"resources": map[string]interface{}{"requests": map[string]interface{}{"cpu": "0.5", "memory": "1024Mi"}}}},
+			fields:  []string{},
+		},
+		{
+			name:    "numeric quantity",
+			live:    map[string]interface{}{"spec": map[string]interface{}{"kafka": map[string]interface{}{"resources": map[string]interface{}{"limits": map[string]interface{}{"cpu": 1.0}}}}},
+			desired: map[string]interface{}{"spec": map[string]interface{}{"kafka": map[string]interface{}{"resources": map[string]interface{}{"limits": map[string]interface{}{"cpu": "1000m"}}}}},
+			fields:  []string{},
+		},
+		{
+			name:    "changed quantity",
+			live:    map[string]interface{}{"spec": map[string]interface{}{"resources": map[string]interface{}{"requests": map[string]interface{}{"storage": "10Gi"}}}},
+			desired: map[string]interface{}{"spec": map[string]interface{}{"resources": map[string]interface{}{"requests": map[string]interface{}{"storage": "15Gi"}}}},
+			fields:  []string{"spec.resources.requests.storage"},
+		},
+		{
+			name:    "quantity-like value outside of the resources",
+			live:    map[string]interface{}{"spec": map[string]interface{}{"version": "1.10"}},
+			desired: map[string]interface{}{"spec": map[string]interface{}{"version": "1.1"}},
+			fields:  []string{"spec.version"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fields := []string{}
+			compareFields("", test.live, test.desired, &fields)
+			sort.Strings(fields)
+			if !reflect.DeepEqual(fields, test.fields) {
+				t.Errorf("compareFields() = %v, want %v", fields, test.fields)
+			}
+		})
+	}
+}
+
+func TestDriftedFields(t *testing.T) {
+	live := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgresql-secrets", ResourceVersion: "2", Labels: map[string]string{"app": "manageiq"}},
+		Data:       map[string][]byte{"sslmode": []byte("verify-full")},
+	}
+	desired := live.DeepCopy()
+	desired.StringData = map[string]string{"sslmode": "verify-full"}
+
+	fields, err := driftedFields(live, desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 0 {
+		t.Errorf("driftedFields() = %v, the stringData is never returned by the API server", fields)
+	}
+
+	desired.Data["sslmode"] = []byte("require")
+	desired.Labels["app"] = "other"
+	fields, err = driftedFields(live, desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"data.sslmode", "metadata.labels.app"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("driftedFields() = %v, want %v", fields, want)
+	}
+
+	livePVC := &corev1.PersistentVolumeClaim{}
+	livePVC.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}
+	desiredPVC := livePVC.DeepCopy()
+	desiredPVC.Spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("1024Mi")
+	fields, err = driftedFields(livePVC, desiredPVC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 0 {
+		t.Errorf("driftedFields() = %v, 1Gi and 1024Mi are the same quantity", fields)
+	}
+}
+
+// TestDetectDriftOperatorUpgrade checks that the Report drift detection leaves the manual changes in
+// place, but not the changes of the builders brought by an operator upgrade
+func TestDetectDriftOperatorUpgrade(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		driftDetection string
+		operatorBuild  string
+		liveBuild      string
+		rolledOut      bool
+		reported       bool
+	}{
+		{
+			name:           "manual change",
+			driftDetection: miqv1alpha1.DriftDetectionReport,
+			operatorBuild:  "20261018120000-b",
+			liveBuild:      "20261018120000-b",
+			reported:       true,
+		},
+		{
+			name:           "operator upgrade",
+			driftDetection: miqv1alpha1.DriftDetectionReport,
+			operatorBuild:  "20261018120000-b",
+			liveBuild:      "20260901120000-a",
+			rolledOut:      true,
+		},
+		{
+			name:           "object written before the build annotation",
+			driftDetection: miqv1alpha1.DriftDetectionReport,
+			operatorBuild:  "20261018120000-b",
+			rolledOut:      true,
+		},
+		{
+			name:           "unknown operator build",
+			driftDetection: miqv1alpha1.DriftDetectionReport,
+			reported:       true,
+		},
+		{
+			name:           "operator upgrade with the Correct drift detection",
+			driftDetection: miqv1alpha1.DriftDetectionCorrect,
+			operatorBuild:  "20261018120000-b",
+			liveBuild:      "20260901120000-a",
+			rolledOut:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cr := &miqv1alpha1.ManageIQ{ObjectMeta: metav1.ObjectMeta{Namespace: "manageiq", Name: "miq", Generation: 1}}
+			cr.Spec.AppName = "manageiq"
+			cr.Spec.DriftDetection = test.driftDetection
+			cr.Status.ObservedGeneration = 1
+			miqtool.DefaultCR(cr)
+
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "manageiq-configs", ResourceVersion: "2"},
+				Data:       map[string]string{"image": "docker.io/manageiq/manageiq:live"},
+			}
+			if test.liveBuild != "" {
+				configMap.Annotations = map[string]string{operatorBuildAnnotation: test.liveBuild}
+			}
+			mutateFunc := func() error {
+				configMap.Data["image"] = "docker.io/manageiq/manageiq:desired"
+				return nil
+			}
+
+			r := &ManageIQReconciler{Scheme: scheme, Recorder: record.NewFakeRecorder(10), OperatorBuild: test.operatorBuild}
+			if err := r.detectDrift(cr, configMap, mutateFunc)(); err != nil {
+				t.Fatalf("detectDrift() failed: %v", err)
+			}
+
+			if rolledOut := configMap.Data["image"] == "docker.io/manageiq/manageiq:desired"; rolledOut != test.rolledOut {
+				t.Errorf("desired state rolled out = %v, want %v", rolledOut, test.rolledOut)
+			}
+			if reported := len(cr.Status.Drift) > 0; reported != test.reported {
+				t.Errorf("drift reported = %v, want %v: %v", reported, test.reported, cr.Status.Drift)
+			}
+			if build := configMap.Annotations[operatorBuildAnnotation]; test.rolledOut && build != test.operatorBuild {
+				t.Errorf("operator build annotation = %q, want %q", build, test.operatorBuild)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqtool "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/miq-components"
+)
+
+// checkExternalDatabase runs the preflight Job against the external database each time its
+// connection settings change and reports the outcome in the DatabaseReady condition. A failed
+// check keeps the CR from becoming Ready, it does not fail the reconcile.
+func (r *ManageIQReconciler) checkExternalDatabase(cr *miqv1alpha1.ManageIQ) error {
+	checksum := miqtool.ExternalDatabaseChecksum(cr, r.Client)
+	job, mutateFunc := miqtool.DatabasePreflightJob(cr, checksum, r.Client, r.Scheme)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, job, mutateFunc); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("Job has been reconciled", "component", "database", "result", result)
+		r.recordReconcileEvent(cr, job, result)
+	}
+
+	if job.Annotations[miqtool.DatabasePreflightChecksumAnnotation] != checksum {
+		logger.Info("Database connection settings have changed, rerunning the preflight check", "component", "database", "job", job.Name)
+		r.reportStatusCondition(cr, "The connection settings have changed, waiting for the preflight check", "PreflightPending", metav1.ConditionUnknown, conditionDatabaseReady)
+		return r.deleteJobs(job)
+	}
+
+	previous := apimeta.FindStatusCondition(cr.Status.Conditions, conditionDatabaseReady)
+	switch {
+	case job.Status.Succeeded > 0:
+		message, err := r.jobTerminationMessage(job)
+		if err != nil {
+			return err
+		}
+		r.reportStatusCondition(cr, "Connected to PostgreSQL "+postgresqlVersionName(message), "PreflightSucceeded", metav1.ConditionTrue, conditionDatabaseReady)
+	case jobFailed(job):
+		message, err := r.jobTerminationMessage(job)
+		if err != nil {
+			return err
+		}
+		message = fmt.Sprintf("Job %s failed, fix the connection settings or delete it to retry: %s", job.Name, strings.TrimSpace(message))
+		if previous == nil || previous.Status != metav1.ConditionFalse {
+			r.Recorder.Event(cr, corev1.EventTypeWarning, "DatabasePreflightFailed", message)
+		}
+		r.reportStatusCondition(cr, message, "PreflightFailed", metav1.ConditionFalse, conditionDatabaseReady)
+	default:
+		r.reportStatusCondition(cr, fmt.Sprintf("Waiting for Job %s", job.Name), "PreflightRunning", metav1.ConditionUnknown, conditionDatabaseReady)
+	}
+
+	return nil
+}
+
+// postgresqlVersionName formats a server_version_num, e.g. 160004 as 16.4
+func postgresqlVersionName(versionNum string) string {
+	num, err := strconv.Atoi(strings.TrimSpace(versionNum))
+	if err != nil {
+		return versionNum
+	}
+
+	return fmt.Sprintf("%d.%d", num/10000, num%10000)
+}
+
+// jobTerminationMessage returns the first termination message written by a container of the pods
+// of the Job
+func (r *ManageIQReconciler) jobTerminationMessage(job *batchv1.Job) (string, error) {
+	pods := &corev1.PodList{}
+	if err := r.Client.List(context.TODO(), pods, client.InNamespace(job.Namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name}); err != nil {
+		return "", err
+	}
+
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated != nil && status.State.Terminated.Message != "" {
+				return status.State.Terminated.Message, nil
+			}
+		}
+	}
+
+	return "", nil
+}
@@ -19,17 +19,26 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -42,6 +51,8 @@ import (
 	miqkafka "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/miq-components/kafka"
 	miqutilsv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/miqutils"
 	routev1 "github.com/openshift/api/route/v1"
+	olmv1 "github.com/operator-framework/api/pkg/operators/v1"
+	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -51,12 +62,17 @@ type ManageIQReconciler struct {
 	Client   client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+	// OperatorBuild identifies the build of the operator, the objects are annotated with the build
+	// which last wrote them so that the changes of an operator upgrade are rolled out, see detectDrift
+	OperatorBuild string
 }
 
 //+kubebuilder:rbac:namespace=changeme,groups="",resources=configmaps;events;persistentvolumeclaims;pods;pods/finalizers;secrets;serviceaccounts;services;services/finalizers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:namespace=changeme,groups="",resources=pods/log,verbs=get
-//+kubebuilder:rbac:namespace=changeme,groups=apps,resources=deployments;deployments/scale;replicasets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:namespace=changeme,groups=apps,resources=deployments;deployments/scale;replicasets;statefulsets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:namespace=changeme,groups=apps,resources=deployments/finalizers,resourceNames=manageiq-operator,verbs=update
+//+kubebuilder:rbac:namespace=changeme,groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:namespace=changeme,groups=batch,resources=cronjobs;jobs,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:namespace=changeme,groups=coordination.k8s.io,resources=leases,verbs=get;list;create;update;delete
 //+kubebuilder:rbac:namespace=changeme,groups=extensions,resources=deployments;deployments/scale;networkpolicies,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:namespace=changeme,groups=kafka.strimzi.io,resources=kafkas;kafkausers;kafkatopics,verbs=get;list;watch;create;update;patch;delete
@@ -65,7 +81,9 @@ type ManageIQReconciler struct {
 //+kubebuilder:rbac:namespace=changeme,groups=manageiq.org,resources=manageiqs/status,verbs=get;update;patch
 //+kubebuilder:rbac:namespace=changeme,groups=monitoring.coreos.com,resources=servicemonitors,verbs=get;create
 //+kubebuilder:rbac:namespace=changeme,groups=networking.k8s.io,resources=ingresses;networkpolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:namespace=changeme,groups=operators.coreos.com,resources=clusterserviceversions,verbs=get;delete
 //+kubebuilder:rbac:namespace=changeme,groups=operators.coreos.com,resources=operatorgroups;subscriptions,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:namespace=changeme,groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:namespace=changeme,groups=rbac.authorization.k8s.io,resources=rolebindings;roles,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:namespace=changeme,groups=route.openshift.io,resources=routes;routes/custom-host,verbs=get;list;watch;create;update;patch;delete
 
@@ -89,99 +107,215 @@ func (r *ManageIQReconciler) Reconcile(ctx context.Context, request ctrl.Request
 	err := r.Client.Get(context.TODO(), request.NamespacedName, miqInstance)
 	if errors.IsNotFound(err) {
 		return reconcile.Result{}, nil
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if !miqInstance.DeletionTimestamp.IsZero() {
+		logger.Info("Finalizing the CR...")
+		return r.finalizeManageIQ(miqInstance)
+	}
+
+	if miqInstance.Annotations[pauseReconciliationAnnotation] == "true" {
+		logger.Info("Reconciliation is paused, skipping.")
+		return r.reconcilePaused(miqInstance)
+	}
+
+	if controllerutil.AddFinalizer(miqInstance, manageiqFinalizer) {
+		if err := r.Client.Update(context.TODO(), miqInstance); err != nil {
+			return reconcile.Result{}, err
+		}
 	}
 
 	logger.Info("Migrating the CR...")
-	if e := r.migrateCR(miqInstance); e != nil {
-		return reconcile.Result{}, e
+	if migrating, e := r.migrateCR(miqInstance); e != nil {
+		return r.reconcileFailed(miqInstance, "MigrationFailed", e)
+	} else if migrating {
+		return r.reconcileMigrating(miqInstance)
 	}
 
 	logger.Info("Reconciling the CR...")
 	if e := r.manageCR(miqInstance); e != nil {
-		return reconcile.Result{}, e
+		return r.reconcileFailed(miqInstance, "ReconcileFailed", e)
 	}
 
 	logger.Info("Validating the CR...")
 	if e := miqInstance.Validate(); e != nil {
-		return reconcile.Result{}, e
+		r.Recorder.Event(miqInstance, corev1.EventTypeWarning, "ValidationFailed", e.Error())
+		return r.reconcileFailed(miqInstance, "ValidationFailed", e)
 	}
 
 	logger.Info("Reconciling the operator pod...")
 	if os.Getenv("POD_NAME") != "" {
 		if e := r.manageOperator(miqInstance); e != nil {
-			return reconcile.Result{}, e
+			return r.reconcileFailed(miqInstance, "ReconcileFailed", e)
 		}
 	} else {
 		logger.Info("Skipping reconcile of the operator pod; not running in a cluster.")
 	}
 
-	logger.Info("Reconciling the NetworkPolicies...")
-	if e := r.generateNetworkPolicies(miqInstance); e != nil {
-		return reconcile.Result{}, e
+	logger.Info("Pruning the disabled components...")
+	if e := r.pruneDisabledComponents(miqInstance); e != nil {
+		return r.reconcileFailed(miqInstance, "PruneFailed", e)
 	}
-	logger.Info("Reconciling the Secrets...")
-	if e := r.generateSecrets(miqInstance); e != nil {
-		return reconcile.Result{}, e
+
+	logger.Info("Reconciling the NetworkPolicies...")
+	if e := r.reconcilePhase(miqInstance, "NetworkPolicies", r.generateNetworkPolicies); e != nil {
+		return r.reconcileFailed(miqInstance, "NetworkPoliciesReconcileFailed", e)
 	}
-	logger.Info("Reconciling the default Service Account...")
-	if e := r.generateDefaultServiceAccount(miqInstance); e != nil {
-		return reconcile.Result{}, e
+	logger.Info("Reconciling the Secrets and the default Service Account...")
+	if e := r.reconcilePhase(miqInstance, "Secrets", r.generateSecrets, r.generateDefaultServiceAccount); e != nil {
+		return r.reconcileFailed(miqInstance, "SecretsReconcileFailed", e)
 	}
 	logger.Info("Reconciling the Postgresql resources...")
-	if e := r.generatePostgresqlResources(miqInstance); e != nil {
-		return reconcile.Result{}, e
+	if e := r.reconcilePhase(miqInstance, "Postgresql", r.generatePostgresqlResources, r.rotateDatabaseCredentials, r.snapshotDatabase, r.generateDatabaseMaintenanceResources); e != nil {
+		return r.reconcileFailed(miqInstance, "PostgresqlReconcileFailed", e)
 	}
-	logger.Info("Reconciling the Opentofu runner resources...")
-	if e := r.generateOpentofuRunnerResources(miqInstance); e != nil {
-		return reconcile.Result{}, e
+	if miqtool.PgbouncerEnabled(miqInstance) {
+		logger.Info("Reconciling the PgBouncer resources...")
+		if e := r.reconcilePhase(miqInstance, "Pgbouncer", r.generatePgbouncerResources); e != nil {
+			return r.reconcileFailed(miqInstance, "PgbouncerReconcileFailed", e)
+		}
+	} else {
+		apimeta.RemoveStatusCondition(&miqInstance.Status.Conditions, "PgbouncerReconciled")
 	}
 	logger.Info("Reconciling the HTTPD resources...")
-	if e := r.generateHttpdResources(miqInstance); e != nil {
-		return reconcile.Result{}, e
+	if e := r.reconcilePhase(miqInstance, "Httpd", r.generateHttpdResources); e != nil {
+		return r.reconcileFailed(miqInstance, "HttpdReconcileFailed", e)
 	}
 	logger.Info("Reconciling the Memcached resources...")
-	if e := r.generateMemcachedResources(miqInstance); e != nil {
-		return reconcile.Result{}, e
+	if e := r.reconcilePhase(miqInstance, "Memcached", r.generateMemcachedResources); e != nil {
+		return r.reconcileFailed(miqInstance, "MemcachedReconcileFailed", e)
 	}
 	if *miqInstance.Spec.DeployMessagingService {
 		logger.Info("Reconciling the Kafka resources...")
-		if e := r.generateKafkaResources(miqInstance); e != nil {
-			return reconcile.Result{}, e
+		if e := r.reconcilePhase(miqInstance, "Kafka", r.generateKafkaResources); e != nil {
+			return r.reconcileFailed(miqInstance, "KafkaReconcileFailed", e)
 		}
+	} else {
+		apimeta.RemoveStatusCondition(&miqInstance.Status.Conditions, "KafkaReconciled")
 	}
-	logger.Info("Reconciling the Orchestrator resources...")
-	if e := r.generateOrchestratorResources(miqInstance); e != nil {
-		return reconcile.Result{}, e
+	logger.Info("Reconciling the Orchestrator, Opentofu runner and application resources...")
+	if e := r.reconcilePhase(miqInstance, "Orchestrator", r.generateOpentofuRunnerResources, r.generateOrchestratorResources, r.manageApplicationResources); e != nil {
+		return r.reconcileFailed(miqInstance, "OrchestratorReconcileFailed", e)
 	}
-	logger.Info("Reconciling the application resources...")
-	if e := r.manageApplicationResources(miqInstance); e != nil {
-		return reconcile.Result{}, e
+
+	resizing, err := r.reportVolumeResizing(miqInstance)
+	if err != nil {
+		return r.reconcileFailed(miqInstance, "ReconcileFailed", err)
 	}
+
 	logger.Info("Reconciling the CR status...")
-	if err := r.updateManageIQStatus(miqInstance); err != nil {
+	ready, err := r.updateManageIQStatus(miqInstance, nil)
+	if err != nil {
 		reqLogger.Error(err, "Failed setting ManageIQ status")
 		return reconcile.Result{}, err
 	}
 
 	logger.Info("Reconcile complete.")
+	result := reconcile.Result{}
+	if (!ready && !*miqInstance.Spec.MaintenanceMode && !miqtool.Quiesced(miqInstance)) || resizing || miqtool.DatabaseSnapshotting(miqInstance) || miqtool.DatabaseCredentialsRotating(miqInstance) {
+		// Kafka, the Route, the volumes of Strimzi, the VolumeSnapshots and the orchestrator pods are not watched, check back until everything is up
+		result.RequeueAfter = notReadyRequeueInterval
+	}
+	for _, next := range []time.Time{r.nextDatabaseSnapshot(miqInstance), r.nextDatabaseCredentialsRotation(miqInstance)} {
+		if !next.IsZero() && (result.RequeueAfter == 0 || time.Until(next) < result.RequeueAfter) {
+			result.RequeueAfter = max(time.Until(next), time.Second)
+		}
+	}
+	return result, nil
+}
+
+// reconcilePhase runs the steps of a reconcile phase in order and records the
+// outcome in the <phase>Reconciled status condition
+func (r *ManageIQReconciler) reconcilePhase(cr *miqv1alpha1.ManageIQ, phase string, steps ...func(*miqv1alpha1.ManageIQ) error) error {
+	for _, step := range steps {
+		if err := step(cr); err != nil {
+			r.reportStatusCondition(cr, err.Error(), "ReconcileFailed", metav1.ConditionFalse, phase+"Reconciled")
+			return err
+		}
+	}
+
+	r.reportStatusCondition(cr, "", "Reconciled", metav1.ConditionTrue, phase+"Reconciled")
+	return nil
+}
+
+// reconcilePaused only reports the Paused condition, nothing else is changed until the
+// pause-reconciliation annotation is removed
+func (r *ManageIQReconciler) reconcilePaused(cr *miqv1alpha1.ManageIQ) (ctrl.Result, error) {
+	if condition := apimeta.FindStatusCondition(cr.Status.Conditions, conditionPaused); condition != nil && condition.Status == metav1.ConditionTrue {
+		return reconcile.Result{}, nil
+	}
+
+	r.reportStatusCondition(cr, fmt.Sprintf("Remove the %s annotation to resume", pauseReconciliationAnnotation), "ReconciliationPaused", metav1.ConditionTrue, conditionPaused)
+	if err := r.Client.Status().Update(context.TODO(), cr); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	r.Recorder.Event(cr, corev1.EventTypeNormal, "Paused", "Reconciliation has been paused")
 	return reconcile.Result{}, nil
 }
 
-func (r *ManageIQReconciler) updateManageIQStatus(cr *miqv1alpha1.ManageIQ) error {
+// reconcileFailed records the failure in the status before returning the error to the controller
+func (r *ManageIQReconciler) reconcileFailed(cr *miqv1alpha1.ManageIQ, reason string, err error) (ctrl.Result, error) {
+	failure := &metav1.Condition{Type: conditionReady, Status: metav1.ConditionFalse, Reason: reason, Message: err.Error()}
+	if _, statusErr := r.updateManageIQStatus(cr, failure); statusErr != nil {
+		logger.Error(statusErr, "Failed setting ManageIQ status")
+	}
+
+	return reconcile.Result{}, err
+}
+
+// updateManageIQStatus writes the conditions collected during the reconcile along with the
+// component status to the CR. The Ready condition is set from failure when the reconcile did not
+// complete, otherwise from the readiness of the components. It returns whether the CR is Ready.
+func (r *ManageIQReconciler) updateManageIQStatus(cr *miqv1alpha1.ManageIQ, failure *metav1.Condition) (bool, error) {
 	miqInstance := &miqv1alpha1.ManageIQ{}
 	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, miqInstance)
 	if err != nil {
 		logger.Error(err, "Error getting cluster cr")
-		return err
+		return false, err
 	}
 
+	// The spec is only applied by a complete reconcile, and the CR may have changed since it was read
+	if failure == nil {
+		miqInstance.Status.ObservedGeneration = cr.Generation
+	}
+	miqInstance.Status.Components = cr.Status.Components
+	miqInstance.Status.Drift = cr.Status.Drift
+	miqInstance.Status.PostgresqlClaimName = cr.Status.PostgresqlClaimName
+	miqInstance.Status.PostgresqlTuning = cr.Status.PostgresqlTuning
+	miqInstance.Status.DatabaseMaintenance = cr.Status.DatabaseMaintenance
+	miqInstance.Status.DatabaseSnapshots = cr.Status.DatabaseSnapshots
+	miqInstance.Status.DatabaseCredentialsRotationTime = cr.Status.DatabaseCredentialsRotationTime
+	miqInstance.Status.DatabaseCredentialsRotationRequest = cr.Status.DatabaseCredentialsRotationRequest
+	apimeta.RemoveStatusCondition(&miqInstance.Status.Conditions, conditionPaused)
+
 	// update status versions
 	r.reportOperatorVersions(miqInstance)
 
+	// carry over the phase conditions set during this reconcile
+	for _, condition := range cr.Status.Conditions {
+		if strings.HasSuffix(condition.Type, "Reconciled") || slices.Contains([]string{
+			conditionDatabaseReady,
+			miqtool.DatabaseCredentialsRotatingCondition,
+			miqtool.DatabaseSnapshottingCondition,
+			miqtool.PostgresqlUpgradingCondition,
+			miqtool.VolumeResizingCondition,
+		}, condition.Type) {
+			r.reportStatusCondition(miqInstance, condition.Message, condition.Reason, condition.Status, condition.Type)
+		}
+	}
+	for _, conditionType := range []string{"KafkaReconciled", "PgbouncerReconciled", conditionDatabaseReady, miqtool.DatabaseSnapshottingCondition} {
+		if apimeta.FindStatusCondition(cr.Status.Conditions, conditionType) == nil {
+			apimeta.RemoveStatusCondition(&miqInstance.Status.Conditions, conditionType)
+		}
+	}
+
 	// update status condition
-	deployments := []string{"httpd", "memcached", "orchestrator", "postgresql"}
+	deployments := []string{"httpd", "memcached", "orchestrator", "pgbouncer", "postgresql"}
 	for _, deploymentName := range deployments {
-		if object := FindDeployment(cr, r.Client, deploymentName); object != nil {
+		if object := FindDeployment(cr, r.Client, miqtool.ResourceName(cr, deploymentName)); object != nil {
 			deploymentStatusConditions := object.Status.Conditions
 			// deployment status can have multiple condition types like ReplicaFailure, Progressing, Available but
 			// in our IMInstall CR we just want to show the latest deployment condition type
@@ -204,7 +338,7 @@ func (r *ManageIQReconciler) updateManageIQStatus(cr *miqv1alpha1.ManageIQ) erro
 	// update status endpoint info
 	ingresses := []string{"httpd"}
 	for _, ingressName := range ingresses {
-		if object := FindIngress(cr, r.Client, ingressName); object != nil {
+		if object := FindIngress(cr, r.Client, miqtool.ResourceName(cr, ingressName)); object != nil {
 			if ownerReferences := object.OwnerReferences; len(ownerReferences) != 0 {
 				if object.Spec.TLS != nil ||
 					object.Spec.Rules != nil {
@@ -233,11 +367,97 @@ func (r *ManageIQReconciler) updateManageIQStatus(cr *miqv1alpha1.ManageIQ) erro
 			}
 		}
 	}
+
+	// update the aggregated Ready condition
+	ready := false
+	if failure != nil {
+		r.reportStatusCondition(miqInstance, failure.Message, failure.Reason, failure.Status, failure.Type)
+	} else if miqtool.Quiesced(miqInstance) {
+		r.reportStatusCondition(miqInstance, "The application is scaled down for a restore", reasonQuiesced, metav1.ConditionFalse, conditionReady)
+	} else if miqtool.PostgresqlUpgrading(miqInstance) {
+		r.reportStatusCondition(miqInstance, "The application is scaled down while the database is upgraded", reasonPostgresqlUpgrading, metav1.ConditionFalse, conditionReady)
+	} else if miqtool.DatabaseSnapshotPaused(miqInstance) {
+		r.reportStatusCondition(miqInstance, "The orchestrator is scaled down while the database is snapshotted", reasonDatabaseSnapshotting, metav1.ConditionFalse, conditionReady)
+	} else if miqInstance.Spec.MaintenanceMode != nil && *miqInstance.Spec.MaintenanceMode {
+		r.reportStatusCondition(miqInstance, "The orchestrator, httpd and memcached are scaled down", "MaintenanceMode", metav1.ConditionFalse, conditionReady)
+	} else if notReady := r.notReadyComponents(miqInstance); len(notReady) > 0 {
+		r.reportStatusCondition(miqInstance, "Waiting for: "+strings.Join(notReady, ", "), "ComponentsNotReady", metav1.ConditionFalse, conditionReady)
+	} else {
+		ready = true
+		r.reportStatusCondition(miqInstance, "All components are ready", "ComponentsReady", metav1.ConditionTrue, conditionReady)
+	}
+
 	if err := r.Client.Status().Update(context.TODO(), miqInstance); err != nil {
 		logger.Error(err, "Error updating status")
-		return err
+		return false, err
 	}
-	return nil
+	return ready, nil
+}
+
+// notReadyComponents returns the names of the deployed components which are not ready yet
+func (r *ManageIQReconciler) notReadyComponents(cr *miqv1alpha1.ManageIQ) []string {
+	notReady := []string{}
+
+	deployments := []string{"httpd", "memcached", "orchestrator"}
+	if miqtool.PgbouncerEnabled(cr) {
+		deployments = append(deployments, "pgbouncer")
+	}
+	if miqtool.InClusterPostgresqlHostname(cr, getSecretKeyValue(r.Client, cr.Namespace, cr.Spec.DatabaseSecret, "hostname")) {
+		if !miqtool.PostgresqlReplicated(cr) {
+			deployments = append(deployments, "postgresql")
+		} else if !r.postgresqlStatefulSetReady(cr) {
+			notReady = append(notReady, "postgresql")
+		}
+	} else if !apimeta.IsStatusConditionTrue(cr.Status.Conditions, conditionDatabaseReady) {
+		notReady = append(notReady, "database")
+	}
+	for _, deploymentName := range deployments {
+		object := FindDeployment(cr, r.Client, miqtool.ResourceName(cr, deploymentName))
+		if object == nil {
+			notReady = append(notReady, deploymentName)
+			continue
+		}
+		available := FindDeploymentStatusCondition(object.Status.Conditions, appsv1.DeploymentAvailable)
+		if available == nil || available.Status != corev1.ConditionTrue {
+			notReady = append(notReady, deploymentName)
+		}
+	}
+
+	if cr.Spec.DeployMessagingService != nil && *cr.Spec.DeployMessagingService && !kafkaReady(miqutilsv1alpha1.FindKafka(r.Client, r.Scheme, cr.Namespace, cr.Spec.AppName)) {
+		notReady = append(notReady, "kafka")
+	}
+
+	if err := r.Client.List(context.TODO(), &routev1.RouteList{}); err == nil {
+		route := &routev1.Route{}
+		if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "httpd")}, route); err != nil || !routeAdmitted(route) {
+			notReady = append(notReady, "route")
+		}
+	}
+
+	return notReady
+}
+
+func kafkaReady(kafka *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(kafka.Object, "status", "conditions")
+	for _, c := range conditions {
+		if condition, ok := c.(map[string]interface{}); ok && condition["type"] == "Ready" && condition["status"] == "True" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func routeAdmitted(route *routev1.Route) bool {
+	for _, ingress := range route.Status.Ingress {
+		for _, condition := range ingress.Conditions {
+			if condition.Type == routev1.RouteAdmitted && condition.Status == corev1.ConditionTrue {
+				return true
+			}
+		}
+	}
+
+	return false
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -245,12 +465,17 @@ func (r *ManageIQReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	controller := ctrl.NewControllerManagedBy(mgr).
 		For(&miqv1alpha1.ManageIQ{}).
 		Owns(&appsv1.Deployment{}).
+		Owns(&appsv1.StatefulSet{}).
+		Owns(&autoscalingv2.HorizontalPodAutoscaler{}).
+		Owns(&batchv1.CronJob{}).
+		Owns(&batchv1.Job{}).
 		Owns(&corev1.ConfigMap{}).
 		Owns(&corev1.PersistentVolumeClaim{}).
 		Owns(&corev1.Secret{}).
 		Owns(&corev1.Service{}).
 		Owns(&networkingv1.Ingress{}).
 		Owns(&networkingv1.NetworkPolicy{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
 		Watches(&corev1.Secret{}, handler.TypedEnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
 			manageiqs := &miqv1alpha1.ManageIQList{}
 			client := mgr.GetClient()
@@ -275,6 +500,22 @@ func (r *ManageIQReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				}
 			}
 			return reconcileRequests
+		})).
+		Watches(&corev1.ConfigMap{}, handler.TypedEnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+			manageiqs := &miqv1alpha1.ManageIQList{}
+			err := mgr.GetClient().List(context.TODO(), manageiqs, client.InNamespace(obj.GetNamespace()))
+			if err != nil {
+				return []reconcile.Request{}
+			}
+
+			var reconcileRequests []reconcile.Request
+
+			for _, miq := range manageiqs.Items {
+				if miq.Spec.PostgresqlConfigMap == obj.GetName() {
+					reconcileRequests = append(reconcileRequests, reconcile.Request{NamespacedName: types.NamespacedName{Name: miq.Name, Namespace: miq.Namespace}})
+				}
+			}
+			return reconcileRequests
 		}))
 
 	if cfg, err := config.GetConfig(); err == nil {
@@ -298,6 +539,32 @@ func (r *ManageIQReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 var logger = log.Log.WithName("controller_manageiq")
 
+const (
+	conditionDatabaseReady = "DatabaseReady"
+	conditionPaused        = "Paused"
+	conditionReady         = "Ready"
+
+	reasonDatabaseSnapshotting = "DatabaseSnapshotting"
+	reasonPostgresqlUpgrading  = "PostgresqlUpgrading"
+	reasonQuiesced             = "Quiesced"
+
+	manageiqFinalizer = "manageiq.org/finalizer"
+
+	pauseReconciliationAnnotation = "manageiq.org/pause-reconciliation"
+
+	componentDatabaseMaintenance = "database-maintenance"
+	componentHttpdAuth           = "httpd-auth"
+	componentHttpdAutoscaler     = "httpd-autoscaler"
+	componentKafka               = "kafka"
+	componentPgbouncer           = "pgbouncer"
+	componentPostgresql          = "postgresql"
+
+	notReadyRequeueInterval = 30 * time.Second
+)
+
+// optionalComponents are the components whose resources are pruned once they are disabled
+var optionalComponents = []string{componentDatabaseMaintenance, componentHttpdAuth, componentHttpdAutoscaler, componentKafka, componentPgbouncer, componentPostgresql}
+
 func FindDeployment(cr *miqv1alpha1.ManageIQ, client client.Client, name string) *appsv1.Deployment {
 	namespacedName := types.NamespacedName{Namespace: cr.Namespace, Name: name}
 	object := &appsv1.Deployment{}
@@ -359,10 +626,11 @@ func (r *ManageIQReconciler) reportOperatorVersions(miqInstance *miqv1alpha1.Man
 
 func (r *ManageIQReconciler) reportStatusCondition(miqInstance *miqv1alpha1.ManageIQ, statusMessage string, reason string, conditionStatus metav1.ConditionStatus, statusType string) {
 	apimeta.SetStatusCondition(&miqInstance.Status.Conditions, metav1.Condition{
-		Type:    statusType,
-		Status:  conditionStatus,
-		Reason:  reason,
-		Message: statusMessage,
+		Type:               statusType,
+		Status:             conditionStatus,
+		ObservedGeneration: miqInstance.Generation,
+		Reason:             reason,
+		Message:            statusMessage,
 	})
 }
 
@@ -382,12 +650,354 @@ func (r *ManageIQReconciler) reportEndpointInfo(miqInstance *miqv1alpha1.ManageI
 	}
 }
 
+// recordReconcileEvent emits an Event on the CR when one of its components has been created or updated
+func (r *ManageIQReconciler) recordReconcileEvent(cr *miqv1alpha1.ManageIQ, obj client.Object, result controllerutil.OperationResult) {
+	var reason string
+	switch result {
+	case controllerutil.OperationResultCreated:
+		reason = "Created"
+	case controllerutil.OperationResultUpdated:
+		reason = "Updated"
+	default:
+		return
+	}
+
+	r.Recorder.Eventf(cr, corev1.EventTypeNormal, reason, "%s %s has been %s", r.objectKind(obj), obj.GetName(), result)
+}
+
+func (r *ManageIQReconciler) objectKind(obj client.Object) string {
+	return objectKind(obj, r.Scheme)
+}
+
+func objectKind(obj client.Object, scheme *runtime.Scheme) string {
+	if gvk, err := apiutil.GVKForObject(obj, scheme); err == nil {
+		return gvk.Kind
+	}
+
+	return obj.GetObjectKind().GroupVersionKind().Kind
+}
+
+// finalizeManageIQ applies the deletion policy before letting the garbage collector remove the
+// owned resources. With Retain the database PVC, the database secret and the encryption key are
+// orphaned, with Snapshot the teardown waits for a final backup of the database.
+func (r *ManageIQReconciler) finalizeManageIQ(cr *miqv1alpha1.ManageIQ) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(cr, manageiqFinalizer) {
+		return reconcile.Result{}, nil
+	}
+
+	switch cr.Spec.DeletionPolicy {
+	case miqv1alpha1.DeletionPolicyRetain:
+		if err := r.releaseObjects(cr, r.dataObjects(cr)...); err != nil {
+			return reconcile.Result{}, err
+		}
+	case miqv1alpha1.DeletionPolicySnapshot:
+		if done, err := r.finalBackup(cr); err != nil {
+			return reconcile.Result{}, err
+		} else if !done {
+			return reconcile.Result{RequeueAfter: notReadyRequeueInterval}, nil
+		}
+	}
+
+	controllerutil.RemoveFinalizer(cr, manageiqFinalizer)
+	if err := r.Client.Update(context.TODO(), cr); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	logger.Info("CR has been finalized", "deletionPolicy", cr.Spec.DeletionPolicy)
+	return reconcile.Result{}, nil
+}
+
+// dataObjects are the objects holding the application data
+func (r *ManageIQReconciler) dataObjects(cr *miqv1alpha1.ManageIQ) []client.Object {
+	objects := append(r.postgresqlClaims(cr),
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "app-secrets")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: cr.Spec.DatabaseSecret}},
+	)
+
+	snapshots, err := r.databaseSnapshots(cr)
+	if err != nil {
+		logger.Error(err, "Failed listing the database snapshots")
+	}
+	for i := range snapshots {
+		objects = append(objects, &snapshots[i])
+	}
+
+	return objects
+}
+
+// releaseObjects removes the owner reference from the objects so that they survive the deletion of the CR
+func (r *ManageIQReconciler) releaseObjects(cr *miqv1alpha1.ManageIQ, objects ...client.Object) error {
+	for _, obj := range objects {
+		if err := r.Client.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj); errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if !metav1.IsControlledBy(obj, cr) {
+			continue
+		}
+
+		if err := controllerutil.RemoveControllerReference(cr, obj, r.Scheme); err != nil {
+			return err
+		}
+		if err := r.Client.Update(context.TODO(), obj); err != nil {
+			return err
+		}
+
+		logger.Info("Object has been retained", "kind", r.objectKind(obj), "name", obj.GetName())
+		r.Recorder.Eventf(cr, corev1.EventTypeNormal, "Retained", "%s %s has been retained", r.objectKind(obj), obj.GetName())
+	}
+
+	return nil
+}
+
+// finalBackup dumps the database to a PVC which is not owned by the CR and reports whether the
+// dump has completed. A failed dump blocks the deletion until the deletion policy is changed.
+func (r *ManageIQReconciler) finalBackup(cr *miqv1alpha1.ManageIQ) (bool, error) {
+	pvc, mutateFunc := miqtool.FinalBackupPVC(cr)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, pvc, mutateFunc); err != nil {
+		return false, err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("PVC has been reconciled", "component", "final-backup", "result", result)
+		r.recordReconcileEvent(cr, pvc, result)
+	}
+
+	job, mutateFunc := miqtool.FinalBackupJob(cr, r.Client, r.Scheme)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, job, mutateFunc); err != nil {
+		return false, err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("Job has been reconciled", "component", "final-backup", "result", result)
+		r.recordReconcileEvent(cr, job, result)
+	}
+
+	var message, reason string
+	var status metav1.ConditionStatus
+	switch {
+	case job.Status.Succeeded > 0:
+		message, reason, status = fmt.Sprintf("Database has been dumped to PVC %s", pvc.Name), "BackupCompleted", metav1.ConditionTrue
+		r.Recorder.Eventf(cr, corev1.EventTypeNormal, "FinalBackupCompleted", "Final database backup has been written to PVC %s", pvc.Name)
+	case jobFailed(job):
+		message, reason, status = fmt.Sprintf("Job %s failed, set the deletionPolicy to Delete or Retain to continue the deletion", job.Name), "BackupFailed", metav1.ConditionFalse
+		r.Recorder.Eventf(cr, corev1.EventTypeWarning, "FinalBackupFailed", "Final database backup Job %s failed, the deletion is blocked", job.Name)
+	default:
+		message, reason, status = fmt.Sprintf("Waiting for Job %s", job.Name), "BackupRunning", metav1.ConditionFalse
+	}
+
+	r.reportStatusCondition(cr, message, reason, status, "FinalBackup")
+	if err := r.Client.Status().Update(context.TODO(), cr); err != nil {
+		return false, err
+	}
+
+	return job.Status.Succeeded > 0, nil
+}
+
+// enabledComponents returns the optional components which are deployed for the current spec
+func (r *ManageIQReconciler) enabledComponents(cr *miqv1alpha1.ManageIQ) []string {
+	components := []string{}
+
+	if miqtool.DatabaseMaintenanceEnabled(cr) {
+		components = append(components, componentDatabaseMaintenance)
+	}
+	if miqtool.PrivilegedHttpd(cr.Spec.HttpdAuthenticationType) {
+		components = append(components, componentHttpdAuth)
+	}
+	if miqtool.HttpdAutoscaleEnabled(cr) {
+		components = append(components, componentHttpdAutoscaler)
+	}
+	if *cr.Spec.DeployMessagingService {
+		components = append(components, componentKafka)
+	}
+	if miqtool.PgbouncerEnabled(cr) {
+		components = append(components, componentPgbouncer)
+	}
+	// The in-cluster database is only disabled by the external database settings of the CR, a database
+	// secret pointing at another host leaves it alone
+	if !miqtool.ExternalDatabase(cr) {
+		components = append(components, componentPostgresql)
+	}
+
+	return components
+}
+
+// pruneDisabledComponents removes the resources of the components recorded in the status which are
+// no longer enabled, then records the enabled components. Until the components have been recorded,
+// e.g. on an upgrade, all the disabled components are pruned. The status is left untouched on
+// failure so that the pruning is retried.
+func (r *ManageIQReconciler) pruneDisabledComponents(cr *miqv1alpha1.ManageIQ) error {
+	enabled := r.enabledComponents(cr)
+
+	components := cr.Status.Components
+	if components == nil {
+		components = optionalComponents
+	}
+
+	for _, component := range components {
+		if slices.Contains(enabled, component) {
+			continue
+		}
+
+		var err error
+		switch component {
+		case componentDatabaseMaintenance:
+			err = r.pruneDatabaseMaintenanceResources(cr)
+		case componentHttpdAuth:
+			err = r.pruneHttpdAuthResources(cr)
+		case componentHttpdAutoscaler:
+			err = r.pruneHttpdAutoscalerResources(cr)
+		case componentKafka:
+			err = r.pruneKafkaResources(cr)
+		case componentPgbouncer:
+			err = r.prunePgbouncerResources(cr)
+		case componentPostgresql:
+			err = r.prunePostgresqlResources(cr)
+		}
+		if err != nil {
+			return err
+		}
+
+		logger.Info("Component has been pruned", "component", component)
+		if slices.Contains(cr.Status.Components, component) {
+			r.Recorder.Eventf(cr, corev1.EventTypeNormal, "Pruned", "Resources of the disabled %s component have been removed", component)
+		}
+	}
+
+	cr.Status.Components = enabled
+	return nil
+}
+
+func (r *ManageIQReconciler) pruneHttpdAuthResources(cr *miqv1alpha1.ManageIQ) error {
+	serviceAccount, _ := miqtool.HttpdServiceAccount(cr, r.Scheme)
+	dbusAPIService, _ := miqtool.HttpdDbusAPIService(cr, r.Scheme)
+	authConfigMap, _ := miqtool.HttpdAuthConfigMap(cr, r.Scheme)
+
+	return r.pruneObjects(cr, componentHttpdAuth, serviceAccount, dbusAPIService, authConfigMap)
+}
+
+func (r *ManageIQReconciler) pruneHttpdAutoscalerResources(cr *miqv1alpha1.ManageIQ) error {
+	hpa, _ := miqtool.HttpdHorizontalPodAutoscaler(cr, r.Scheme)
+
+	return r.pruneObjects(cr, componentHttpdAutoscaler, hpa)
+}
+
+func (r *ManageIQReconciler) pruneKafkaResources(cr *miqv1alpha1.ManageIQ) error {
+	objects := []client.Object{}
+	for _, topic := range miqkafka.KafkaTopicNames() {
+		kafkaTopicCR, _ := miqkafka.KafkaTopic(cr, r.Scheme, topic)
+		objects = append(objects, kafkaTopicCR)
+	}
+	kafkaUserCR, _ := miqkafka.KafkaUser(cr, r.Scheme)
+	kafkaClusterCR, _ := miqkafka.KafkaCluster(cr, r.Client, r.Scheme, r.Recorder)
+	kafkaCACert, _ := miqkafka.KafkaCASecret(cr, r.Client, r.Scheme, "cert")
+	kafkaCAKey, _ := miqkafka.KafkaCASecret(cr, r.Client, r.Scheme, "key")
+	networkPolicyAllowKafka, _ := miqtool.NetworkPolicyAllowKafka(cr, r.Scheme, &r.Client)
+	networkPolicyAllowZookeeper, _ := miqtool.NetworkPolicyAllowZookeeper(cr, r.Scheme, &r.Client)
+	objects = append(objects, kafkaUserCR, kafkaClusterCR, kafkaCACert, kafkaCAKey, networkPolicyAllowKafka, networkPolicyAllowZookeeper)
+
+	if err := r.pruneObjects(cr, componentKafka, objects...); err != nil {
+		return err
+	}
+
+	// Removing the Subscription leaves the Strimzi operator running, its CSV has to go as well
+	kafkaSubscription, _ := miqkafka.KafkaInstall(cr, r.Scheme)
+	if err := r.Client.Get(context.TODO(), client.ObjectKeyFromObject(kafkaSubscription), kafkaSubscription); err == nil && metav1.IsControlledBy(kafkaSubscription, cr) && kafkaSubscription.Status.InstalledCSV != "" {
+		csv := &olmv1alpha1.ClusterServiceVersion{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: kafkaSubscription.Status.InstalledCSV}}
+		if err := r.Client.Delete(context.TODO(), csv); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		logger.Info("Object has been pruned", "component", componentKafka, "kind", "ClusterServiceVersion", "name", csv.Name)
+	}
+
+	kafkaOperatorGroup, _ := miqkafka.KafkaOperatorGroup(cr, r.Scheme)
+	return r.pruneObjects(cr, componentKafka, kafkaSubscription, kafkaOperatorGroup)
+}
+
+func (r *ManageIQReconciler) pruneDatabaseMaintenanceResources(cr *miqv1alpha1.ManageIQ) error {
+	objects := []client.Object{}
+	for _, task := range miqtool.DatabaseMaintenanceTasks {
+		cronJob, _ := miqtool.DatabaseMaintenanceCronJob(cr, task, r.Client, r.Scheme)
+		objects = append(objects, cronJob)
+	}
+	networkPolicyAllowPostgresMaintenance, _ := miqtool.NetworkPolicyAllowPostgresMaintenance(cr, r.Scheme)
+	objects = append(objects, networkPolicyAllowPostgresMaintenance)
+
+	return r.pruneObjects(cr, componentDatabaseMaintenance, objects...)
+}
+
+func (r *ManageIQReconciler) prunePgbouncerResources(cr *miqv1alpha1.ManageIQ) error {
+	service, _ := miqtool.PgbouncerService(cr, r.Scheme)
+	pdb, _ := miqtool.PgbouncerPodDisruptionBudget(cr, r.Scheme)
+	networkPolicyAllowPgbouncer, _ := miqtool.NetworkPolicyAllowPgbouncer(cr, r.Scheme, &r.Client)
+	objects := []client.Object{
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "pgbouncer")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "pgbouncer-secrets")}},
+		service,
+		pdb,
+		networkPolicyAllowPgbouncer,
+	}
+
+	return r.pruneObjects(cr, componentPgbouncer, objects...)
+}
+
+// prunePostgresqlResources removes the in-cluster database once the CR selects an external database
+// with ExternalDatabaseHost. The PVCs are released whatever the deletion policy, they may hold the only copy of
+// the data, e.g. the pg_upgrade backup or a restored snapshot.
+func (r *ManageIQReconciler) prunePostgresqlResources(cr *miqv1alpha1.ManageIQ) error {
+	objects := []client.Object{
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "postgresql")}},
+		&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "postgresql")}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "postgresql")}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "postgresql-headless")}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "postgresql-readonly")}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "postgresql-configs")}},
+		&policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "postgresql")}},
+	}
+	if err := r.pruneObjects(cr, componentPostgresql, objects...); err != nil {
+		return err
+	}
+
+	return r.releaseObjects(cr, r.postgresqlClaims(cr)...)
+}
+
+// pruneObjects deletes the objects of a disabled component which are controlled by the CR
+func (r *ManageIQReconciler) pruneObjects(cr *miqv1alpha1.ManageIQ, component string, objects ...client.Object) error {
+	for _, obj := range objects {
+		if err := r.Client.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj); errors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if !metav1.IsControlledBy(obj, cr) {
+			continue
+		}
+
+		if err := r.Client.Delete(context.TODO(), obj, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		logger.Info("Object has been pruned", "component", component, "kind", r.objectKind(obj), "name", obj.GetName())
+	}
+
+	return nil
+}
+
+func jobFailed(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (r *ManageIQReconciler) generateDefaultServiceAccount(cr *miqv1alpha1.ManageIQ) error {
 	serviceAccount, mutateFunc := miqtool.DefaultServiceAccount(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, serviceAccount, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, serviceAccount, r.detectDrift(cr, serviceAccount, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Service Account has been reconciled", "component", "app", "result", result)
+		r.recordReconcileEvent(cr, serviceAccount, result)
 	}
 
 	return nil
@@ -398,10 +1008,11 @@ func (r *ManageIQReconciler) generateHttpdResources(cr *miqv1alpha1.ManageIQ) er
 
 	if privileged {
 		httpdServiceAccount, mutateFunc := miqtool.HttpdServiceAccount(cr, r.Scheme)
-		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdServiceAccount, mutateFunc); err != nil {
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdServiceAccount, r.detectDrift(cr, httpdServiceAccount, mutateFunc)); err != nil {
 			return err
 		} else if result != controllerutil.OperationResultNone {
 			logger.Info("ServiceAccount has been reconciled", "component", "httpd", "result", result)
+			r.recordReconcileEvent(cr, httpdServiceAccount, result)
 		}
 	}
 
@@ -409,63 +1020,71 @@ func (r *ManageIQReconciler) generateHttpdResources(cr *miqv1alpha1.ManageIQ) er
 	if err != nil {
 		return err
 	}
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdConfigMap, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdConfigMap, r.detectDrift(cr, httpdConfigMap, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("ConfigMap has been reconciled", "component", "httpd", "result", result)
+		r.recordReconcileEvent(cr, httpdConfigMap, result)
 	}
 
 	if cr.Spec.HttpdAuthenticationType != "internal" && cr.Spec.HttpdAuthenticationType != "openid-connect" {
 		httpdAuthConfigMap, mutateFunc := miqtool.HttpdAuthConfigMap(cr, r.Scheme)
-		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdAuthConfigMap, mutateFunc); err != nil {
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdAuthConfigMap, r.detectDrift(cr, httpdAuthConfigMap, mutateFunc)); err != nil {
 			return err
 		} else if result != controllerutil.OperationResultNone {
 			logger.Info("ConfigMap has been reconciled", "component", "httpd-auth", "result", result)
+			r.recordReconcileEvent(cr, httpdAuthConfigMap, result)
 		}
 	}
 
 	if httpdAuthConfig, mutateFunc := miqtool.HttpdAuthConfig(r.Client, cr, r.Scheme); httpdAuthConfig != nil {
-		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdAuthConfig, mutateFunc); err != nil {
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdAuthConfig, r.detectDrift(cr, httpdAuthConfig, mutateFunc)); err != nil {
 			return err
 		} else if result != controllerutil.OperationResultNone {
 			logger.Info("Secret has been reconciled", "component", "httpd-auth", "result", result)
+			r.recordReconcileEvent(cr, httpdAuthConfig, result)
 		}
 	}
 
 	uiService, mutateFunc := miqtool.UIService(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, uiService, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, uiService, r.detectDrift(cr, uiService, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Service has been reconciled", "component", "httpd", "service", "ui", "result", result)
+		r.recordReconcileEvent(cr, uiService, result)
 	}
 
 	webService, mutateFunc := miqtool.WebService(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, webService, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, webService, r.detectDrift(cr, webService, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Service has been reconciled", "component", "httpd", "service", "web_service", "result", result)
+		r.recordReconcileEvent(cr, webService, result)
 	}
 
 	remoteConsoleService, mutateFunc := miqtool.RemoteConsoleService(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, remoteConsoleService, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, remoteConsoleService, r.detectDrift(cr, remoteConsoleService, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Service has been reconciled", "component", "httpd", "service", "remote_console_service", "result", result)
+		r.recordReconcileEvent(cr, remoteConsoleService, result)
 	}
 
 	httpdService, mutateFunc := miqtool.HttpdService(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdService, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdService, r.detectDrift(cr, httpdService, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Service has been reconciled", "component", "httpd", "result", result)
+		r.recordReconcileEvent(cr, httpdService, result)
 	}
 
 	if privileged {
 		httpdDbusAPIService, mutateFunc := miqtool.HttpdDbusAPIService(cr, r.Scheme)
-		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdDbusAPIService, mutateFunc); err != nil {
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdDbusAPIService, r.detectDrift(cr, httpdDbusAPIService, mutateFunc)); err != nil {
 			return err
 		} else if result != controllerutil.OperationResultNone {
 			logger.Info("Service has been reconciled", "component", "httpd", "service", "dbus_api_service", "result", result)
+			r.recordReconcileEvent(cr, httpdDbusAPIService, result)
 		}
 	}
 
@@ -473,25 +1092,45 @@ func (r *ManageIQReconciler) generateHttpdResources(cr *miqv1alpha1.ManageIQ) er
 		return err
 	}
 
+	httpdPodDisruptionBudget, mutateFunc := miqtool.HttpdPodDisruptionBudget(cr, r.Scheme)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdPodDisruptionBudget, r.detectDrift(cr, httpdPodDisruptionBudget, mutateFunc)); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("PodDisruptionBudget has been reconciled", "component", "httpd", "result", result)
+		r.recordReconcileEvent(cr, httpdPodDisruptionBudget, result)
+	}
+
+	if miqtool.HttpdAutoscaleEnabled(cr) {
+		httpdHorizontalPodAutoscaler, mutateFunc := miqtool.HttpdHorizontalPodAutoscaler(cr, r.Scheme)
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdHorizontalPodAutoscaler, r.detectDrift(cr, httpdHorizontalPodAutoscaler, mutateFunc)); err != nil {
+			return err
+		} else if result != controllerutil.OperationResultNone {
+			logger.Info("HorizontalPodAutoscaler has been reconciled", "component", componentHttpdAutoscaler, "result", result)
+			r.recordReconcileEvent(cr, httpdHorizontalPodAutoscaler, result)
+		}
+	}
+
 	// Prefer routes if available, otherwise use ingress
 	if err := r.Client.List(context.TODO(), &routev1.RouteList{}); err == nil {
 		httpdRoute, mutateFunc := miqtool.Route(cr, r.Scheme, r.Client)
-		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdRoute, mutateFunc); err != nil {
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdRoute, r.detectDrift(cr, httpdRoute, mutateFunc)); err != nil {
 			return err
 		} else if result != controllerutil.OperationResultNone {
 			logger.Info("Route has been reconciled", "component", "httpd", "result", result)
+			r.recordReconcileEvent(cr, httpdRoute, result)
 		}
 
 		ingress := &networkingv1.Ingress{}
-		if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: "httpd"}, ingress); err == nil {
+		if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "httpd")}, ingress); err == nil {
 			r.Client.Delete(context.TODO(), ingress)
 		}
 	} else {
 		httpdIngress, mutateFunc := miqtool.Ingress(cr, r.Scheme)
-		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdIngress, mutateFunc); err != nil {
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdIngress, r.detectDrift(cr, httpdIngress, mutateFunc)); err != nil {
 			return err
 		} else if result != controllerutil.OperationResultNone {
 			logger.Info("Ingress has been reconciled", "component", "httpd", "result", result)
+			r.recordReconcileEvent(cr, httpdIngress, result)
 		}
 	}
 
@@ -504,10 +1143,11 @@ func (r *ManageIQReconciler) reconcileHttpdDeployment(cr *miqv1alpha1.ManageIQ)
 		return err
 	}
 
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdDeployment, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, httpdDeployment, r.detectDrift(cr, httpdDeployment, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Deployment has been reconciled", "component", "httpd", "result", result)
+		r.recordReconcileEvent(cr, httpdDeployment, result)
 	}
 	return nil
 }
@@ -518,17 +1158,27 @@ func (r *ManageIQReconciler) generateMemcachedResources(cr *miqv1alpha1.ManageIQ
 		return err
 	}
 
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, deployment, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, deployment, r.detectDrift(cr, deployment, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Deployment has been reconciled", "component", "memcached", "result", result)
+		r.recordReconcileEvent(cr, deployment, result)
 	}
 
 	service, mutateFunc := miqtool.NewMemcachedService(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, service, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, service, r.detectDrift(cr, service, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Service has been reconciled", "component", "memcached", "result", result)
+		r.recordReconcileEvent(cr, service, result)
+	}
+
+	pdb, mutateFunc := miqtool.MemcachedPodDisruptionBudget(cr, r.Scheme)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, pdb, r.detectDrift(cr, pdb, mutateFunc)); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("PodDisruptionBudget has been reconciled", "component", "memcached", "result", result)
+		r.recordReconcileEvent(cr, pdb, result)
 	}
 
 	return nil
@@ -536,47 +1186,110 @@ func (r *ManageIQReconciler) generateMemcachedResources(cr *miqv1alpha1.ManageIQ
 
 func (r *ManageIQReconciler) generateOpentofuRunnerResources(cr *miqv1alpha1.ManageIQ) error {
 	tfRunnerService, mutateFunc := miqtool.TfRunnerService(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, tfRunnerService, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, tfRunnerService, r.detectDrift(cr, tfRunnerService, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Service has been reconciled", "component", "opentofu-runner", "result", result)
+		r.recordReconcileEvent(cr, tfRunnerService, result)
 	}
 	return nil
 }
 
 func (r *ManageIQReconciler) generatePostgresqlResources(cr *miqv1alpha1.ManageIQ) error {
+	if miqtool.ExternalDatabase(cr) && FindSecret(cr, r.Client, cr.Spec.DatabaseSecret) == nil {
+		return fmt.Errorf("Secret %s with the dbname, username and password of the external database %s does not exist", cr.Spec.DatabaseSecret, cr.Spec.ExternalDatabaseHost)
+	}
+
 	secret, mutateFunc := miqtool.ManagePostgresqlSecret(cr, r.Client, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, secret, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, secret, r.detectDrift(cr, secret, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Secret has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, secret, result)
 	}
 
 	hostName := string(secret.Data["hostname"])
-	if hostName != "postgresql" {
+	if !miqtool.InClusterPostgresqlHostname(cr, hostName) {
 		logger.Info("External PostgreSQL Database selected, skipping postgresql service reconciliation", "hostname", hostName)
-		return nil
+		r.Recorder.Eventf(cr, corev1.EventTypeNormal, "ExternalDatabase", "External PostgreSQL database %s selected, the postgresql resources are not managed", hostName)
+		cr.Status.PostgresqlTuning = nil
+		return r.checkExternalDatabase(cr)
+	}
+
+	apimeta.RemoveStatusCondition(&cr.Status.Conditions, conditionDatabaseReady)
+	preflightJob, _ := miqtool.DatabasePreflightJob(cr, "", r.Client, r.Scheme)
+	if err := r.deleteJobs(preflightJob); err != nil {
+		return err
+	}
+
+	if tuning := miqtool.PostgresqlTuning(cr); !maps.Equal(tuning, cr.Status.PostgresqlTuning) {
+		logger.Info("PostgreSQL parameters have been tuned", "component", "postgresql", "parameters", tuning)
+		cr.Status.PostgresqlTuning = tuning
 	}
 
 	configMap, mutateFunc := miqtool.PostgresqlConfigMap(cr, r.Client, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, configMap, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, configMap, r.detectDrift(cr, configMap, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("ConfigMap has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, configMap, result)
+	}
+
+	pdb, mutateFunc := miqtool.PostgresqlPodDisruptionBudget(cr, r.Scheme)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, pdb, r.detectDrift(cr, pdb, mutateFunc)); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("PodDisruptionBudget has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, pdb, result)
+	}
+
+	if migrated, err := r.migratePostgresqlMode(cr); err != nil || !migrated {
+		return err
+	}
+
+	if upgraded, err := r.upgradePostgresql(cr); err != nil || !upgraded {
+		return err
+	}
+
+	if miqtool.PostgresqlReplicated(cr) {
+		// The data has been copied to the claims of the StatefulSet
+		cr.Status.PostgresqlClaimName = ""
+		return r.generatePostgresqlReplicatedResources(cr)
+	}
+
+	if cr.Spec.DatabaseSnapshotRestore != "" {
+		if restored, err := r.restoreDatabaseSnapshot(cr); err != nil || !restored {
+			return err
+		}
 	}
 
 	pvc, mutateFunc := miqtool.PostgresqlPVC(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, pvc, mutateFunc); err != nil {
+	if err := r.expandVolumeClaims(cr, "DatabaseVolumeCapacity", cr.Spec.DatabaseVolumeCapacity, pvc.DeepCopy()); err != nil {
+		return err
+	}
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, pvc, r.detectDrift(cr, pvc, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("PVC has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, pvc, result)
 	}
+	cr.Status.PostgresqlClaimName = pvc.Name
 
 	service, mutateFunc := miqtool.PostgresqlService(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, service, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, service, r.detectDrift(cr, service, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Service has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, service, result)
+	}
+	if err := r.reconcileLegacyPostgresqlService(cr); err != nil {
+		return err
+	}
+
+	headlessService, _ := miqtool.PostgresqlHeadlessService(cr, r.Scheme)
+	readonlyService, _ := miqtool.PostgresqlReadonlyService(cr, r.Scheme)
+	if err := r.pruneObjects(cr, componentPostgresql, headlessService, readonlyService); err != nil {
+		return err
 	}
 
 	deployment, mutateFunc, err := miqtool.PostgresqlDeployment(cr, r.Client, r.Scheme)
@@ -584,69 +1297,150 @@ func (r *ManageIQReconciler) generatePostgresqlResources(cr *miqv1alpha1.ManageI
 		return err
 	}
 
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, deployment, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, deployment, r.detectDrift(cr, deployment, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Deployment has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, deployment, result)
 	}
 
 	return nil
 }
 
+func (r *ManageIQReconciler) generatePgbouncerResources(cr *miqv1alpha1.ManageIQ) error {
+	secret, mutateFunc, err := miqtool.PgbouncerSecret(cr, r.Client, r.Scheme)
+	if err != nil {
+		return err
+	}
+
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, secret, r.detectDrift(cr, secret, mutateFunc)); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("Secret has been reconciled", "component", "pgbouncer", "result", result)
+		r.recordReconcileEvent(cr, secret, result)
+	}
+
+	deployment, mutateFunc, err := miqtool.PgbouncerDeployment(cr, r.Client, r.Scheme)
+	if err != nil {
+		return err
+	}
+
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, deployment, r.detectDrift(cr, deployment, mutateFunc)); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("Deployment has been reconciled", "component", "pgbouncer", "result", result)
+		r.recordReconcileEvent(cr, deployment, result)
+	}
+
+	service, mutateFunc := miqtool.PgbouncerService(cr, r.Scheme)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, service, r.detectDrift(cr, service, mutateFunc)); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("Service has been reconciled", "component", "pgbouncer", "result", result)
+		r.recordReconcileEvent(cr, service, result)
+	}
+
+	pdb, mutateFunc := miqtool.PgbouncerPodDisruptionBudget(cr, r.Scheme)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, pdb, r.detectDrift(cr, pdb, mutateFunc)); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("PodDisruptionBudget has been reconciled", "component", "pgbouncer", "result", result)
+		r.recordReconcileEvent(cr, pdb, result)
+	}
+
+	return nil
+}
+
+// kafkaOperatorShared returns whether the namespace already has an OperatorGroup which is not the
+// one of the CR. A namespace holds a single OperatorGroup and the Strimzi operator installed for
+// another instance serves every Kafka of the namespace.
+func (r *ManageIQReconciler) kafkaOperatorShared(cr *miqv1alpha1.ManageIQ) (bool, error) {
+	operatorGroups := &olmv1.OperatorGroupList{}
+	if err := r.Client.List(context.TODO(), operatorGroups, client.InNamespace(cr.Namespace)); apimeta.IsNoMatchError(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	for i := range operatorGroups.Items {
+		if !metav1.IsControlledBy(&operatorGroups.Items[i], cr) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 func (r *ManageIQReconciler) generateKafkaResources(cr *miqv1alpha1.ManageIQ) error {
-	if miqutilsv1alpha1.FindCatalogSourceByName(r.Client, "openshift-marketplace", "community-operators") != nil {
+	shared, err := r.kafkaOperatorShared(cr)
+	if err != nil {
+		return err
+	}
+
+	if !shared && miqutilsv1alpha1.FindCatalogSourceByName(r.Client, "openshift-marketplace", "community-operators") != nil {
 		kafkaOperatorGroup, mutateFunc := miqkafka.KafkaOperatorGroup(cr, r.Scheme)
-		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, kafkaOperatorGroup, mutateFunc); err != nil {
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, kafkaOperatorGroup, r.detectDrift(cr, kafkaOperatorGroup, mutateFunc)); err != nil {
 			return err
 		} else if result != controllerutil.OperationResultNone {
 			logger.Info("Kafka Operator group has been reconciled", "result", result)
+			r.recordReconcileEvent(cr, kafkaOperatorGroup, result)
 		}
 
 		kafkaSubscription, mutateFunc := miqkafka.KafkaInstall(cr, r.Scheme)
-		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, kafkaSubscription, mutateFunc); err != nil {
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, kafkaSubscription, r.detectDrift(cr, kafkaSubscription, mutateFunc)); err != nil {
 			return err
 		} else if result != controllerutil.OperationResultNone {
 			logger.Info("Kafka Subscription has been reconciled", "result", result)
+			r.recordReconcileEvent(cr, kafkaSubscription, result)
 		}
 	}
 
-	kafkaClusterCR, mutateFunc := miqkafka.KafkaCluster(cr, r.Client, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, kafkaClusterCR, mutateFunc); err != nil {
+	if err := r.checkKafkaVolumeExpansion(cr); err != nil {
+		return err
+	}
+
+	kafkaClusterCR, mutateFunc := miqkafka.KafkaCluster(cr, r.Client, r.Scheme, r.Recorder)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, kafkaClusterCR, r.detectDrift(cr, kafkaClusterCR, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Kafka Cluster has been reconciled", "result", result)
+		r.recordReconcileEvent(cr, kafkaClusterCR, result)
 	}
 
 	if certSecret := miqtool.InternalCertificatesSecret(cr, r.Client); certSecret.Data["root_crt"] != nil && certSecret.Data["root_key"] != nil {
 		kafkaCACert, mutateFunc := miqkafka.KafkaCASecret(cr, r.Client, r.Scheme, "cert")
-		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, kafkaCACert, mutateFunc); err != nil {
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, kafkaCACert, r.detectDrift(cr, kafkaCACert, mutateFunc)); err != nil {
 			return err
 		} else if result != controllerutil.OperationResultNone {
 			logger.Info("Kafka CA Certificate has been reconciled", "result", result)
+			r.recordReconcileEvent(cr, kafkaCACert, result)
 		}
 
 		kafkaCAKey, mutateFunc := miqkafka.KafkaCASecret(cr, r.Client, r.Scheme, "key")
-		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, kafkaCAKey, mutateFunc); err != nil {
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, kafkaCAKey, r.detectDrift(cr, kafkaCAKey, mutateFunc)); err != nil {
 			return err
 		} else if result != controllerutil.OperationResultNone {
 			logger.Info("Kafka CA Key has been reconciled", "result", result)
+			r.recordReconcileEvent(cr, kafkaCAKey, result)
 		}
 	}
 
 	kafkaUserCR, mutateFunc := miqkafka.KafkaUser(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, kafkaUserCR, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, kafkaUserCR, r.detectDrift(cr, kafkaUserCR, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Kafka User has been reconciled", "result", result)
+		r.recordReconcileEvent(cr, kafkaUserCR, result)
 	}
 
-	topics := []string{"manageiq.ems", "manageiq.ems-events", "manageiq.ems-inventory", "manageiq.metrics"}
+	topics := miqkafka.KafkaTopicNames()
 	for i := 0; i < len(topics); i++ {
 		kafkaTopicCR, mutateFunc := miqkafka.KafkaTopic(cr, r.Scheme, topics[i])
-		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, kafkaTopicCR, mutateFunc); err != nil {
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, kafkaTopicCR, r.detectDrift(cr, kafkaTopicCR, mutateFunc)); err != nil {
 			return err
 		} else if result != controllerutil.OperationResultNone {
 			logger.Info(fmt.Sprintf("Kafka topic %s has been reconciled", topics[i]))
+			r.recordReconcileEvent(cr, kafkaTopicCR, result)
 		}
 	}
 
@@ -655,24 +1449,27 @@ func (r *ManageIQReconciler) generateKafkaResources(cr *miqv1alpha1.ManageIQ) er
 
 func (r *ManageIQReconciler) generateOrchestratorResources(cr *miqv1alpha1.ManageIQ) error {
 	serviceAccount, mutateFunc := miqtool.OrchestratorServiceAccount(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, serviceAccount, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, serviceAccount, r.detectDrift(cr, serviceAccount, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Service Account has been reconciled", "component", "orchestrator", "result", result)
+		r.recordReconcileEvent(cr, serviceAccount, result)
 	}
 
 	role, mutateFunc := miqtool.OrchestratorRole(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, role, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, role, r.detectDrift(cr, role, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Role has been reconciled", "component", "orchestrator", "result", result)
+		r.recordReconcileEvent(cr, role, result)
 	}
 
 	roleBinding, mutateFunc := miqtool.OrchestratorRoleBinding(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, roleBinding, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, roleBinding, r.detectDrift(cr, roleBinding, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Role Binding has been reconciled", "component", "orchestrator", "result", result)
+		r.recordReconcileEvent(cr, roleBinding, result)
 	}
 
 	deployment, mutateFunc, err := miqtool.OrchestratorDeployment(cr, r.Scheme, r.Client)
@@ -680,10 +1477,19 @@ func (r *ManageIQReconciler) generateOrchestratorResources(cr *miqv1alpha1.Manag
 		return err
 	}
 
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, deployment, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, deployment, r.detectDrift(cr, deployment, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Deployment has been reconciled", "component", "orchestrator", "result", result)
+		r.recordReconcileEvent(cr, deployment, result)
+	}
+
+	pdb, mutateFunc := miqtool.OrchestratorPodDisruptionBudget(cr, r.Scheme)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, pdb, r.detectDrift(cr, pdb, mutateFunc)); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("PodDisruptionBudget has been reconciled", "component", "orchestrator", "result", result)
+		r.recordReconcileEvent(cr, pdb, result)
 	}
 
 	return nil
@@ -691,75 +1497,105 @@ func (r *ManageIQReconciler) generateOrchestratorResources(cr *miqv1alpha1.Manag
 
 func (r *ManageIQReconciler) generateNetworkPolicies(cr *miqv1alpha1.ManageIQ) error {
 	networkPolicyDefaultDeny, mutateFunc := miqtool.NetworkPolicyDefaultDeny(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyDefaultDeny, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyDefaultDeny, r.detectDrift(cr, networkPolicyDefaultDeny, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("NetworkPolicy default-deny has been reconciled", "component", "network_policy", "result", result)
+		r.recordReconcileEvent(cr, networkPolicyDefaultDeny, result)
 	}
 
 	networkPolicyAllowInboundHttpd, mutateFunc := miqtool.NetworkPolicyAllowInboundHttpd(cr, r.Scheme, r.Client)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowInboundHttpd, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowInboundHttpd, r.detectDrift(cr, networkPolicyAllowInboundHttpd, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("NetworkPolicy allow inbound-httpd has been reconciled", "component", "network_policy", "result", result)
+		r.recordReconcileEvent(cr, networkPolicyAllowInboundHttpd, result)
 	}
 
 	networkPolicyAllowHttpdApi, mutateFunc := miqtool.NetworkPolicyAllowHttpdApi(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowHttpdApi, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowHttpdApi, r.detectDrift(cr, networkPolicyAllowHttpdApi, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("NetworkPolicy allow httpd-api has been reconciled", "component", "network_policy", "result", result)
+		r.recordReconcileEvent(cr, networkPolicyAllowHttpdApi, result)
 	}
 
 	networkPolicyAllowHttpdRemoteConsole, mutateFunc := miqtool.NetworkPolicyAllowHttpdRemoteConsole(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowHttpdRemoteConsole, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowHttpdRemoteConsole, r.detectDrift(cr, networkPolicyAllowHttpdRemoteConsole, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("NetworkPolicy allow httpd-remote-console has been reconciled", "component", "network_policy", "result", result)
+		r.recordReconcileEvent(cr, networkPolicyAllowHttpdRemoteConsole, result)
 	}
 
 	networkPolicyAllowHttpdUi, mutateFunc := miqtool.NetworkPolicyAllowHttpdUi(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowHttpdUi, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowHttpdUi, r.detectDrift(cr, networkPolicyAllowHttpdUi, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("NetworkPolicy allow httpd-ui has been reconciled", "component", "network_policy", "result", result)
+		r.recordReconcileEvent(cr, networkPolicyAllowHttpdUi, result)
 	}
 
 	networkPolicyAllowMemcached, mutateFunc := miqtool.NetworkPolicyAllowMemcached(cr, r.Scheme, &r.Client)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowMemcached, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowMemcached, r.detectDrift(cr, networkPolicyAllowMemcached, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("NetworkPolicy allow memcached has been reconciled", "component", "network_policy", "result", result)
+		r.recordReconcileEvent(cr, networkPolicyAllowMemcached, result)
+	}
+
+	if miqtool.PgbouncerEnabled(cr) {
+		networkPolicyAllowPgbouncer, mutateFunc := miqtool.NetworkPolicyAllowPgbouncer(cr, r.Scheme, &r.Client)
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowPgbouncer, r.detectDrift(cr, networkPolicyAllowPgbouncer, mutateFunc)); err != nil {
+			return err
+		} else if result != controllerutil.OperationResultNone {
+			logger.Info("NetworkPolicy allow pgbouncer has been reconciled", "component", "network_policy", "result", result)
+			r.recordReconcileEvent(cr, networkPolicyAllowPgbouncer, result)
+		}
 	}
 
 	networkPolicyAllowPostgres, mutateFunc := miqtool.NetworkPolicyAllowPostgres(cr, r.Scheme, &r.Client)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowPostgres, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowPostgres, r.detectDrift(cr, networkPolicyAllowPostgres, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("NetworkPolicy allow postgres has been reconciled", "component", "network_policy", "result", result)
+		r.recordReconcileEvent(cr, networkPolicyAllowPostgres, result)
+	}
+
+	if miqtool.DatabaseMaintenanceEnabled(cr) {
+		networkPolicyAllowPostgresMaintenance, mutateFunc := miqtool.NetworkPolicyAllowPostgresMaintenance(cr, r.Scheme)
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowPostgresMaintenance, r.detectDrift(cr, networkPolicyAllowPostgresMaintenance, mutateFunc)); err != nil {
+			return err
+		} else if result != controllerutil.OperationResultNone {
+			logger.Info("NetworkPolicy allow postgres maintenance has been reconciled", "component", "network_policy", "result", result)
+			r.recordReconcileEvent(cr, networkPolicyAllowPostgresMaintenance, result)
+		}
 	}
 
 	if *cr.Spec.DeployMessagingService == true {
 		networkPolicyAllowKafka, mutateFunc := miqtool.NetworkPolicyAllowKafka(cr, r.Scheme, &r.Client)
-		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowKafka, mutateFunc); err != nil {
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowKafka, r.detectDrift(cr, networkPolicyAllowKafka, mutateFunc)); err != nil {
 			return err
 		} else if result != controllerutil.OperationResultNone {
 			logger.Info("NetworkPolicy allow kafka has been reconciled", "component", "network_policy", "result", result)
+			r.recordReconcileEvent(cr, networkPolicyAllowKafka, result)
 		}
 
 		networkPolicyAllowZookeeper, mutateFunc := miqtool.NetworkPolicyAllowZookeeper(cr, r.Scheme, &r.Client)
-		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowZookeeper, mutateFunc); err != nil {
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowZookeeper, r.detectDrift(cr, networkPolicyAllowZookeeper, mutateFunc)); err != nil {
 			return err
 		} else if result != controllerutil.OperationResultNone {
 			logger.Info("NetworkPolicy allow zookeeper has been reconciled", "component", "network_policy", "result", result)
+			r.recordReconcileEvent(cr, networkPolicyAllowZookeeper, result)
 		}
 	}
 
 	networkPolicyAllowTfRunner, mutateFunc := miqtool.NetworkPolicyAllowTfRunner(cr, r.Scheme, &r.Client)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowTfRunner, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, networkPolicyAllowTfRunner, r.detectDrift(cr, networkPolicyAllowTfRunner, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("NetworkPolicy allow opentofu-runner has been reconciled", "component", "network_policy", "result", result)
+		r.recordReconcileEvent(cr, networkPolicyAllowTfRunner, result)
 	}
 
 	return nil
@@ -767,69 +1603,117 @@ func (r *ManageIQReconciler) generateNetworkPolicies(cr *miqv1alpha1.ManageIQ) e
 
 func (r *ManageIQReconciler) generateSecrets(cr *miqv1alpha1.ManageIQ) error {
 	secret, mutateFunc := miqtool.ManageAppSecret(cr, r.Client, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, secret, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, secret, r.detectDrift(cr, secret, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Secret has been reconciled", "component", "app-secret", "result", result)
+		r.recordReconcileEvent(cr, secret, result)
 	}
 
 	secret, mutateFunc, err := miqtool.ManageTlsSecret(cr, r.Client, r.Scheme)
 	if err != nil {
 		return err
 	}
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, secret, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, secret, r.detectDrift(cr, secret, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Secret has been reconciled", "component", "tls-secret", "result", result)
+		r.recordReconcileEvent(cr, secret, result)
 	}
 
 	if cr.Spec.ImagePullSecret != "" {
 		imagePullSecret, mutateFunc := miqtool.ImagePullSecret(cr, r.Client)
-		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, imagePullSecret, mutateFunc); err != nil {
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, imagePullSecret, r.detectDrift(cr, imagePullSecret, mutateFunc)); err != nil {
 			return err
 		} else if result != controllerutil.OperationResultNone {
 			logger.Info("Image Pull Secret has been reconciled", "component", "operator", "result", result)
+			r.recordReconcileEvent(cr, imagePullSecret, result)
 		}
 	}
 
 	if cr.Spec.HttpdAuthenticationType == "openid-connect" {
 		if cr.Spec.OIDCClientSecret != "" {
 			oidcClientSecret, mutateFunc := miqtool.OidcClientSecret(cr, r.Client)
-			if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, oidcClientSecret, mutateFunc); err != nil {
+			if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, oidcClientSecret, r.detectDrift(cr, oidcClientSecret, mutateFunc)); err != nil {
 				return err
 			} else if result != controllerutil.OperationResultNone {
 				logger.Info("OIDC Client Secret has been reconciled", "component", "operator", "result", result)
+				r.recordReconcileEvent(cr, oidcClientSecret, result)
 			}
 		}
 
 		if cr.Spec.OIDCCACertSecret != "" {
 			oidcCaCertSecret, mutateFunc := miqtool.OidcCaCertSecret(cr, r.Client)
-			if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, oidcCaCertSecret, mutateFunc); err != nil {
+			if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, oidcCaCertSecret, r.detectDrift(cr, oidcCaCertSecret, mutateFunc)); err != nil {
 				return err
 			} else if result != controllerutil.OperationResultNone {
 				logger.Info("OIDC CA Secret has been reconciled", "component", "operator", "result", result)
+				r.recordReconcileEvent(cr, oidcCaCertSecret, result)
 			}
 		}
 	}
 
 	if cr.Spec.InternalCertificatesSecret != "" {
 		internalCertificatesSecret, mutateFunc := miqtool.ManageInternalCertificatesSecret(cr, r.Client)
-		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, internalCertificatesSecret, mutateFunc); err != nil {
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, internalCertificatesSecret, r.detectDrift(cr, internalCertificatesSecret, mutateFunc)); err != nil {
 			return err
 		} else if result != controllerutil.OperationResultNone {
 			logger.Info("Internal Certificates Secret has been reconciled", "component", "operator", "result", result)
+			r.recordReconcileEvent(cr, internalCertificatesSecret, result)
 		}
 	}
 
 	return nil
 }
 
-func (r *ManageIQReconciler) migrateCR(cr *miqv1alpha1.ManageIQ) error {
+// migrateCR applies the CR migrations, it returns whether a migration is still waiting on the cluster
+func (r *ManageIQReconciler) migrateCR(cr *miqv1alpha1.ManageIQ) (bool, error) {
+	migrationsRan := len(cr.Spec.MigrationsRan)
+
 	manageiq, mutateFunc := cr_migration.Migrate(cr, r.Client, r.Scheme)
 	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, manageiq, mutateFunc); err != nil {
-		return err
+		return false, err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("CR has been migrated", "component", "app", "result", result)
+		if len(manageiq.Spec.MigrationsRan) > migrationsRan {
+			for _, migration := range manageiq.Spec.MigrationsRan[migrationsRan:] {
+				r.Recorder.Eventf(manageiq, corev1.EventTypeNormal, "Migrated", "CR migration %s has been applied", migration)
+			}
+		}
+	}
+
+	return !cr_migration.Complete(manageiq), nil
+}
+
+// reconcileMigrating reports a CR migration waiting on the cluster, e.g. on the copy of the database
+// files, and checks back on it without failing the reconcile
+func (r *ManageIQReconciler) reconcileMigrating(cr *miqv1alpha1.ManageIQ) (ctrl.Result, error) {
+	pending := &metav1.Condition{Type: conditionReady, Status: metav1.ConditionFalse, Reason: "Migrating", Message: "Waiting for the CR migrations to complete"}
+	if _, err := r.updateManageIQStatus(cr, pending); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{RequeueAfter: notReadyRequeueInterval}, nil
+}
+
+// reconcileLegacyPostgresqlService updates the postgresql Service kept by the migration to the
+// prefixed names, if any
+func (r *ManageIQReconciler) reconcileLegacyPostgresqlService(cr *miqv1alpha1.ManageIQ) error {
+	service, mutateFunc := miqtool.LegacyPostgresqlService(cr, r.Scheme)
+	if err := r.Client.Get(context.TODO(), client.ObjectKeyFromObject(service), service); errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(service, cr) {
+		return nil
+	}
+
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, service, mutateFunc); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("Legacy Service has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, service, result)
 	}
 
 	return nil
@@ -852,6 +1736,7 @@ func (r *ManageIQReconciler) manageOperator(cr *miqv1alpha1.ManageIQ) error {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Operator has been reconciled", "component", "app", "result", result)
+		r.recordReconcileEvent(cr, operator, result)
 	}
 
 	serviceAccount, mutateFunc := miqtool.ManageOperatorServiceAccount(cr, r.Client)
@@ -859,6 +1744,7 @@ func (r *ManageIQReconciler) manageOperator(cr *miqv1alpha1.ManageIQ) error {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Service Account has been reconciled", "component", "operator", "result", result)
+		r.recordReconcileEvent(cr, serviceAccount, result)
 	}
 
 	role, mutateFunc := miqtool.ManageOperatorRole(cr, r.Client)
@@ -866,6 +1752,7 @@ func (r *ManageIQReconciler) manageOperator(cr *miqv1alpha1.ManageIQ) error {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Role has been reconciled", "component", "operator", "result", result)
+		r.recordReconcileEvent(cr, role, result)
 	}
 
 	roleBinding, mutateFunc := miqtool.ManageOperatorRoleBinding(cr, r.Client)
@@ -873,6 +1760,7 @@ func (r *ManageIQReconciler) manageOperator(cr *miqv1alpha1.ManageIQ) error {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Role Binding has been reconciled", "component", "operator", "result", result)
+		r.recordReconcileEvent(cr, roleBinding, result)
 	}
 
 	return nil
@@ -890,45 +1778,51 @@ func getSecretKeyValue(client client.Client, nameSpace string, secretName string
 
 func (r *ManageIQReconciler) manageApplicationResources(cr *miqv1alpha1.ManageIQ) error {
 	configMap, mutateFunc := miqtool.ApplicationUiHttpdConfigMap(cr, r.Scheme, r.Client)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, configMap, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, configMap, r.detectDrift(cr, configMap, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("ConfigMap has been reconciled", "component", "application ui", "result", result)
+		r.recordReconcileEvent(cr, configMap, result)
 	}
 
 	configMap, mutateFunc = miqtool.ApplicationApiHttpdConfigMap(cr, r.Scheme, r.Client)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, configMap, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, configMap, r.detectDrift(cr, configMap, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("ConfigMap has been reconciled", "component", "application api", "result", result)
+		r.recordReconcileEvent(cr, configMap, result)
 	}
 
 	configMap, mutateFunc = miqtool.ApplicationRemoteConsoleHttpdConfigMap(cr, r.Scheme, r.Client)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, configMap, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, configMap, r.detectDrift(cr, configMap, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("ConfigMap has been reconciled", "component", "application remote console", "result", result)
+		r.recordReconcileEvent(cr, configMap, result)
 	}
 
 	role, mutateFunc := miqtool.AutomationRole(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, role, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, role, r.detectDrift(cr, role, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("Role has been reconciled", "component", "automation", "result", result)
+		r.recordReconcileEvent(cr, role, result)
 	}
 
 	roleBinding, mutateFunc := miqtool.AutomationRoleBinding(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, roleBinding, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, roleBinding, r.detectDrift(cr, roleBinding, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("RoleBinding has been reconciled", "component", "automation", "result", result)
+		r.recordReconcileEvent(cr, roleBinding, result)
 	}
 
 	serviceAccount, mutateFunc := miqtool.AutomationServiceAccount(cr, r.Scheme)
-	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, serviceAccount, mutateFunc); err != nil {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, serviceAccount, r.detectDrift(cr, serviceAccount, mutateFunc)); err != nil {
 		return err
 	} else if result != controllerutil.OperationResultNone {
 		logger.Info("ServiceAccount has been reconciled", "component", "automation", "result", result)
+		r.recordReconcileEvent(cr, serviceAccount, result)
 	}
 
 	return nil
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqtool "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/miq-components"
+)
+
+// TestPruneDisabledPostgresql checks that the in-cluster database is only pruned once the CR selects
+// an external database, whatever the hostname of the database secret
+func TestPruneDisabledPostgresql(t *testing.T) {
+	tests := []struct {
+		name         string
+		hostname     string
+		externalHost string
+		components   []string
+		pruned       bool
+	}{
+		{
+			name:       "prefixed hostname",
+			hostname:   "manageiq-postgresql",
+			components: []string{componentPostgresql},
+		},
+		{
+			name:       "legacy hostname",
+			hostname:   "postgresql",
+			components: []string{componentPostgresql},
+		},
+		{
+			name:     "legacy hostname before the components are recorded",
+			hostname: "postgresql",
+		},
+		{
+			name:       "other hostname without an external database",
+			hostname:   "db.example.com",
+			components: []string{componentPostgresql},
+		},
+		{
+			name:         "external database",
+			hostname:     "db.example.com",
+			externalHost: "db.example.com",
+			components:   []string{componentPostgresql},
+			pruned:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := clientgoscheme.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			if err := miqv1alpha1.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+
+			cr := &miqv1alpha1.ManageIQ{ObjectMeta: metav1.ObjectMeta{Namespace: "manageiq", Name: "miq", UID: "miq-uid"}}
+			cr.Spec.AppName = "manageiq"
+			cr.Spec.DatabaseSecret = "postgresql-secrets"
+			cr.Spec.ExternalDatabaseHost = test.externalHost
+			cr.Status.Components = test.components
+			miqtool.DefaultCR(cr)
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: cr.Spec.DatabaseSecret},
+				Data:       map[string][]byte{"hostname": []byte(test.hostname)},
+			}
+			deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "manageiq-postgresql"}}
+			pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "manageiq-postgresql"}}
+			for _, obj := range []client.Object{deployment, pvc} {
+				if err := controllerutil.SetControllerReference(cr, obj, scheme); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cr, secret, deployment, pvc).Build()
+			r := &ManageIQReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(100)}
+
+			if err := r.pruneDisabledComponents(cr); err != nil {
+				t.Fatalf("pruneDisabledComponents() failed: %v", err)
+			}
+
+			err := c.Get(context.TODO(), client.ObjectKeyFromObject(deployment), deployment)
+			if pruned := errors.IsNotFound(err); pruned != test.pruned {
+				t.Errorf("postgresql Deployment pruned = %v, want %v (%v)", pruned, test.pruned, err)
+			}
+
+			// The PVC is released rather than deleted when the database is pruned
+			if err := c.Get(context.TODO(), client.ObjectKeyFromObject(pvc), pvc); err != nil {
+				t.Fatalf("the postgresql PVC has been deleted: %v", err)
+			}
+			if released := !metav1.IsControlledBy(pvc, cr); released != test.pruned {
+				t.Errorf("postgresql PVC released = %v, want %v", released, test.pruned)
+			}
+		})
+	}
+}
+
+// TestFinalizeSnapshot checks that the final backup keeps the encryption key and the database secret
+// with the dump, both secrets are removed along with the CR
+func TestFinalizeSnapshot(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := miqv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	cr := &miqv1alpha1.ManageIQ{ObjectMeta: metav1.ObjectMeta{Namespace: "manageiq", Name: "miq", UID: "miq-uid", Finalizers: []string{manageiqFinalizer}}}
+	cr.Spec.AppName = "manageiq"
+	cr.Spec.DatabaseSecret = "postgresql-secrets"
+	cr.Spec.DeletionPolicy = miqv1alpha1.DeletionPolicySnapshot
+	miqtool.DefaultCR(cr)
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&miqv1alpha1.ManageIQ{}, &batchv1.Job{}).
+		WithObjects(cr).
+		Build()
+	r := &ManageIQReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(100)}
+
+	if _, err := r.finalizeManageIQ(cr); err != nil {
+		t.Fatalf("finalizeManageIQ() failed: %v", err)
+	}
+	if !controllerutil.ContainsFinalizer(cr, manageiqFinalizer) {
+		t.Fatal("the finalizer has been removed before the final backup completed")
+	}
+
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: "manageiq-final-backup"}}
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(job), job); err != nil {
+		t.Fatalf("the final backup Job has not been created: %v", err)
+	}
+
+	// The key and the secret are copied from their mounts next to the dump on the final backup PVC
+	mounts := map[string]string{}
+	for _, mount := range job.Spec.Template.Spec.Containers[0].VolumeMounts {
+		mounts[mount.MountPath] = mount.Name
+	}
+	volumes := map[string]corev1.Volume{}
+	for _, volume := range job.Spec.Template.Spec.Volumes {
+		volumes[volume.Name] = volume
+	}
+	for path, secret := range map[string]string{"/run/secrets/app": "manageiq-app-secrets", "/run/secrets/database": "postgresql-secrets", "/backups": ""} {
+		volume, ok := volumes[mounts[path]]
+		if !ok {
+			t.Errorf("nothing is mounted at %s", path)
+		} else if secret != "" && (volume.Secret == nil || volume.Secret.SecretName != secret) {
+			t.Errorf("the volume mounted at %s is not the Secret %s: %+v", path, secret, volume.VolumeSource)
+		}
+	}
+	if claim := volumes[mounts["/backups"]].PersistentVolumeClaim; claim == nil || claim.ClaimName != "manageiq-final-backup" {
+		t.Errorf("the backups are not written to the final backup PVC: %+v", volumes[mounts["/backups"]].VolumeSource)
+	}
+
+	script := job.Spec.Template.Spec.Containers[0].Command[2]
+	for _, source := range []string{"/run/secrets/app/encryption-key", "/run/secrets/database/*"} {
+		if !strings.Contains(script, "cp -L "+source) {
+			t.Errorf("the final backup does not copy %s: %s", source, script)
+		}
+	}
+
+	job.Status.Succeeded = 1
+	if err := c.Status().Update(context.TODO(), job); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.finalizeManageIQ(cr); err != nil {
+		t.Fatalf("finalizeManageIQ() failed: %v", err)
+	}
+	if controllerutil.ContainsFinalizer(cr, manageiqFinalizer) {
+		t.Error("the finalizer has not been removed once the final backup completed")
+	}
+}
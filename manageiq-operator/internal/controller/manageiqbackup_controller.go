@@ -0,0 +1,276 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqtool "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/miq-components"
+)
+
+// ManageIQBackupReconciler reconciles a ManageIQBackup object
+type ManageIQBackupReconciler struct {
+	Client   client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:namespace=changeme,groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:namespace=changeme,groups=manageiq.org,resources=manageiqbackups,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:namespace=changeme,groups=manageiq.org,resources=manageiqbackups/finalizers,verbs=update
+//+kubebuilder:rbac:namespace=changeme,groups=manageiq.org,resources=manageiqbackups/status,verbs=get;update;patch
+
+const conditionLastBackupSucceeded = "LastBackupSucceeded"
+
+// backupJobHistoryLimit is the number of finished Jobs kept for a ManageIQBackup without a schedule
+const backupJobHistoryLimit = 3
+
+// Reconcile runs the backup Job, or the CronJob when the ManageIQBackup has a schedule, and reports
+// the outcome of the most recent backup
+func (r *ManageIQBackupReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := logger.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling ManageIQBackup")
+
+	backup := &miqv1alpha1.ManageIQBackup{}
+	err := r.Client.Get(context.TODO(), request.NamespacedName, backup)
+	if errors.IsNotFound(err) {
+		return reconcile.Result{}, nil
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if !backup.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	cr := &miqv1alpha1.ManageIQ{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Namespace: backup.Namespace, Name: backup.Spec.ManageIQName}, cr)
+	if errors.IsNotFound(err) {
+		message := fmt.Sprintf("ManageIQ %s not found", backup.Spec.ManageIQName)
+		return reconcile.Result{RequeueAfter: notReadyRequeueInterval}, r.updateBackupStatus(backup, metav1.ConditionFalse, "ManageIQNotFound", message)
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+	miqtool.DefaultCR(cr)
+
+	if (backup.Spec.Storage.VolumeClaim == nil) == (backup.Spec.Storage.S3 == nil) {
+		message := "Exactly one of storage.volumeClaim or storage.s3 must be set"
+		r.Recorder.Event(backup, corev1.EventTypeWarning, "ValidationFailed", message)
+		return reconcile.Result{}, r.updateBackupStatus(backup, metav1.ConditionFalse, "ValidationFailed", message)
+	}
+
+	if err := r.reconcileBackupResources(backup, cr); err != nil {
+		if statusErr := r.updateBackupStatus(backup, metav1.ConditionFalse, "ReconcileFailed", err.Error()); statusErr != nil {
+			logger.Error(statusErr, "Failed setting ManageIQBackup status")
+		}
+		return reconcile.Result{}, err
+	}
+
+	if err := r.reportLastBackup(backup); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, r.updateBackupStatus(backup, metav1.ConditionTrue, "Reconciled", "")
+}
+
+func (r *ManageIQBackupReconciler) reconcileBackupResources(backup *miqv1alpha1.ManageIQBackup, cr *miqv1alpha1.ManageIQ) error {
+	if backup.Spec.Storage.VolumeClaim != nil {
+		pvc, mutateFunc := miqtool.BackupVolumeClaim(backup, cr)
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, pvc, mutateFunc); err != nil {
+			return err
+		} else if result != controllerutil.OperationResultNone {
+			logger.Info("PVC has been reconciled", "component", "backup", "result", result)
+			r.recordBackupEvent(backup, pvc, result)
+		}
+	}
+
+	cronJob := &batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Namespace: backup.Namespace, Name: backup.Name}}
+	if backup.Spec.Schedule == "" {
+		// The backups taken on a removed schedule stay in the storage
+		err := r.Client.Delete(context.TODO(), cronJob, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err == nil {
+			logger.Info("CronJob has been deleted", "component", "backup", "name", cronJob.Name)
+		} else if !errors.IsNotFound(err) {
+			return err
+		}
+
+		jobs := &batchv1.JobList{}
+		if err := r.Client.List(context.TODO(), jobs, client.InNamespace(backup.Namespace), client.MatchingLabels(miqtool.BackupJobLabels(backup))); err != nil {
+			return err
+		}
+		if !miqtool.BackupRequested(backup, jobs.Items) {
+			return nil
+		}
+
+		job, mutateFunc := miqtool.BackupJob(backup, cr, r.Client, r.Scheme)
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, job, mutateFunc); err != nil {
+			return err
+		} else if result != controllerutil.OperationResultNone {
+			logger.Info("Job has been reconciled", "component", "backup", "result", result)
+			r.recordBackupEvent(backup, job, result)
+		}
+
+		return r.pruneBackupJobs(backup, jobs.Items)
+	}
+
+	cronJob, mutateFunc := miqtool.BackupCronJob(backup, cr, r.Client, r.Scheme)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, cronJob, mutateFunc); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("CronJob has been reconciled", "component", "backup", "result", result)
+		r.recordBackupEvent(backup, cronJob, result)
+	}
+
+	return nil
+}
+
+// pruneBackupJobs deletes the finished Jobs of a ManageIQBackup without a schedule beyond the most
+// recent ones, like the history limits of its CronJob
+func (r *ManageIQBackupReconciler) pruneBackupJobs(backup *miqv1alpha1.ManageIQBackup, jobs []batchv1.Job) error {
+	finished := []*batchv1.Job{}
+	for i := range jobs {
+		if metav1.IsControlledBy(&jobs[i], backup) && (jobs[i].Status.Succeeded > 0 || jobFailed(&jobs[i])) {
+			finished = append(finished, &jobs[i])
+		}
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[j].CreationTimestamp.Before(&finished[i].CreationTimestamp)
+	})
+
+	for _, job := range finished[min(len(finished), backupJobHistoryLimit):] {
+		err := r.Client.Delete(context.TODO(), job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err == nil {
+			logger.Info("Job has been deleted", "component", "backup", "name", job.Name)
+		} else if !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reportLastBackup sets the LastBackupSucceeded condition from the most recent backup Job and emits
+// an Event when it finishes
+func (r *ManageIQBackupReconciler) reportLastBackup(backup *miqv1alpha1.ManageIQBackup) error {
+	jobs := &batchv1.JobList{}
+	if err := r.Client.List(context.TODO(), jobs, client.InNamespace(backup.Namespace), client.MatchingLabels(miqtool.BackupJobLabels(backup))); err != nil {
+		return err
+	}
+
+	var last *batchv1.Job
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if job.Status.Succeeded > 0 && job.Status.CompletionTime != nil {
+			if backup.Status.LastSuccessfulTime == nil || backup.Status.LastSuccessfulTime.Before(job.Status.CompletionTime) {
+				backup.Status.LastSuccessfulTime = job.Status.CompletionTime
+			}
+		}
+		if last == nil || last.CreationTimestamp.Before(&job.CreationTimestamp) {
+			last = job
+		}
+	}
+	if last == nil {
+		return nil
+	}
+
+	previous := apimeta.FindStatusCondition(backup.Status.Conditions, conditionLastBackupSucceeded)
+	finished := previous != nil && backup.Status.LastBackupJob == last.Name && previous.Status != metav1.ConditionUnknown
+	backup.Status.LastBackupJob = last.Name
+
+	var message, reason string
+	var status metav1.ConditionStatus
+	switch {
+	case last.Status.Succeeded > 0:
+		message, reason, status = fmt.Sprintf("Job %s has completed", last.Name), "BackupCompleted", metav1.ConditionTrue
+		if !finished {
+			r.Recorder.Eventf(backup, corev1.EventTypeNormal, "BackupCompleted", "Database backup Job %s has completed", last.Name)
+		}
+	case jobFailed(last):
+		message, reason, status = fmt.Sprintf("Job %s failed", last.Name), "BackupFailed", metav1.ConditionFalse
+		if !finished {
+			r.Recorder.Eventf(backup, corev1.EventTypeWarning, "BackupFailed", "Database backup Job %s failed", last.Name)
+		}
+	default:
+		message, reason, status = fmt.Sprintf("Waiting for Job %s", last.Name), "BackupRunning", metav1.ConditionUnknown
+	}
+
+	apimeta.SetStatusCondition(&backup.Status.Conditions, metav1.Condition{
+		Type:               conditionLastBackupSucceeded,
+		Status:             status,
+		ObservedGeneration: backup.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+
+	return nil
+}
+
+func (r *ManageIQBackupReconciler) updateBackupStatus(backup *miqv1alpha1.ManageIQBackup, status metav1.ConditionStatus, reason string, message string) error {
+	apimeta.SetStatusCondition(&backup.Status.Conditions, metav1.Condition{
+		Type:               conditionReady,
+		Status:             status,
+		ObservedGeneration: backup.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+
+	return r.Client.Status().Update(context.TODO(), backup)
+}
+
+func (r *ManageIQBackupReconciler) recordBackupEvent(backup *miqv1alpha1.ManageIQBackup, obj client.Object, result controllerutil.OperationResult) {
+	kind := objectKind(obj, r.Scheme)
+	switch result {
+	case controllerutil.OperationResultCreated:
+		r.Recorder.Eventf(backup, corev1.EventTypeNormal, "Created", "%s %s has been created", kind, obj.GetName())
+	case controllerutil.OperationResultUpdated:
+		r.Recorder.Eventf(backup, corev1.EventTypeNormal, "Updated", "%s %s has been updated", kind, obj.GetName())
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ManageIQBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&miqv1alpha1.ManageIQBackup{}).
+		Owns(&batchv1.CronJob{}).
+		// The Jobs created by the CronJob are owned by it, both kinds of Jobs carry the backup label
+		Watches(&batchv1.Job{}, handler.TypedEnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+			name, ok := obj.GetLabels()[miqtool.BackupNameLabel]
+			if !ok {
+				return []reconcile.Request{}
+			}
+
+			return []reconcile.Request{reconcile.Request{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}}}
+		})).
+		Complete(r)
+}
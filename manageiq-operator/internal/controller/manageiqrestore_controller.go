@@ -0,0 +1,325 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqtool "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/miq-components"
+)
+
+// ManageIQRestoreReconciler reconciles a ManageIQRestore object
+type ManageIQRestoreReconciler struct {
+	Client   client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:namespace=changeme,groups=manageiq.org,resources=manageiqrestores,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:namespace=changeme,groups=manageiq.org,resources=manageiqrestores/finalizers,verbs=update
+//+kubebuilder:rbac:namespace=changeme,groups=manageiq.org,resources=manageiqrestores/status,verbs=get;update;patch
+
+const (
+	conditionRestored = "Restored"
+
+	restoreFinalizer = "manageiq.org/restore-finalizer"
+
+	// quiescedByAnnotation records on the ManageIQ CR which ManageIQRestore set the QuiesceAnnotation
+	quiescedByAnnotation = "manageiq.org/quiesced-by"
+
+	quiesceRequeueInterval = 10 * time.Second
+)
+
+// Reconcile quiesces the application of the ManageIQ CR, runs the restore Job and resumes the
+// application once the restore has completed. A failed restore leaves the application quiesced
+// until the ManageIQRestore is deleted.
+func (r *ManageIQRestoreReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := logger.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling ManageIQRestore")
+
+	restore := &miqv1alpha1.ManageIQRestore{}
+	err := r.Client.Get(context.TODO(), request.NamespacedName, restore)
+	if errors.IsNotFound(err) {
+		return reconcile.Result{}, nil
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if !restore.DeletionTimestamp.IsZero() {
+		return r.finalizeRestore(restore)
+	}
+
+	if restore.Status.Phase == miqv1alpha1.RestorePhaseCompleted || restore.Status.Phase == miqv1alpha1.RestorePhaseFailed {
+		return reconcile.Result{}, nil
+	}
+
+	if controllerutil.AddFinalizer(restore, restoreFinalizer) {
+		if err := r.Client.Update(context.TODO(), restore); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	backup := &miqv1alpha1.ManageIQBackup{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Namespace: restore.Namespace, Name: restore.Spec.BackupName}, backup)
+	if errors.IsNotFound(err) {
+		message := fmt.Sprintf("ManageIQBackup %s not found", restore.Spec.BackupName)
+		return r.updateRestoreStatus(restore, miqv1alpha1.RestorePhasePending, "BackupNotFound", message, notReadyRequeueInterval)
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	cr := &miqv1alpha1.ManageIQ{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Namespace: restore.Namespace, Name: backup.Spec.ManageIQName}, cr)
+	if errors.IsNotFound(err) {
+		message := fmt.Sprintf("ManageIQ %s not found", backup.Spec.ManageIQName)
+		return r.updateRestoreStatus(restore, miqv1alpha1.RestorePhasePending, "ManageIQNotFound", message, notReadyRequeueInterval)
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+	miqtool.DefaultCR(cr)
+
+	switch restore.Status.Phase {
+	case miqv1alpha1.RestorePhaseQuiescing:
+		return r.waitForQuiesce(restore, backup, cr)
+	case miqv1alpha1.RestorePhaseRestoring:
+		return r.runRestore(restore, backup, cr)
+	default:
+		return r.quiesce(restore, backup, cr)
+	}
+}
+
+// quiesce sets the QuiesceAnnotation on the ManageIQ CR, unless another ManageIQRestore holds it.
+// A base backup replaces the data directory, the database is scaled down along with the application.
+func (r *ManageIQRestoreReconciler) quiesce(restore *miqv1alpha1.ManageIQRestore, backup *miqv1alpha1.ManageIQBackup, cr *miqv1alpha1.ManageIQ) (ctrl.Result, error) {
+	if target := restore.Spec.TargetTime; target != nil {
+		message := ""
+		if backup.Spec.Method != miqv1alpha1.BackupMethodBaseBackup || cr.Spec.PostgresqlWalArchiveBackup != backup.Name {
+			message = fmt.Sprintf("A TargetTime requires ManageIQBackup %s to take base backups and to be the PostgresqlWalArchiveBackup of ManageIQ %s", backup.Name, cr.Name)
+		} else if target.After(time.Now()) {
+			message = fmt.Sprintf("TargetTime %s is in the future", target.UTC().Format(time.RFC3339))
+		}
+		if message != "" {
+			r.Recorder.Event(restore, corev1.EventTypeWarning, "RestoreFailed", message)
+			return r.updateRestoreStatus(restore, miqv1alpha1.RestorePhaseFailed, "ValidationFailed", message, 0)
+		}
+	}
+
+	mode := miqv1alpha1.QuiesceApplication
+	if backup.Spec.Method == miqv1alpha1.BackupMethodBaseBackup {
+		hostname := getSecretKeyValue(r.Client, cr.Namespace, cr.Spec.DatabaseSecret, "hostname")
+		if !miqtool.InClusterPostgresqlHostname(cr, hostname) {
+			message := "A BaseBackup can only be restored to the database deployed by the operator"
+			r.Recorder.Event(restore, corev1.EventTypeWarning, "RestoreFailed", message)
+			return r.updateRestoreStatus(restore, miqv1alpha1.RestorePhaseFailed, "ValidationFailed", message, 0)
+		}
+		mode = miqv1alpha1.QuiesceDatabase
+	}
+
+	if holder := cr.Annotations[quiescedByAnnotation]; holder != "" && holder != restore.Name {
+		message := fmt.Sprintf("Waiting for ManageIQRestore %s to finish", holder)
+		return r.updateRestoreStatus(restore, miqv1alpha1.RestorePhasePending, "Waiting", message, notReadyRequeueInterval)
+	}
+
+	patch := client.MergeFrom(cr.DeepCopy())
+	if cr.Annotations == nil {
+		cr.Annotations = map[string]string{}
+	}
+	cr.Annotations[miqv1alpha1.QuiesceAnnotation] = mode
+	cr.Annotations[quiescedByAnnotation] = restore.Name
+	if err := r.Client.Patch(context.TODO(), cr, patch); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	logger.Info("Application is being quiesced", "manageiq", cr.Name, "mode", mode)
+	r.Recorder.Eventf(restore, corev1.EventTypeNormal, "Quiescing", "ManageIQ %s is being scaled down for the restore", cr.Name)
+
+	now := metav1.Now()
+	restore.Status.StartTime = &now
+	return r.updateRestoreStatus(restore, miqv1alpha1.RestorePhaseQuiescing, "Quiescing", fmt.Sprintf("Waiting for ManageIQ %s to scale down", cr.Name), quiesceRequeueInterval)
+}
+
+// waitForQuiesce waits until the orchestrator and its workers, and the database when its data
+// directory is replaced, have stopped
+func (r *ManageIQRestoreReconciler) waitForQuiesce(restore *miqv1alpha1.ManageIQRestore, backup *miqv1alpha1.ManageIQBackup, cr *miqv1alpha1.ManageIQ) (ctrl.Result, error) {
+	selectors := []client.ListOption{
+		client.MatchingLabels{"app": cr.Spec.AppName, "name": "orchestrator"},
+		client.HasLabels{cr.Spec.AppName + "-orchestrated-by"},
+	}
+	if cr.Annotations[miqv1alpha1.QuiesceAnnotation] == miqv1alpha1.QuiesceDatabase {
+		selectors = append(selectors, client.MatchingLabels{"app": cr.Spec.AppName, "name": "postgresql"})
+	}
+
+	for _, selector := range selectors {
+		pods := &corev1.PodList{}
+		if err := r.Client.List(context.TODO(), pods, client.InNamespace(cr.Namespace), selector); err != nil {
+			return reconcile.Result{}, err
+		}
+		if len(pods.Items) > 0 {
+			return reconcile.Result{RequeueAfter: quiesceRequeueInterval}, nil
+		}
+	}
+
+	logger.Info("Application has been quiesced", "manageiq", cr.Name)
+	return r.runRestore(restore, backup, cr)
+}
+
+// runRestore creates the restore Job along with the ServiceAccount allowed to update the
+// encryption key, and resumes the application once it has completed
+func (r *ManageIQRestoreReconciler) runRestore(restore *miqv1alpha1.ManageIQRestore, backup *miqv1alpha1.ManageIQBackup, cr *miqv1alpha1.ManageIQ) (ctrl.Result, error) {
+	serviceAccount, mutateFunc := miqtool.RestoreServiceAccount(restore, cr, r.Scheme)
+	if err := r.reconcileRestoreObject(restore, serviceAccount, mutateFunc); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	role, mutateFunc := miqtool.RestoreRole(restore, cr, r.Scheme)
+	if err := r.reconcileRestoreObject(restore, role, mutateFunc); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	roleBinding, mutateFunc := miqtool.RestoreRoleBinding(restore, cr, r.Scheme)
+	if err := r.reconcileRestoreObject(restore, roleBinding, mutateFunc); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	job, mutateFunc := miqtool.RestoreJob(restore, backup, cr, r.Client, r.Scheme)
+	if err := r.reconcileRestoreObject(restore, job, mutateFunc); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	switch {
+	case job.Status.Succeeded > 0:
+		if err := r.resume(restore, cr); err != nil {
+			return reconcile.Result{}, err
+		}
+
+		now := metav1.Now()
+		restore.Status.CompletionTime = &now
+		r.Recorder.Eventf(restore, corev1.EventTypeNormal, "RestoreCompleted", "Backup of ManageIQBackup %s has been restored, ManageIQ %s is resuming", backup.Name, cr.Name)
+		return r.updateRestoreStatus(restore, miqv1alpha1.RestorePhaseCompleted, "RestoreCompleted", fmt.Sprintf("Job %s has completed", job.Name), 0)
+	case jobFailed(job):
+		now := metav1.Now()
+		restore.Status.CompletionTime = &now
+		r.Recorder.Eventf(restore, corev1.EventTypeWarning, "RestoreFailed", "Restore Job %s failed, ManageIQ %s stays quiesced until the ManageIQRestore is deleted", job.Name, cr.Name)
+		return r.updateRestoreStatus(restore, miqv1alpha1.RestorePhaseFailed, "RestoreFailed", fmt.Sprintf("Job %s failed, delete the ManageIQRestore to resume the application", job.Name), 0)
+	default:
+		return r.updateRestoreStatus(restore, miqv1alpha1.RestorePhaseRestoring, "Restoring", fmt.Sprintf("Waiting for Job %s", job.Name), 0)
+	}
+}
+
+func (r *ManageIQRestoreReconciler) reconcileRestoreObject(restore *miqv1alpha1.ManageIQRestore, obj client.Object, mutateFunc controllerutil.MutateFn) error {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, obj, mutateFunc); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("Object has been reconciled", "component", "restore", "name", obj.GetName(), "result", result)
+		if result == controllerutil.OperationResultCreated {
+			r.Recorder.Eventf(restore, corev1.EventTypeNormal, "Created", "%s %s has been created", objectKind(obj, r.Scheme), obj.GetName())
+		}
+	}
+
+	return nil
+}
+
+// resume removes the QuiesceAnnotation from the ManageIQ CR if this restore set it
+func (r *ManageIQRestoreReconciler) resume(restore *miqv1alpha1.ManageIQRestore, cr *miqv1alpha1.ManageIQ) error {
+	if cr.Annotations[quiescedByAnnotation] != restore.Name {
+		return nil
+	}
+
+	patch := client.MergeFrom(cr.DeepCopy())
+	delete(cr.Annotations, miqv1alpha1.QuiesceAnnotation)
+	delete(cr.Annotations, quiescedByAnnotation)
+	if err := r.Client.Patch(context.TODO(), cr, patch); err != nil {
+		return err
+	}
+
+	logger.Info("Application has been resumed", "manageiq", cr.Name)
+	return nil
+}
+
+// finalizeRestore resumes the application left quiesced by a failed or interrupted restore
+func (r *ManageIQRestoreReconciler) finalizeRestore(restore *miqv1alpha1.ManageIQRestore) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(restore, restoreFinalizer) {
+		return reconcile.Result{}, nil
+	}
+
+	manageiqs := &miqv1alpha1.ManageIQList{}
+	if err := r.Client.List(context.TODO(), manageiqs, client.InNamespace(restore.Namespace)); err != nil {
+		return reconcile.Result{}, err
+	}
+	for i := range manageiqs.Items {
+		if err := r.resume(restore, &manageiqs.Items[i]); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(restore, restoreFinalizer)
+	if err := r.Client.Update(context.TODO(), restore); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+func (r *ManageIQRestoreReconciler) updateRestoreStatus(restore *miqv1alpha1.ManageIQRestore, phase string, reason string, message string, requeueAfter time.Duration) (ctrl.Result, error) {
+	status := metav1.ConditionFalse
+	if phase == miqv1alpha1.RestorePhaseCompleted {
+		status = metav1.ConditionTrue
+	}
+
+	restore.Status.Phase = phase
+	apimeta.SetStatusCondition(&restore.Status.Conditions, metav1.Condition{
+		Type:               conditionRestored,
+		Status:             status,
+		ObservedGeneration: restore.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+
+	if err := r.Client.Status().Update(context.TODO(), restore); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ManageIQRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&miqv1alpha1.ManageIQRestore{}).
+		Owns(&batchv1.Job{}).
+		Owns(&corev1.ServiceAccount{}).
+		Owns(&rbacv1.Role{}).
+		Owns(&rbacv1.RoleBinding{}).
+		Complete(r)
+}
@@ -0,0 +1,369 @@
+package controllers
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqtool "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/miq-components"
+)
+
+const postgresqlPodNameLabel = "statefulset.kubernetes.io/pod-name"
+
+// generatePostgresqlReplicatedResources reconciles the StatefulSet of the replicated mode. The
+// postgresql Service follows the primary ordinal of the CR, changing it promotes that standby once
+// it is ready.
+func (r *ManageIQReconciler) generatePostgresqlReplicatedResources(cr *miqv1alpha1.ManageIQ) error {
+	claims := []*corev1.PersistentVolumeClaim{}
+	for ordinal := int32(0); ordinal < *cr.Spec.PostgresqlReplicas; ordinal++ {
+		pvc, _ := miqtool.PostgresqlReplicaPVC(cr, ordinal, r.Scheme)
+		claims = append(claims, pvc)
+	}
+	if err := r.expandVolumeClaims(cr, "DatabaseVolumeCapacity", cr.Spec.DatabaseVolumeCapacity, claims...); err != nil {
+		return err
+	}
+
+	for ordinal := int32(0); ordinal < *cr.Spec.PostgresqlReplicas; ordinal++ {
+		pvc, mutateFunc := miqtool.PostgresqlReplicaPVC(cr, ordinal, r.Scheme)
+		if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, pvc, r.detectDrift(cr, pvc, mutateFunc)); err != nil {
+			return err
+		} else if result != controllerutil.OperationResultNone {
+			logger.Info("PVC has been reconciled", "component", "postgresql", "result", result)
+			r.recordReconcileEvent(cr, pvc, result)
+		}
+	}
+
+	if current, ok := r.postgresqlCurrentPrimary(cr); ok && current != *cr.Spec.PostgresqlPrimary {
+		if err := r.postgresqlPromotable(cr); err != nil {
+			logger.Info("Standby is not promoted", "component", "postgresql", "primary", *cr.Spec.PostgresqlPrimary, "reason", err.Error())
+			r.Recorder.Eventf(cr, corev1.EventTypeWarning, "PostgresqlPromotionBlocked", "Standby %s is not promoted: %s", miqtool.PostgresqlPodName(cr, *cr.Spec.PostgresqlPrimary), err)
+			cr = cr.DeepCopy()
+			cr.Spec.PostgresqlPrimary = &current
+		}
+	}
+
+	headlessService, mutateFunc := miqtool.PostgresqlHeadlessService(cr, r.Scheme)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, headlessService, r.detectDrift(cr, headlessService, mutateFunc)); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("Headless Service has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, headlessService, result)
+	}
+
+	readonlyService, mutateFunc := miqtool.PostgresqlReadonlyService(cr, r.Scheme)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, readonlyService, r.detectDrift(cr, readonlyService, mutateFunc)); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("Readonly Service has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, readonlyService, result)
+	}
+
+	service, mutateFunc := miqtool.PostgresqlService(cr, r.Scheme)
+	previousPrimary := ""
+	recordPrimary := func() error {
+		previousPrimary = service.Spec.Selector[postgresqlPodNameLabel]
+		return mutateFunc()
+	}
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, service, r.detectDrift(cr, service, recordPrimary)); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("Service has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, service, result)
+	}
+	if err := r.reconcileLegacyPostgresqlService(cr); err != nil {
+		return err
+	}
+	if primary := service.Spec.Selector[postgresqlPodNameLabel]; previousPrimary != "" && previousPrimary != primary {
+		logger.Info("Standby has been promoted", "component", "postgresql", "previous", previousPrimary, "primary", primary)
+		r.Recorder.Eventf(cr, corev1.EventTypeNormal, "PostgresqlPromoted", "Standby %s has been promoted, it replaces the primary %s", primary, previousPrimary)
+	}
+
+	statefulSet, mutateFunc, err := miqtool.PostgresqlStatefulSet(cr, r.Client, r.Scheme)
+	if err != nil {
+		return err
+	}
+
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, statefulSet, r.detectDrift(cr, statefulSet, mutateFunc)); err != nil {
+		return err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("StatefulSet has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, statefulSet, result)
+	}
+
+	if err := r.labelPostgresqlPods(cr); err != nil {
+		return err
+	}
+
+	return r.rollPostgresqlPods(cr, statefulSet)
+}
+
+// postgresqlCurrentPrimary returns the primary ordinal the StatefulSet was last reconciled with
+func (r *ManageIQReconciler) postgresqlCurrentPrimary(cr *miqv1alpha1.ManageIQ) (int32, bool) {
+	statefulSet := &appsv1.StatefulSet{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "postgresql")}, statefulSet); err != nil {
+		return 0, false
+	}
+
+	return statefulSetPrimary(statefulSet), true
+}
+
+// postgresqlPromotable checks that the standby with the primary ordinal of the CR holds a copy of the
+// database, its PVC exists and its pod is ready, i.e. it has cloned the primary and is streaming
+func (r *ManageIQReconciler) postgresqlPromotable(cr *miqv1alpha1.ManageIQ) error {
+	pvc, _ := miqtool.PostgresqlReplicaPVC(cr, *cr.Spec.PostgresqlPrimary, r.Scheme)
+	if err := r.Client.Get(context.TODO(), client.ObjectKeyFromObject(pvc), pvc); errors.IsNotFound(err) {
+		return fmt.Errorf("PVC %s does not exist", pvc.Name)
+	} else if err != nil {
+		return err
+	}
+
+	pod := &corev1.Pod{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: miqtool.PostgresqlPodName(cr, *cr.Spec.PostgresqlPrimary)}, pod); errors.IsNotFound(err) {
+		return fmt.Errorf("pod %s does not exist", pod.Name)
+	} else if err != nil {
+		return err
+	}
+	if !podReady(pod) {
+		return fmt.Errorf("pod %s is not ready", pod.Name)
+	}
+
+	return nil
+}
+
+// rollPostgresqlPods replaces the outdated database pods one at a time, once the updated ones are
+// ready. The pod with the primary ordinal goes first, so that a promoted standby streams from the
+// former primary until it is replaced and its clean shutdown has sent the rest of the WAL.
+func (r *ManageIQReconciler) rollPostgresqlPods(cr *miqv1alpha1.ManageIQ, statefulSet *appsv1.StatefulSet) error {
+	if statefulSet.Status.ObservedGeneration != statefulSet.Generation || statefulSet.Status.UpdateRevision == "" {
+		return nil
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.Client.List(context.TODO(), pods, client.InNamespace(cr.Namespace), client.MatchingLabels{"app": cr.Spec.AppName, "name": "postgresql"}); err != nil {
+		return err
+	}
+
+	primary := miqtool.PostgresqlPodName(cr, *cr.Spec.PostgresqlPrimary)
+	outdated := []*corev1.Pod{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.DeletionTimestamp != nil {
+			return nil
+		}
+		if pod.Labels[appsv1.ControllerRevisionHashLabelKey] == statefulSet.Status.UpdateRevision {
+			if !podReady(pod) {
+				return nil
+			}
+			continue
+		}
+		outdated = append(outdated, pod)
+	}
+	if len(outdated) == 0 {
+		return nil
+	}
+
+	// The primary first, then the standbys from the highest ordinal as a rolling update would
+	order := func(pod *corev1.Pod) int {
+		if pod.Name == primary {
+			return math.MaxInt
+		}
+		ordinal, _ := strconv.Atoi(pod.Name[strings.LastIndex(pod.Name, "-")+1:])
+		return ordinal
+	}
+	slices.SortFunc(outdated, func(a, b *corev1.Pod) int { return cmp.Compare(order(b), order(a)) })
+
+	pod := outdated[0]
+	if err := r.Client.Delete(context.TODO(), pod); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	logger.Info("Pod has been replaced", "component", "postgresql", "name", pod.Name, "revision", statefulSet.Status.UpdateRevision)
+
+	return nil
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// labelPostgresqlPods sets the role label selected by the readonly Service on the database pods
+func (r *ManageIQReconciler) labelPostgresqlPods(cr *miqv1alpha1.ManageIQ) error {
+	pods := &corev1.PodList{}
+	if err := r.Client.List(context.TODO(), pods, client.InNamespace(cr.Namespace), client.MatchingLabels{"app": cr.Spec.AppName, "name": "postgresql"}); err != nil {
+		return err
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		role := miqtool.PostgresqlPodRole(cr, pod)
+		if pod.Labels[miqtool.PostgresqlRoleLabel] == role {
+			continue
+		}
+
+		patch := client.MergeFrom(pod.DeepCopy())
+		pod.Labels[miqtool.PostgresqlRoleLabel] = role
+		if err := r.Client.Patch(context.TODO(), pod, patch); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		logger.Info("Pod has been labeled", "component", "postgresql", "name", pod.Name, "role", role)
+	}
+
+	return nil
+}
+
+// migratePostgresqlMode copies the data directory when the database switches between the standalone
+// Deployment and the StatefulSet of the replicated mode, and reports whether the workload of the
+// current mode can be reconciled. The workload of the previous mode is scaled down first and only
+// deleted once the copy has completed, so that an interrupted switch is resumed.
+func (r *ManageIQReconciler) migratePostgresqlMode(cr *miqv1alpha1.ManageIQ) (bool, error) {
+	var previous client.Object
+	if miqtool.PostgresqlReplicated(cr) {
+		previous = &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "postgresql")}}
+	} else {
+		previous = &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "postgresql")}}
+	}
+	if err := r.Client.Get(context.TODO(), client.ObjectKeyFromObject(previous), previous); errors.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	if !metav1.IsControlledBy(previous, cr) {
+		return true, nil
+	}
+
+	var zero int32 = 0
+	var replicas *int32
+	var source, target *corev1.PersistentVolumeClaim
+	var targetMutateFunc controllerutil.MutateFn
+	patch := client.MergeFrom(previous.DeepCopyObject().(client.Object))
+	switch workload := previous.(type) {
+	case *appsv1.Deployment:
+		replicas, workload.Spec.Replicas = workload.Spec.Replicas, &zero
+		source, _ = miqtool.PostgresqlPVC(cr, r.Scheme)
+		target, targetMutateFunc = miqtool.PostgresqlReplicaPVC(cr, *cr.Spec.PostgresqlPrimary, r.Scheme)
+	case *appsv1.StatefulSet:
+		replicas, workload.Spec.Replicas = workload.Spec.Replicas, &zero
+		source, _ = miqtool.PostgresqlReplicaPVC(cr, statefulSetPrimary(workload), r.Scheme)
+		target, targetMutateFunc = miqtool.PostgresqlPVC(cr, r.Scheme)
+	}
+
+	if replicas == nil || *replicas != 0 {
+		if err := r.Client.Patch(context.TODO(), previous, patch); err != nil {
+			return false, err
+		}
+		logger.Info("Scaled down the database to switch the postgresql mode", "component", "postgresql", "kind", r.objectKind(previous), "mode", cr.Spec.PostgresqlMode)
+		r.Recorder.Eventf(cr, corev1.EventTypeNormal, "PostgresqlMigrating", "Switching the database to the %s mode, copying PVC %s to PVC %s", cr.Spec.PostgresqlMode, source.Name, target.Name)
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.Client.List(context.TODO(), pods, client.InNamespace(cr.Namespace), client.MatchingLabels{"app": cr.Spec.AppName, "name": "postgresql"}); err != nil {
+		return false, err
+	}
+	if len(pods.Items) > 0 {
+		logger.Info("Waiting for the database pods to stop", "component", "postgresql", "pods", len(pods.Items))
+		return false, nil
+	}
+
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, target, targetMutateFunc); err != nil {
+		return false, err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("PVC has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, target, result)
+	}
+
+	job, mutateFunc := miqtool.PostgresqlDataCopyJob(cr, source.Name, target.Name, r.Client, r.Scheme)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, job, mutateFunc); err != nil {
+		return false, err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("Job has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, job, result)
+	}
+
+	switch {
+	case job.Status.Succeeded > 0:
+	case jobFailed(job):
+		return false, fmt.Errorf("Job %s copying the database to PVC %s failed, delete it to retry", job.Name, target.Name)
+	default:
+		logger.Info("Waiting for the database copy", "component", "postgresql", "job", job.Name)
+		return false, nil
+	}
+
+	for _, obj := range []client.Object{job, previous} {
+		if err := r.Client.Delete(context.TODO(), obj, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+	}
+	logger.Info("Switched the postgresql mode", "component", "postgresql", "mode", cr.Spec.PostgresqlMode)
+	r.Recorder.Eventf(cr, corev1.EventTypeNormal, "PostgresqlMigrated", "Database has been switched to the %s mode, PVC %s is kept", cr.Spec.PostgresqlMode, source.Name)
+
+	return true, nil
+}
+
+// statefulSetPrimary returns the primary ordinal the StatefulSet was last reconciled with
+func statefulSetPrimary(statefulSet *appsv1.StatefulSet) int32 {
+	for _, container := range statefulSet.Spec.Template.Spec.Containers {
+		for _, env := range container.Env {
+			if env.Name == "POSTGRESQL_PRIMARY_ORDINAL" {
+				if ordinal, err := strconv.ParseInt(env.Value, 10, 32); err == nil {
+					return int32(ordinal)
+				}
+			}
+		}
+	}
+
+	return 0
+}
+
+// postgresqlClaims returns the database PVCs of both modes, along with the backup of the last
+// upgrade and the PVCs restored from a snapshot, which are controlled by the CR
+func (r *ManageIQReconciler) postgresqlClaims(cr *miqv1alpha1.ManageIQ) []client.Object {
+	claims := []client.Object{
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "postgresql")}},
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "postgresql-upgrade-backup")}},
+	}
+
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := r.Client.List(context.TODO(), pvcs, client.InNamespace(cr.Namespace)); err != nil {
+		logger.Error(err, "Failed listing the database PVCs")
+		return claims
+	}
+
+	replicaPrefix := "miq-pgdb-volume-" + miqtool.ResourceName(cr, "postgresql") + "-"
+	for i := range pvcs.Items {
+		restored := pvcs.Items[i].Spec.DataSource != nil && pvcs.Items[i].Spec.DataSource.Kind == "VolumeSnapshot"
+		if (strings.HasPrefix(pvcs.Items[i].Name, replicaPrefix) || restored) && metav1.IsControlledBy(&pvcs.Items[i], cr) {
+			claims = append(claims, &pvcs.Items[i])
+		}
+	}
+
+	return claims
+}
+
+func (r *ManageIQReconciler) postgresqlStatefulSetReady(cr *miqv1alpha1.ManageIQ) bool {
+	statefulSet := &appsv1.StatefulSet{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "postgresql")}, statefulSet); err != nil {
+		return false
+	}
+
+	return statefulSet.Status.ObservedGeneration == statefulSet.Generation &&
+		statefulSet.Spec.Replicas != nil &&
+		statefulSet.Status.ReadyReplicas == *statefulSet.Spec.Replicas
+}
@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqtool "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/miq-components"
+)
+
+// upgradePostgresql compares the major version of the data directory with the one of the image
+// when the image of the database changes, and reports whether the workload can be reconciled with
+// the image of the CR. A newer image starts an upgrade which keeps the workload on the previous
+// image, scaled down, until the data directory has been backed up and upgraded.
+func (r *ManageIQReconciler) upgradePostgresql(cr *miqv1alpha1.ManageIQ) (bool, error) {
+	workload, image, err := r.postgresqlWorkload(cr)
+	if err != nil {
+		return false, err
+	}
+
+	if miqtool.PostgresqlUpgrading(cr) {
+		if workload == nil {
+			return false, fmt.Errorf("The %s database workload has been deleted during the upgrade", cr.Spec.PostgresqlMode)
+		}
+		return r.runPostgresqlUpgrade(cr, workload, image)
+	}
+
+	if workload == nil || image == "" || image == cr.Spec.PostgresqlImage {
+		return true, nil
+	}
+	if miqtool.Quiesced(cr) {
+		logger.Info("Waiting for the restore before checking the database version", "component", "postgresql")
+		return false, nil
+	}
+
+	dataVersion, imageVersion, err := r.postgresqlVersions(cr)
+	if err != nil || dataVersion == "" {
+		return false, err
+	}
+	if dataVersion == "none" || dataVersion == imageVersion {
+		job, _ := miqtool.PostgresqlVersionJob(cr, "", r.Client, r.Scheme)
+		return true, r.deleteJobs(job)
+	}
+
+	if data, err := strconv.Atoi(dataVersion); err != nil {
+		return false, fmt.Errorf("Unexpected PG_VERSION %q in PVC %s", dataVersion, miqtool.PostgresqlClaimName(cr))
+	} else if target, err := strconv.Atoi(imageVersion); err != nil {
+		return false, fmt.Errorf("Unexpected PostgreSQL version %q of image %s", imageVersion, cr.Spec.PostgresqlImage)
+	} else if data > target {
+		return false, fmt.Errorf("The data directory of PostgreSQL %d cannot be downgraded to PostgreSQL %d, set postgresqlImage back to %s", data, target, image)
+	}
+
+	logger.Info("Database upgrade has started", "component", "postgresql", "from", dataVersion, "to", imageVersion)
+	r.Recorder.Eventf(cr, corev1.EventTypeNormal, "PostgresqlUpgrading", "Upgrading the database from PostgreSQL %s to PostgreSQL %s, the application is scaled down", dataVersion, imageVersion)
+	r.reportStatusCondition(cr, fmt.Sprintf("Upgrading from PostgreSQL %s to PostgreSQL %s, waiting for the application to stop", dataVersion, imageVersion), "Stopping", metav1.ConditionTrue, miqtool.PostgresqlUpgradingCondition)
+
+	return r.runPostgresqlUpgrade(cr, workload, image)
+}
+
+// postgresqlWorkload returns the database workload of the current mode which is controlled by
+// the CR, along with the image of its postgresql container
+func (r *ManageIQReconciler) postgresqlWorkload(cr *miqv1alpha1.ManageIQ) (client.Object, string, error) {
+	var workload client.Object
+	if miqtool.PostgresqlReplicated(cr) {
+		workload = &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "postgresql")}}
+	} else {
+		workload = &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: miqtool.ResourceName(cr, "postgresql")}}
+	}
+	if err := r.Client.Get(context.TODO(), client.ObjectKeyFromObject(workload), workload); errors.IsNotFound(err) {
+		return nil, "", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+	if !metav1.IsControlledBy(workload, cr) {
+		return nil, "", nil
+	}
+
+	var containers []corev1.Container
+	switch w := workload.(type) {
+	case *appsv1.Deployment:
+		containers = w.Spec.Template.Spec.Containers
+	case *appsv1.StatefulSet:
+		containers = w.Spec.Template.Spec.Containers
+	}
+	for _, container := range containers {
+		if container.Name == "postgresql" {
+			return workload, container.Image, nil
+		}
+	}
+
+	return workload, "", nil
+}
+
+// postgresqlVersions returns the major versions of the data directory and of the image of the CR
+// reported by the version Job, or empty versions until it has completed
+func (r *ManageIQReconciler) postgresqlVersions(cr *miqv1alpha1.ManageIQ) (string, string, error) {
+	pods := &corev1.PodList{}
+	if err := r.Client.List(context.TODO(), pods, client.InNamespace(cr.Namespace), client.MatchingLabels{"app": cr.Spec.AppName, "name": "postgresql"}); err != nil {
+		return "", "", err
+	}
+
+	// The PVC can only be mounted on the node of a running database pod
+	nodeName := ""
+	for _, pod := range pods.Items {
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == miqtool.PostgresqlClaimName(cr) {
+				nodeName = pod.Spec.NodeName
+			}
+		}
+	}
+
+	job, mutateFunc := miqtool.PostgresqlVersionJob(cr, nodeName, r.Client, r.Scheme)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, job, mutateFunc); err != nil {
+		return "", "", err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("Job has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, job, result)
+	}
+
+	// The Job of a previous image change is recreated for the image of the CR
+	if job.Spec.Template.Spec.Containers[0].Image != cr.Spec.PostgresqlImage {
+		return "", "", r.deleteJobs(job)
+	}
+
+	switch {
+	case job.Status.Succeeded > 0:
+	case jobFailed(job):
+		return "", "", fmt.Errorf("Job %s checking the database version failed, delete it to retry", job.Name)
+	default:
+		logger.Info("Waiting for the database version", "component", "postgresql", "job", job.Name)
+		return "", "", nil
+	}
+
+	message, err := r.jobTerminationMessage(job)
+	if err != nil {
+		return "", "", err
+	}
+	if versions := strings.Fields(message); len(versions) == 2 {
+		return versions[0], versions[1], nil
+	}
+
+	return "", "", fmt.Errorf("Job %s did not report the database version, delete it to retry", job.Name)
+}
+
+// runPostgresqlUpgrade scales down the application and the database, copies the data directory to
+// the upgrade backup PVC and runs pg_upgrade with the binaries of the previous image. It reports
+// whether the upgrade has completed, a failed Job keeps the application scaled down.
+func (r *ManageIQReconciler) runPostgresqlUpgrade(cr *miqv1alpha1.ManageIQ, workload client.Object, image string) (bool, error) {
+	var zero int32 = 0
+	var replicas *int32
+	patch := client.MergeFrom(workload.DeepCopyObject().(client.Object))
+	switch w := workload.(type) {
+	case *appsv1.Deployment:
+		replicas, w.Spec.Replicas = w.Spec.Replicas, &zero
+	case *appsv1.StatefulSet:
+		replicas, w.Spec.Replicas = w.Spec.Replicas, &zero
+	}
+	if replicas == nil || *replicas != 0 {
+		if err := r.Client.Patch(context.TODO(), workload, patch); err != nil {
+			return false, err
+		}
+		logger.Info("Scaled down the database for the upgrade", "component", "postgresql", "kind", r.objectKind(workload))
+	}
+
+	selectors := []client.ListOption{
+		client.MatchingLabels{"app": cr.Spec.AppName, "name": "orchestrator"},
+		client.HasLabels{cr.Spec.AppName + "-orchestrated-by"},
+		client.MatchingLabels{"app": cr.Spec.AppName, "name": "postgresql"},
+	}
+	for _, selector := range selectors {
+		pods := &corev1.PodList{}
+		if err := r.Client.List(context.TODO(), pods, client.InNamespace(cr.Namespace), selector); err != nil {
+			return false, err
+		}
+		if len(pods.Items) > 0 {
+			logger.Info("Waiting for the application and the database to stop", "component", "postgresql", "pods", len(pods.Items))
+			return false, nil
+		}
+	}
+
+	pvc, mutateFunc := miqtool.PostgresqlUpgradeBackupPVC(cr, r.Scheme)
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, pvc, mutateFunc); err != nil {
+		return false, err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("PVC has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, pvc, result)
+	}
+
+	backupJob, mutateFunc := miqtool.PostgresqlUpgradeBackupJob(cr, r.Client, r.Scheme)
+	if done, err := r.runPostgresqlUpgradeJob(cr, backupJob, mutateFunc, "BackingUp", fmt.Sprintf("Copying the data directory to PVC %s", pvc.Name)); err != nil || !done {
+		return false, err
+	}
+
+	upgradeJob, mutateFunc := miqtool.PostgresqlUpgradeJob(cr, image, r.Client, r.Scheme)
+	if done, err := r.runPostgresqlUpgradeJob(cr, upgradeJob, mutateFunc, "Upgrading", "Running pg_upgrade on the data directory"); err != nil || !done {
+		return false, err
+	}
+
+	versionJob, _ := miqtool.PostgresqlVersionJob(cr, "", r.Client, r.Scheme)
+	if err := r.deleteJobs(backupJob, upgradeJob, versionJob); err != nil {
+		return false, err
+	}
+
+	logger.Info("Database upgrade has completed", "component", "postgresql", "image", cr.Spec.PostgresqlImage)
+	r.Recorder.Eventf(cr, corev1.EventTypeNormal, "PostgresqlUpgraded", "Database has been upgraded to image %s, the data directory before the upgrade is kept in PVC %s", cr.Spec.PostgresqlImage, pvc.Name)
+	r.reportStatusCondition(cr, fmt.Sprintf("Upgraded to image %s, the data directory before the upgrade is kept in PVC %s", cr.Spec.PostgresqlImage, pvc.Name), "Upgraded", metav1.ConditionFalse, miqtool.PostgresqlUpgradingCondition)
+
+	return true, nil
+}
+
+// runPostgresqlUpgradeJob reconciles a Job of the upgrade, recording it as the current step in the
+// PostgresqlUpgrading condition, and reports whether it has completed
+func (r *ManageIQReconciler) runPostgresqlUpgradeJob(cr *miqv1alpha1.ManageIQ, job *batchv1.Job, mutateFunc controllerutil.MutateFn, reason string, message string) (bool, error) {
+	if result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, job, mutateFunc); err != nil {
+		return false, err
+	} else if result != controllerutil.OperationResultNone {
+		logger.Info("Job has been reconciled", "component", "postgresql", "result", result)
+		r.recordReconcileEvent(cr, job, result)
+	}
+
+	switch {
+	case job.Status.Succeeded > 0:
+		return true, nil
+	case jobFailed(job):
+		message = fmt.Sprintf("Job %s failed, delete it to retry", job.Name)
+		r.reportStatusCondition(cr, message, "UpgradeFailed", metav1.ConditionTrue, miqtool.PostgresqlUpgradingCondition)
+		return false, fmt.Errorf("%s, the application stays scaled down", message)
+	default:
+		r.reportStatusCondition(cr, fmt.Sprintf("%s, waiting for Job %s", message, job.Name), reason, metav1.ConditionTrue, miqtool.PostgresqlUpgradingCondition)
+		logger.Info("Waiting for the database upgrade", "component", "postgresql", "job", job.Name)
+		return false, nil
+	}
+}
+
+func (r *ManageIQReconciler) deleteJobs(jobs ...*batchv1.Job) error {
+	for _, job := range jobs {
+		if err := r.Client.Delete(context.TODO(), job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqtool "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/miq-components"
+	miqkafka "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/miq-components/kafka"
+)
+
+//+kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get
+
+// checkVolumeExpansion returns the existing claims whose storage request is lower than the
+// capacity of the CR. Shrinking a claim, or growing one whose StorageClass does not allow volume
+// expansion, is refused.
+func (r *ManageIQReconciler) checkVolumeExpansion(field string, capacity string, claims ...*corev1.PersistentVolumeClaim) ([]*corev1.PersistentVolumeClaim, error) {
+	requested, err := resource.ParseQuantity(capacity)
+	if err != nil {
+		return nil, fmt.Errorf("%s %q is not a valid quantity: %v", field, capacity, err)
+	}
+
+	expanding := []*corev1.PersistentVolumeClaim{}
+	for _, pvc := range claims {
+		if err := r.Client.Get(context.TODO(), client.ObjectKeyFromObject(pvc), pvc); errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		current := pvc.Spec.Resources.Requests.Storage()
+		switch requested.Cmp(*current) {
+		case 0:
+			continue
+		case -1:
+			return nil, fmt.Errorf("%s %s is lower than the %s of PVC %s, volumes can not be shrunk", field, capacity, current.String(), pvc.Name)
+		}
+
+		if allowed, storageClass, err := miqtool.StorageClassAllowsExpansion(pvc, r.Client); err != nil {
+			return nil, err
+		} else if storageClass == "" {
+			return nil, fmt.Errorf("PVC %s has no StorageClass, it can not be grown to the %s %s", pvc.Name, field, capacity)
+		} else if !allowed {
+			return nil, fmt.Errorf("StorageClass %s of PVC %s does not allow volume expansion, it can not be grown to the %s %s", storageClass, pvc.Name, field, capacity)
+		}
+
+		expanding = append(expanding, pvc)
+	}
+
+	return expanding, nil
+}
+
+// expandVolumeClaims grows the storage request of the claims of the database to the capacity of
+// the CR, the volumes are then resized by the storage provider and the kubelet
+func (r *ManageIQReconciler) expandVolumeClaims(cr *miqv1alpha1.ManageIQ, field string, capacity string, claims ...*corev1.PersistentVolumeClaim) error {
+	expanding, err := r.checkVolumeExpansion(field, capacity, claims...)
+	if err != nil {
+		return err
+	}
+
+	requested := resource.MustParse(capacity)
+	for _, pvc := range expanding {
+		current := pvc.Spec.Resources.Requests.Storage().String()
+
+		patch := client.MergeFrom(pvc.DeepCopy())
+		pvc.Spec.Resources.Requests[corev1.ResourceStorage] = requested
+		if err := r.Client.Patch(context.TODO(), pvc, patch); err != nil {
+			return err
+		}
+
+		logger.Info("PVC storage request has been grown", "pvc", pvc.Name, "from", current, "to", capacity)
+		r.Recorder.Eventf(cr, corev1.EventTypeNormal, "VolumeExpanding", "Growing PVC %s from %s to %s", pvc.Name, current, capacity)
+	}
+
+	return nil
+}
+
+// checkKafkaVolumeExpansion checks the claims created by Strimzi before the storage size of the
+// Kafka CR is changed, Strimzi grows the claims itself
+func (r *ManageIQReconciler) checkKafkaVolumeExpansion(cr *miqv1alpha1.ManageIQ) error {
+	for _, component := range []struct{ name, field, capacity string }{
+		{"kafka", "KafkaVolumeCapacity", cr.Spec.KafkaVolumeCapacity},
+		{"zookeeper", "ZookeeperVolumeCapacity", cr.Spec.ZookeeperVolumeCapacity},
+	} {
+		pvcs := &corev1.PersistentVolumeClaimList{}
+		if err := r.Client.List(context.TODO(), pvcs, client.InNamespace(cr.Namespace), client.MatchingLabels(miqkafka.KafkaVolumeClaimLabels(cr, component.name))); err != nil {
+			return err
+		}
+
+		claims := []*corev1.PersistentVolumeClaim{}
+		for i := range pvcs.Items {
+			claims = append(claims, &pvcs.Items[i])
+		}
+		if _, err := r.checkVolumeExpansion(component.field, component.capacity, claims...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reportVolumeResizing sets the Resizing condition while the volumes of the database or of Kafka
+// have not reached their storage request, and returns whether any of them is being resized
+func (r *ManageIQReconciler) reportVolumeResizing(cr *miqv1alpha1.ManageIQ) (bool, error) {
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := r.Client.List(context.TODO(), pvcs, client.InNamespace(cr.Namespace)); err != nil {
+		return false, err
+	}
+
+	resizing := []string{}
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		if !metav1.IsControlledBy(pvc, cr) && pvc.Labels["strimzi.io/cluster"] != cr.Spec.AppName {
+			continue
+		}
+		if miqtool.VolumeClaimResizing(pvc) {
+			resizing = append(resizing, pvc.Name)
+		}
+	}
+	sort.Strings(resizing)
+
+	if len(resizing) > 0 {
+		r.reportStatusCondition(cr, "Waiting for the volumes of PVCs: "+strings.Join(resizing, ", "), "Resizing", metav1.ConditionTrue, miqtool.VolumeResizingCondition)
+		return true, nil
+	}
+
+	if condition := apimeta.FindStatusCondition(cr.Status.Conditions, miqtool.VolumeResizingCondition); condition != nil && condition.Status == metav1.ConditionTrue {
+		logger.Info("Volumes have been resized")
+		r.Recorder.Event(cr, corev1.EventTypeNormal, "VolumeExpanded", "The volumes have reached their requested capacity")
+		r.reportStatusCondition(cr, "The volumes have reached their requested capacity", "Resized", metav1.ConditionFalse, miqtool.VolumeResizingCondition)
+	}
+
+	return false, nil
+}
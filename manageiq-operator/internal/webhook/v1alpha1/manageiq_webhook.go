@@ -0,0 +1,98 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	miqv1alpha1 "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1"
+	miqtool "github.com/ManageIQ/manageiq-pods/manageiq-operator/api/v1alpha1/helpers/miq-components"
+)
+
+var manageiqlog = logf.Log.WithName("manageiq-webhook")
+
+// SetupManageIQWebhookWithManager registers the webhooks for ManageIQ in the manager.
+// v1alpha1 is the conversion hub so this also serves the /convert endpoint for v1beta1.
+func SetupManageIQWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &miqv1alpha1.ManageIQ{}).
+		WithDefaulter(&ManageIQCustomDefaulter{}).
+		WithValidator(&ManageIQCustomValidator{Client: mgr.GetAPIReader()}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-manageiq-org-v1alpha1-manageiq,mutating=true,failurePolicy=fail,sideEffects=None,groups=manageiq.org,resources=manageiqs,verbs=create;update,versions=v1alpha1,name=mmanageiq-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// ManageIQCustomDefaulter sets the spec defaults when a ManageIQ is created or updated.
+// Defaults which depend on the cluster (image pull secret, server GUID) are still set by the controller.
+type ManageIQCustomDefaulter struct{}
+
+func (d *ManageIQCustomDefaulter) Default(_ context.Context, cr *miqv1alpha1.ManageIQ) error {
+	manageiqlog.Info("Defaulting ManageIQ", "name", cr.GetName())
+
+	miqtool.DefaultCR(cr)
+
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-manageiq-org-v1alpha1-manageiq,mutating=false,failurePolicy=fail,sideEffects=None,groups=manageiq.org,resources=manageiqs,verbs=create;update,versions=v1alpha1,name=vmanageiq-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// ManageIQCustomValidator rejects invalid ManageIQ objects before they are persisted
+type ManageIQCustomValidator struct {
+	Client client.Reader
+}
+
+func (v *ManageIQCustomValidator) ValidateCreate(ctx context.Context, cr *miqv1alpha1.ManageIQ) (admission.Warnings, error) {
+	manageiqlog.Info("Validating ManageIQ create", "name", cr.GetName())
+
+	// The object names are prefixed with the AppName, instances sharing a namespace need their own
+	manageiqs := &miqv1alpha1.ManageIQList{}
+	if err := v.Client.List(ctx, manageiqs, client.InNamespace(cr.Namespace)); err != nil {
+		return nil, err
+	}
+	for _, other := range manageiqs.Items {
+		if other.Name != cr.Name && other.Spec.AppName == cr.Spec.AppName {
+			return nil, fmt.Errorf("ManageIQ %s already uses the appName %s in namespace %s", other.Name, cr.Spec.AppName, cr.Namespace)
+		}
+	}
+
+	return nil, cr.Validate()
+}
+
+func (v *ManageIQCustomValidator) ValidateUpdate(_ context.Context, oldCR, newCR *miqv1alpha1.ManageIQ) (admission.Warnings, error) {
+	manageiqlog.Info("Validating ManageIQ update", "name", newCR.GetName())
+
+	// Never block the removal of the finalizer from a CR which is being deleted
+	if !newCR.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	if err := newCR.ValidateUpdate(oldCR); err != nil {
+		return nil, err
+	}
+
+	return nil, newCR.Validate()
+}
+
+func (v *ManageIQCustomValidator) ValidateDelete(_ context.Context, _ *miqv1alpha1.ManageIQ) (admission.Warnings, error) {
+	return nil, nil
+}
@@ -61,17 +61,19 @@ class CertGenerator
 end
 
 
-application_domain = ARGV[0]
-if ARGV.length != 1 || application_domain.length == 0
-  puts "Usage: cert_generator your.application.domain.example.com"
+application_domain, app_name = ARGV
+app_name ||= "manageiq"
+if ARGV.length < 1 || ARGV.length > 2 || application_domain.length == 0
+  puts "Usage: cert_generator your.application.domain.example.com [app-name]"
   exit 1
 end
 
+# The services are prefixed with the app name of the ManageIQ CR
 c = CertGenerator.new
-c.generate_cert("httpd")
+c.generate_cert("httpd", "httpd", "#{app_name}-httpd")
 c.generate_cert("kafka")
-c.generate_cert("memcached")
-c.generate_cert("postgresql")
+c.generate_cert("memcached", "memcached", "#{app_name}-memcached")
+c.generate_cert("postgresql", "postgresql", "#{app_name}-postgresql")
 
 c.generate_cert("api", application_domain)
 c.generate_cert("remote-console", application_domain)